@@ -0,0 +1,185 @@
+/*
+Copyright © 2023 containeroo hello©containeroo.ch
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"certalert/internal/config"
+	"certalert/internal/resolve"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// configCmd represents the config command
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Validate the config file, print its JSON Schema or encrypt values.",
+	Long: `Config contains commands to work with the config file without starting CertAlert.
+
+Examples:
+	# Validate the config file passed with --config
+	certalert config validate --config config.yaml
+
+	# Validate a config file
+	certalert config validate config.yaml
+
+	# Print the JSON Schema of the config file
+	certalert config schema > certalert.schema.json
+
+	# Encrypt a password for the identity passed with --age-identity
+	echo -n 'changeit' | certalert config encrypt --age-identity key.txt
+	`,
+	Annotations: map[string]string{skipConfigReadCheck: "true"},
+}
+
+var validateRunCommands bool
+
+// configValidateCmd represents the config validate command
+var configValidateCmd = &cobra.Command{
+	Use:   "validate [file]",
+	Short: "Validate the config file.",
+	Long: `Validate checks the config file against the JSON Schema of CertAlert and runs semantic checks:
+duplicate certificate names, certificate types which can't be inferred, unresolvable 'env:' and
+'file:' references and missing certificate files.
+
+The files of 'include' and of the directory passed with --config-dir are validated as well. Certificate
+names must be unique across all files.
+
+The commands of 'exec:' references are not run, only their executables must exist. With --run-commands,
+the commands are run and must succeed, which can have side effects like prompting a password manager.
+
+All problems are printed with their line numbers. The command exits with status 1 if a problem is found,
+so it can be used to check a config file in CI before it is rolled out.
+	`,
+	Args:        cobra.MaximumNArgs(1),
+	Annotations: map[string]string{skipConfigReadCheck: "true"},
+	Run: func(cmd *cobra.Command, args []string) {
+		configPath := viper.ConfigFileUsed()
+		if len(args) == 1 {
+			configPath = args[0]
+		}
+		if configPath == "" {
+			fmt.Println("Please provide a config file as argument or use the --config flag")
+			cmd.Help()
+			os.Exit(1)
+		}
+
+		findings, err := config.Validate(configPath, config.ValidateOptions{
+			ConfigDir:   config.App.ConfigDir,
+			RunCommands: validateRunCommands,
+		})
+		if err != nil {
+			log.Fatal().Msgf("Failed to validate config file: %v", err)
+		}
+
+		if len(findings) == 0 {
+			log.Info().Msgf("Config file '%s' is valid", configPath)
+			return
+		}
+
+		for _, finding := range findings {
+			fmt.Printf("%s: %s\n", finding.File, finding)
+		}
+		os.Exit(1)
+	},
+}
+
+// configSchemaCmd represents the config schema command
+var configSchemaCmd = &cobra.Command{
+	Use:   "schema",
+	Short: "Print the JSON Schema of the config file.",
+	Long: `Schema prints the JSON Schema of the config file. It can be used by editors to complete and
+validate the config file, e.g. with the comment '# yaml-language-server: $schema=certalert.schema.json'.
+	`,
+	Args:        cobra.NoArgs,
+	Annotations: map[string]string{skipConfigReadCheck: "true"},
+	Run: func(cmd *cobra.Command, args []string) {
+		schema, err := json.MarshalIndent(config.GenerateSchema(), "", "  ")
+		if err != nil {
+			log.Fatal().Msgf("Failed to generate schema: %v", err)
+		}
+		fmt.Println(string(schema))
+	},
+}
+
+var encryptRecipients []string
+
+// configEncryptCmd represents the config encrypt command
+var configEncryptCmd = &cobra.Command{
+	Use:   "encrypt [value]",
+	Short: "Encrypt a value for the config file.",
+	Long: `Encrypt encrypts a value with age and prints it as 'enc:' value, which can be committed in the
+config file instead of the plain value. CertAlert decrypts it with the identity file passed with
+--age-identity, the CERTALERT_AGE_IDENTITY or the SOPS_AGE_KEY_FILE environment variable.
+
+The value is read from the standard input if it isn't passed as argument, so it doesn't end up in the
+shell history. A trailing newline is removed.
+
+The value is encrypted for the recipients passed with --recipient. Without recipients, it is encrypted
+for the identities of the identity file.
+
+Examples:
+	# Encrypt a password for the identity file
+	echo -n 'changeit' | certalert config encrypt --age-identity key.txt
+
+	# Encrypt a password for the public keys of the servers running CertAlert
+	certalert config encrypt --recipient age1ql3z7hjy54pw3hyww5ayyfg7zqgvc7w3j2elw8zmrj2kg5sfn9aqmcac8p changeit
+	`,
+	Args:        cobra.MaximumNArgs(1),
+	Annotations: map[string]string{skipConfigReadCheck: "true"},
+	Run: func(cmd *cobra.Command, args []string) {
+		var value string
+		if len(args) == 1 {
+			value = args[0]
+		} else {
+			data, err := io.ReadAll(os.Stdin)
+			if err != nil {
+				log.Fatal().Msgf("Failed to read value: %v", err)
+			}
+			value = strings.TrimSuffix(strings.TrimSuffix(string(data), "\n"), "\r")
+		}
+
+		recipients := encryptRecipients
+		if len(recipients) == 0 {
+			var err error
+			if recipients, err = resolve.IdentityRecipients(resolve.AgeIdentityFile); err != nil {
+				log.Fatal().Msgf("Failed to read recipients: %v", err)
+			}
+		}
+
+		encrypted, err := resolve.Encrypt(value, recipients)
+		if err != nil {
+			log.Fatal().Msgf("Failed to encrypt value: %v", err)
+		}
+		fmt.Println(encrypted)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(configCmd)
+	configCmd.AddCommand(configValidateCmd)
+	configCmd.AddCommand(configSchemaCmd)
+	configCmd.AddCommand(configEncryptCmd)
+
+	configValidateCmd.Flags().BoolVar(&validateRunCommands, "run-commands", false, "Run the commands of 'exec:' references instead of only checking that their executables exist.")
+	configEncryptCmd.Flags().StringSliceVarP(&encryptRecipients, "recipient", "r", nil, "The age public key to encrypt the value for. Can be repeated.")
+}
@@ -0,0 +1,118 @@
+/*
+Copyright © 2023 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"certalert/internal/certificates"
+	"certalert/internal/config"
+	"certalert/internal/print"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	lintAll          bool
+	lintOutputFormat string
+)
+
+// lintCmd represents the lint command
+var lintCmd = &cobra.Command{
+	Use:   "lint",
+	Short: "Check certificates against the cryptographic policy.",
+	Long: fmt.Sprintf(`Lint checks certificates against the cryptographic policy and prints the violated rules.
+
+Available rules are: %s. The severity of each rule can be configured in the 'policy' section
+of the config file. The command exits with status 1 if a rule with the severity 'error' is violated.
+
+Examples:
+	# Lint all certificates
+	certalert lint --all
+
+	# Lint the certificate with the name 'my-cert' and print the violations in json format
+	certalert lint my-cert --output json
+	`, strings.Join(certificates.PolicyRules, ", ")),
+	Run: func(cmd *cobra.Command, args []string) {
+		if !slices.Contains(supportedOutputFormats, lintOutputFormat) {
+			fmt.Printf("Unsupported output format: %s. Supported formats are: %s\n", lintOutputFormat, strings.Join(supportedOutputFormats, ", "))
+			cmd.Help()
+			os.Exit(1)
+		}
+
+		// Parse config file in subcommand, because it is not needed for all subcommands
+		// or there is a special order in which the flags should be parsed
+		if err := config.App.Parse(); err != nil {
+			log.Fatal().Msgf("Error parsing config file: %v", err)
+		}
+
+		certs := config.App.Certs
+		if !lintAll {
+			// Handle arguments
+			if len(args) < 1 {
+				fmt.Println("Please provide at least one argument or use the --all flag")
+				cmd.Help()
+				os.Exit(1)
+			}
+
+			certs = nil
+			for _, arg := range args {
+				certificate, err := certificates.GetCertificateByName(arg, config.App.Certs)
+				if err != nil {
+					log.Fatal().Msgf("Failed to lint certificate: %v", err)
+				}
+				certs = append(certs, *certificate)
+			}
+		}
+
+		violations, err := print.LintCertificates(certs, config.App.Policy, config.App.FailOnError)
+		if err != nil {
+			log.Fatal().Msgf("Failed to lint certificates: %v", err)
+		}
+
+		if len(violations) == 0 {
+			log.Info().Msg("No policy violations found")
+			return
+		}
+
+		output, err := print.ConvertPolicyViolationsToFormat(lintOutputFormat, violations)
+		if err != nil {
+			log.Fatal().Msgf("Failed to convert policy violations: %v", err)
+		}
+		fmt.Println(output)
+
+		for _, violation := range violations {
+			if violation.Severity == certificates.SeverityError {
+				os.Exit(1)
+			}
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(lintCmd)
+
+	lintCmd.PersistentFlags().BoolVarP(&lintAll, "all", "A", false, "Lints all certificates")
+
+	lintCmd.Flags().StringVarP(&lintOutputFormat, "output", "o", "text", fmt.Sprintf("Output format. One of: %s", strings.Join(supportedOutputFormats, "|")))
+	lintCmd.RegisterFlagCompletionFunc("output", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return supportedOutputFormats, cobra.ShellCompDirectiveDefault
+	})
+}
@@ -0,0 +1,87 @@
+package cmd
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// commandArgsEnv is the environment variable passing the arguments of a command run by runCommand.
+const commandArgsEnv = "CERTALERT_TEST_COMMAND_ARGS"
+
+// TestMain runs the command of the arguments in commandArgsEnv instead of the tests, so commands which
+// exit the process can be tested in a subprocess.
+func TestMain(m *testing.M) {
+	if args, found := os.LookupEnv(commandArgsEnv); found {
+		rootCmd.SetArgs(strings.Split(args, "\n"))
+		if err := rootCmd.Execute(); err != nil {
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// runCommand runs certalert with the given arguments in a subprocess.
+//
+// Returns:
+//   - string
+//     The combined output of the command.
+//   - int
+//     The exit code of the command.
+func runCommand(t *testing.T, args ...string) (string, int) {
+	cmd := exec.Command(os.Args[0])
+	cmd.Env = append(os.Environ(), commandArgsEnv+"="+strings.Join(args, "\n"))
+	output, err := cmd.CombinedOutput()
+
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return string(output), exitErr.ExitCode()
+	}
+	if err != nil {
+		t.Fatalf("Failed to run command: %v", err)
+	}
+	return string(output), 0
+}
+
+// writeCommandConfig writes a config file with a single certificate and returns its path.
+func writeCommandConfig(t *testing.T) string {
+	certPath, err := filepath.Abs("../tests/certs/pem/final.crt")
+	if err != nil {
+		t.Fatalf("Failed to resolve certificate path: %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte("certs:\n  - name: final\n    path: "+certPath+"\n"), 0o644); err != nil {
+		t.Fatalf("Failed to write config file: %v", err)
+	}
+	return path
+}
+
+func TestLintCommand(t *testing.T) {
+	configPath := writeCommandConfig(t)
+
+	t.Run("lints a certificate", func(t *testing.T) {
+		output, code := runCommand(t, "lint", "final", "--config", configPath)
+		assert.Equal(t, 0, code, output)
+		assert.Contains(t, output, "No policy violations found")
+	})
+
+	t.Run("unknown certificate name", func(t *testing.T) {
+		output, code := runCommand(t, "lint", "unknown", "--config", configPath)
+		assert.Equal(t, 1, code, output)
+		assert.Contains(t, output, "Failed to lint certificate: Certificate 'unknown' not found")
+		assert.NotContains(t, output, "panic")
+	})
+
+	t.Run("unknown output format", func(t *testing.T) {
+		output, code := runCommand(t, "lint", "final", "--config", configPath, "--output", "xml")
+		assert.Equal(t, 1, code, output)
+		assert.Contains(t, output, "Unsupported output format: xml.")
+	})
+}
@@ -31,6 +31,7 @@ import (
 
 var (
 	printAll               bool
+	printDedup             bool
 	outputFormat           string
 	supportedOutputFormats = []string{"text", "json", "yaml"}
 )
@@ -53,6 +54,9 @@ Examples:
 
 	# Print only the certificate with the name 'my-cert' in yaml format
 	certalert print my-cert --output yaml
+
+	# Print every distinct certificate once, together with the locations containing it
+	certalert print --all --dedup
 	`,
 	Run: func(cmd *cobra.Command, args []string) {
 		if !slices.Contains(supportedOutputFormats, outputFormat) {
@@ -67,9 +71,14 @@ Examples:
 			log.Fatal().Msgf("Error parsing config file: %v", err)
 		}
 
+		convert := print.ConvertCertificatesToFormat
+		if printDedup {
+			convert = print.ConvertDeduplicatedCertificatesToFormat
+		}
+
 		if printAll {
 			// Handle --all flag
-			output, err := print.ConvertCertificatesToFormat(outputFormat, config.App.Certs, config.App.FailOnError)
+			output, err := convert(outputFormat, config.App.Certs, config.App.FailOnError)
 			if err != nil {
 				log.Fatal().Err(err)
 			}
@@ -95,7 +104,7 @@ Examples:
 		}
 
 		// Print the certificates
-		output, err := print.ConvertCertificatesToFormat(outputFormat, certs, config.App.FailOnError)
+		output, err := convert(outputFormat, certs, config.App.FailOnError)
 		if err != nil {
 			log.Fatal().Err(err)
 		}
@@ -107,6 +116,7 @@ func init() {
 	rootCmd.AddCommand(printCmd)
 
 	printCmd.PersistentFlags().BoolVarP(&printAll, "all", "A", false, "Prints all certificates")
+	printCmd.Flags().BoolVar(&printDedup, "dedup", false, "Prints every distinct certificate once, together with the locations containing it")
 
 	printCmd.Flags().StringVarP(&outputFormat, "output", "o", "text", fmt.Sprintf("Output format. One of: %s", strings.Join(supportedOutputFormats, "|")))
 	printCmd.RegisterFlagCompletionFunc("output", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
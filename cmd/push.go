@@ -65,7 +65,8 @@ Examples:
 				config.App.Pushgateway.Auth,
 				config.App.Certs,
 				config.App.Pushgateway.InsecureSkipVerify,
-				config.App.FailOnError); err != nil {
+				config.App.FailOnError,
+				config.App.Labels); err != nil {
 				log.Fatal().Err(err)
 			}
 			return
@@ -89,7 +90,8 @@ Examples:
 				config.App.Pushgateway.Auth,
 				[]certificates.Certificate{*certificate},
 				config.App.Pushgateway.InsecureSkipVerify,
-				config.App.FailOnError); err != nil {
+				config.App.FailOnError,
+				config.App.Labels); err != nil {
 				log.Panic().Err(err)
 			}
 		}
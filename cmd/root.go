@@ -18,6 +18,7 @@ package cmd
 import (
 	"certalert/internal/certificates"
 	"certalert/internal/config"
+	"certalert/internal/resolve"
 	"certalert/internal/utils"
 	"fmt"
 	"os"
@@ -33,11 +34,15 @@ const (
 	version = "v0.0.32"
 )
 
+// skipConfigReadCheck is the annotation of commands which handle an unreadable config file themselves.
+const skipConfigReadCheck = "skipConfigReadCheck"
+
 var (
-	cfgFile      string
-	verbose      bool
-	silent       bool
-	printVersion bool
+	cfgFile       string
+	verbose       bool
+	silent        bool
+	printVersion  bool
+	configReadErr error // configReadErr is the error of reading the config file, checked after the logger is configured
 )
 
 // rootCmd represents the base command when called without any subcommands
@@ -81,6 +86,10 @@ var rootCmd = &cobra.Command{
 			zerolog.SetGlobalLevel(zerolog.TraceLevel)
 			log.Debug().Msgf("Silent output enabled")
 		}
+
+		if configReadErr != nil && cmd.Annotations[skipConfigReadCheck] == "" {
+			log.Fatal().Msgf("Error reading config file: %v", configReadErr)
+		}
 	},
 	Run: func(cmd *cobra.Command, args []string) {
 		// Enter here if no subcommand is specified
@@ -109,6 +118,11 @@ func init() {
 	cobra.OnInitialize(initConfig)
 
 	rootCmd.PersistentFlags().StringVarP(&cfgFile, "config", "c", "", "Path to the configuration file (Default: $HOME/.certalert.yaml).")
+	rootCmd.PersistentFlags().StringVar(&config.App.ConfigDir, "config-dir", "", "Directory with config fragments which are merged into the configuration file.")
+
+	rootCmd.PersistentFlags().StringVar(&resolve.SecretsDir, "secrets-dir", resolve.SecretsDir, "Directory with the mounted secrets of 'secretKeyRef:' references (Env: CERTALERT_SECRETS_DIR).")
+	rootCmd.PersistentFlags().StringVar(&resolve.AgeIdentityFile, "age-identity", resolve.AgeIdentityFile, "Path to the age identity file to decrypt 'enc:' and 'enc:sops:' values (Env: CERTALERT_AGE_IDENTITY or SOPS_AGE_KEY_FILE).")
+	rootCmd.PersistentFlags().DurationVar(&resolve.ExecTimeout, "exec-timeout", resolve.ExecTimeout, "Time a command of an 'exec:' reference may run.")
 
 	rootCmd.PersistentFlags().BoolVarP(&verbose, "verbose", "v", false, "Activates verbose output for detailed logging.")
 	rootCmd.PersistentFlags().BoolVarP(&silent, "silent", "s", false, "Enables silent mode, displaying only errors.")
@@ -136,7 +150,7 @@ func initConfig() {
 
 	viper.AutomaticEnv() // read in environment variables that match
 
-	if err := config.App.Read(viper.ConfigFileUsed()); err != nil {
-		log.Fatal().Msgf("Error reading config file: %v", err)
-	}
+	// The flags are recorded before the config file is read into them, so a reload starts from the flags again
+	config.SetFlagSettings(config.App)
+	configReadErr = config.App.Read(viper.ConfigFileUsed())
 }
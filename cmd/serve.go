@@ -19,7 +19,6 @@ import (
 	"certalert/internal/config"
 	"certalert/internal/handlers"
 	"certalert/internal/server"
-	"certalert/internal/utils"
 
 	"github.com/fsnotify/fsnotify"
 	"github.com/rs/zerolog/log"
@@ -52,50 +51,37 @@ Endpoints:
 
 `,
 	Run: func(cmd *cobra.Command, args []string) {
-		// Watch for config changes
-		viper.OnConfigChange(func(e fsnotify.Event) {
-			log.Info().Msgf("Config file changed: %s", e.Name)
-
-			if err := config.App.Read(viper.ConfigFileUsed()); err != nil {
-				log.Fatal().Msgf("Error reading config file: %v", err)
-			}
-
-			if err := utils.DeepCopy(config.App, &config.AppCopy); err != nil {
-				log.Fatal().Msgf("Unable to copy config: %s", err)
-			}
-
-			if err := config.App.Parse(); err != nil {
-				log.Fatal().Msgf("Unable to parse config: %s", err)
-			}
-
-			if err := config.RedactConfig(&config.AppCopy); err != nil {
-				log.Fatal().Msgf("Unable to redact config: %s", err)
-			}
-		})
-
-		if config.App.AutoReloadConfig {
-			log.Debug().Msg("Auto reloading configuration is enabled")
-			viper.WatchConfig()
-		}
-
 		config.App.Version = version
 
 		// this is only necessary if starting the web server
-		if err := utils.DeepCopy(config.App, &config.AppCopy); err != nil {
-			log.Fatal().Msgf("Unable to copy config: %s", err)
+		snapshot, err := config.NewSnapshot(config.App)
+		if err != nil {
+			log.Fatal().Msgf("Error loading config file: %v", err)
 		}
+		config.Activate(snapshot)
 
-		if err := config.App.Parse(); err != nil {
-			log.Fatal().Msgf("Error parsing config file: %v", err)
+		// Watch for config changes. An invalid config is logged and the previous config keeps serving.
+		reloadOnChange := func(e fsnotify.Event) {
+			log.Info().Msgf("Config file changed: %s", e.Name)
+			if err := config.Reload(viper.ConfigFileUsed()); err != nil {
+				log.Error().Msgf("Failed to reload configuration, keeping the previous configuration. %s", err)
+			}
 		}
+		viper.OnConfigChange(reloadOnChange)
 
-		if err := config.RedactConfig(&config.AppCopy); err != nil {
-			log.Fatal().Msgf("Unable to redact config: %s", err)
+		if snapshot.Config.AutoReloadConfig {
+			log.Debug().Msg("Auto reloading configuration is enabled")
+			viper.WatchConfig()
+
+			// viper only watches the config file, the included files are watched separately
+			if err := config.WatchIncludes(reloadOnChange); err != nil {
+				log.Error().Msgf("Failed to watch included config files. %s", err)
+			}
 		}
 
 		// Collect handlers and start the server
 		handlers.Collect()
-		server.Run(config.App.Server.ListenAddress)
+		server.Run(snapshot.Config.Server.ListenAddress)
 	},
 }
 
@@ -0,0 +1,14 @@
+package certificates
+
+// PruneCaches drops the cached content, clients and credentials of sources which are not in the
+// certificates anymore, so the caches don't grow with every change of the config. It is called when a
+// new config snapshot is activated.
+//
+// Parameters:
+//   - certs: []Certificate
+//     The certificates of the new config.
+func PruneCaches(certs []Certificate) {
+	pruneURLCache(certs)
+	pruneHTTPClients(certs)
+	pruneVaultCaches(certs)
+}
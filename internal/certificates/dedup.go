@@ -0,0 +1,97 @@
+package certificates
+
+import (
+	"fmt"
+)
+
+// ProcessDeduplicated processes a list of certificates and groups the extracted certificates by their
+// SHA-256 fingerprint.
+//
+// Parameters:
+//   - certificates: []Certificate
+//     A slice of Certificate structs representing the certificates to process.
+//   - failOnError: bool
+//     A flag indicating whether to fail immediately on encountering an error.
+//
+// Returns:
+//   - []DeduplicatedCertificateInfo
+//     One entry per distinct certificate. See Deduplicate.
+//   - []CertificateInfo
+//     The entries of the certificates which could not be extracted.
+//   - error
+//     An error, if any, encountered during the processing.
+func ProcessDeduplicated(certificates []Certificate, failOnError bool) ([]DeduplicatedCertificateInfo, []CertificateInfo, error) {
+	certInfoList, err := Process(certificates, failOnError)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	deduplicated, failed := Deduplicate(certInfoList)
+	return deduplicated, failed, nil
+}
+
+// Deduplicate groups certificate information by the SHA-256 fingerprint of the certificates.
+//
+// The same certificate, e.g. an intermediate CA, is often part of many bundles. Deduplicate returns one
+// canonical entry per certificate, taken from the first location the certificate was found at, together
+// with all locations containing it. The order of the first occurrences is preserved.
+//
+// Parameters:
+//   - certInfoList: []CertificateInfo
+//     The certificate information returned by Process.
+//
+// Returns:
+//   - []DeduplicatedCertificateInfo
+//     One entry per distinct certificate.
+//   - []CertificateInfo
+//     The entries describing a failed extraction. They have no fingerprint and can't be grouped.
+func Deduplicate(certInfoList []CertificateInfo) ([]DeduplicatedCertificateInfo, []CertificateInfo) {
+	var deduplicated []DeduplicatedCertificateInfo
+	var failed []CertificateInfo
+	indexByFingerprint := make(map[string]int)
+
+	for _, certInfo := range certInfoList {
+		if certInfo.certificate == nil {
+			failed = append(failed, certInfo)
+			continue
+		}
+
+		location := CertificateLocation{
+			Name:   certInfo.Name,
+			Type:   certInfo.Type,
+			Labels: certInfo.Labels,
+		}
+
+		fingerprint := certificateFingerprint(certInfo.certificate)
+		if idx, found := indexByFingerprint[fingerprint]; found {
+			deduplicated[idx].Locations = append(deduplicated[idx].Locations, location)
+			continue
+		}
+
+		indexByFingerprint[fingerprint] = len(deduplicated)
+		deduplicated = append(deduplicated, DeduplicatedCertificateInfo{
+			Fingerprint: fingerprint,
+			Name:        certInfo.Name,
+			Subject:     certInfo.Subject,
+			Epoch:       certInfo.Epoch,
+			Type:        certInfo.Type,
+			Role:        certInfo.Role,
+			Locations:   []CertificateLocation{location},
+		})
+	}
+
+	return deduplicated, failed
+}
+
+// String returns the name of the certificate config followed by the sorted labels, e.g. 'bundles{path=a.pem}'.
+func (l CertificateLocation) String() string {
+	if len(l.Labels) == 0 {
+		return l.Name
+	}
+	return fmt.Sprintf("%s{%s}", l.Name, l.LabelsString())
+}
+
+// LabelsString returns the labels sorted by key as comma separated 'key=value' pairs.
+func (l CertificateLocation) LabelsString() string {
+	return FormatLabels(l.Labels)
+}
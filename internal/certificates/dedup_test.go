@@ -0,0 +1,42 @@
+package certificates
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProcessDeduplicated(t *testing.T) {
+	certs := []Certificate{
+		{Name: "chain", Path: "../../tests/certs/pem/chain.pem", Type: "pem"},
+		{Name: "final", Path: "../../tests/certs/pem/final.crt", Type: "pem"},
+		{Name: "root", Path: "../../tests/certs/pem/root.crt", Type: "pem"},
+		{Name: "missing", Path: "../../tests/certs/pem/missing.crt", Type: "pem"},
+	}
+
+	deduplicated, failed, err := ProcessDeduplicated(certs, false)
+	assert.NoError(t, err)
+
+	assert.Len(t, failed, 1)
+	assert.Equal(t, "missing", failed[0].Name)
+
+	var subjects []string
+	for _, dci := range deduplicated {
+		subjects = append(subjects, dci.Subject)
+	}
+	assert.Equal(t, []string{"CN=final", "CN=intermediate", "CN=root"}, subjects)
+
+	assert.Equal(t, "67af9d5315338cb373ab3e4b93fe976bfaaaf415d0efcabe4fe3b5a29abd2719", deduplicated[0].Fingerprint)
+	assert.Equal(t, "chain", deduplicated[0].Name)
+	assert.Equal(t, []CertificateLocation{{Name: "chain", Type: "pem"}, {Name: "final", Type: "pem"}}, deduplicated[0].Locations)
+	assert.Equal(t, []CertificateLocation{{Name: "chain", Type: "pem"}}, deduplicated[1].Locations)
+	assert.Len(t, deduplicated[2].Locations, 2)
+
+	_, _, err = ProcessDeduplicated(certs, true)
+	assert.Error(t, err)
+}
+
+func TestCertificateLocationString(t *testing.T) {
+	assert.Equal(t, "bundles", CertificateLocation{Name: "bundles"}.String())
+	assert.Equal(t, "repo{path=certs/ca.pem,ref=HEAD}", CertificateLocation{Name: "repo", Labels: map[string]string{"ref": "HEAD", "path": "certs/ca.pem"}}.String())
+}
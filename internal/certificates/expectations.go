@@ -0,0 +1,119 @@
+package certificates
+
+import (
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Results of the check of the expected names and issuer of a certificate.
+const (
+	ExpectationMatch    = "match"
+	ExpectationMismatch = "mismatch"
+)
+
+// mismatchNoLeaf is the mismatch of a certificate config without a leaf certificate to check.
+const mismatchNoLeaf = "no leaf certificate"
+
+// HasExpectations reports whether the certificate config defines expected names or an expected issuer.
+func (c *Certificate) HasExpectations() bool {
+	return len(c.ExpectedDNSNames) > 0 || len(c.ExpectedIPs) > 0 || c.ExpectedIssuer != ""
+}
+
+// ValidateExpectations checks if the expected DNS names and IP addresses of the certificate config are valid.
+//
+// Returns:
+//   - error
+//     An error describing the first invalid expectation.
+func (c *Certificate) ValidateExpectations() error {
+	for _, name := range c.ExpectedDNSNames {
+		if name == "" || strings.ContainsAny(name, " /:") || net.ParseIP(name) != nil {
+			return fmt.Errorf("Invalid expected DNS name '%s'.", name)
+		}
+	}
+
+	for _, ip := range c.ExpectedIPs {
+		if net.ParseIP(ip) == nil {
+			return fmt.Errorf("Invalid expected IP '%s'.", ip)
+		}
+	}
+
+	return nil
+}
+
+// evaluateExpectations checks the leaf certificates against the expected names and issuer of the certificate config.
+//
+// Only certificates with the role leaf or self-signed are checked, as the names of a service are
+// part of its leaf certificate. A DNS name matches if the certificate is valid for it, so wildcard
+// names are considered. An IP address must be part of the IP subject alternative names. The issuer
+// is compared with the issuer of the certificate, e.g. 'CN=Example CA,O=Example'. If none of the
+// extracted certificates is a leaf, e.g. a file only containing a root CA, every extracted
+// certificate is reported as a mismatch, as the expected service certificate is missing.
+//
+// Parameters:
+//   - cert: Certificate
+//     The certificate config with the expectations.
+//   - certInfoList: []CertificateInfo
+//     The certificates extracted for the certificate config. The result is stored in each checked certificate.
+func evaluateExpectations(cert Certificate, certInfoList []CertificateInfo) {
+	if !cert.HasExpectations() {
+		return
+	}
+
+	checked := false
+	for i := range certInfoList {
+		certInfo := &certInfoList[i]
+		if certInfo.certificate == nil || (certInfo.Role != RoleLeaf && certInfo.Role != RoleSelfSigned) {
+			continue
+		}
+		checked = true
+
+		var mismatches []string
+		for _, name := range cert.ExpectedDNSNames {
+			if err := certInfo.certificate.VerifyHostname(name); err != nil {
+				mismatches = append(mismatches, "dns:"+name)
+			}
+		}
+		for _, ip := range cert.ExpectedIPs {
+			if !containsIP(certInfo.certificate.IPAddresses, net.ParseIP(ip)) {
+				mismatches = append(mismatches, "ip:"+ip)
+			}
+		}
+		if cert.ExpectedIssuer != "" && certInfo.certificate.Issuer.String() != cert.ExpectedIssuer {
+			mismatches = append(mismatches, "issuer:"+cert.ExpectedIssuer)
+		}
+
+		certInfo.Expectation = ExpectationMatch
+		certInfo.Mismatches = mismatches
+		if len(mismatches) > 0 {
+			certInfo.Expectation = ExpectationMismatch
+			log.Warn().Msgf("Certificate '%s' of '%s' doesn't match '%s'", certInfo.Subject, certInfo.Name, strings.Join(mismatches, "', '"))
+		}
+	}
+
+	if checked {
+		return
+	}
+
+	for i := range certInfoList {
+		certInfo := &certInfoList[i]
+		if certInfo.certificate == nil {
+			continue
+		}
+		certInfo.Expectation = ExpectationMismatch
+		certInfo.Mismatches = []string{mismatchNoLeaf}
+		log.Warn().Msgf("Certificate '%s' of '%s' doesn't match, '%s' contains no leaf certificate", certInfo.Subject, certInfo.Name, cert.Name)
+	}
+}
+
+// containsIP reports whether the list contains the IP address.
+func containsIP(ips []net.IP, ip net.IP) bool {
+	for _, candidate := range ips {
+		if candidate.Equal(ip) {
+			return true
+		}
+	}
+	return false
+}
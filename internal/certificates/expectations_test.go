@@ -0,0 +1,106 @@
+package certificates
+
+import (
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProcessExpectations(t *testing.T) {
+	bundle := writeLeafBundle(t)
+
+	testCases := []struct {
+		Name        string
+		Cert        Certificate
+		Expectation string
+		Mismatches  []string
+	}{
+		{
+			Name: "no expectations",
+			Cert: Certificate{},
+		},
+		{
+			Name:        "match",
+			Cert:        Certificate{ExpectedDNSNames: []string{"example.com", "www.example.com"}, ExpectedIPs: []string{"10.0.0.1"}, ExpectedIssuer: "CN=bundle-ca"},
+			Expectation: ExpectationMatch,
+		},
+		{
+			Name:        "missing names",
+			Cert:        Certificate{ExpectedDNSNames: []string{"www.example.com", "api.example.com"}, ExpectedIPs: []string{"10.0.0.1", "::1"}},
+			Expectation: ExpectationMismatch,
+			Mismatches:  []string{"dns:api.example.com", "ip:::1"},
+		},
+		{
+			Name:        "wrong issuer",
+			Cert:        Certificate{ExpectedIssuer: "CN=Example CA"},
+			Expectation: ExpectationMismatch,
+			Mismatches:  []string{"issuer:CN=Example CA"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			cert := tc.Cert
+			cert.Name = "bundle"
+			cert.Path = bundle
+			cert.Type = "pem"
+
+			result, err := Process([]Certificate{cert}, true)
+			assert.NoError(t, err)
+			assert.Len(t, result, 2)
+
+			for _, certInfo := range result {
+				if certInfo.Role != RoleLeaf {
+					// CA certificates are never checked
+					assert.Empty(t, certInfo.Expectation)
+					continue
+				}
+				assert.Equal(t, tc.Expectation, certInfo.Expectation)
+				assert.Equal(t, tc.Mismatches, certInfo.Mismatches)
+			}
+		})
+	}
+}
+
+func TestProcessExpectationsWithoutLeaf(t *testing.T) {
+	root, _ := createTestCertificate(t, "root", true, true, nil, nil)
+	path := filepath.Join(t.TempDir(), "root.pem")
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: root.Raw}), 0o644); err != nil {
+		t.Fatalf("Failed to write certificate: %v", err)
+	}
+
+	result, err := Process([]Certificate{{Name: "root", Path: path, Type: "pem", ExpectedDNSNames: []string{"example.com"}}}, true)
+	assert.NoError(t, err)
+	assert.Len(t, result, 1)
+	assert.Equal(t, RoleRoot, result[0].Role)
+	assert.Equal(t, ExpectationMismatch, result[0].Expectation)
+	assert.Equal(t, []string{"no leaf certificate"}, result[0].Mismatches)
+}
+
+func TestValidateExpectations(t *testing.T) {
+	testCases := []struct {
+		Name          string
+		Cert          Certificate
+		ExpectedError string
+	}{
+		{Name: "valid", Cert: Certificate{ExpectedDNSNames: []string{"*.example.com", "localhost"}, ExpectedIPs: []string{"10.0.0.1", "fe80::1"}}},
+		{Name: "empty DNS name", Cert: Certificate{ExpectedDNSNames: []string{""}}, ExpectedError: "Invalid expected DNS name ''."},
+		{Name: "URL as DNS name", Cert: Certificate{ExpectedDNSNames: []string{"https://example.com"}}, ExpectedError: "Invalid expected DNS name 'https://example.com'."},
+		{Name: "IP as DNS name", Cert: Certificate{ExpectedDNSNames: []string{"10.0.0.1"}}, ExpectedError: "Invalid expected DNS name '10.0.0.1'."},
+		{Name: "invalid IP", Cert: Certificate{ExpectedIPs: []string{"10.0.0.256"}}, ExpectedError: "Invalid expected IP '10.0.0.256'."},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			err := tc.Cert.ValidateExpectations()
+			if tc.ExpectedError == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tc.ExpectedError)
+			}
+		})
+	}
+}
@@ -0,0 +1,75 @@
+package certificates
+
+import (
+	"encoding/base64"
+	"fmt"
+	"os"
+	"strings"
+)
+
+func init() {
+	registerSource(DefaultSource, FetchFileCertificate, true)
+}
+
+// FetchFileCertificate reads the raw certificate data from the local file system.
+//
+// If the certificate has inline content instead of a path, the content is used. Binary
+// certificate types (e.g. 'p12' or 'jks') must be base64 encoded in the content.
+//
+// Parameters:
+//   - cert: Certificate
+//     A Certificate struct representing the certificate file, including its path or content.
+//
+// Returns:
+//   - []Blob
+//     A slice containing the content of the certificate file.
+//   - error
+//     An error if the file can't be read or the content can't be decoded.
+func FetchFileCertificate(cert Certificate) ([]Blob, error) {
+	if cert.Content != "" {
+		return contentBlobs(cert)
+	}
+
+	certData, err := os.ReadFile(cert.Path)
+	if err != nil {
+		// Accessibility of the file is checked in the config validation, if reached
+		// here, the file exists but can't be read for some reason.
+		return nil, fmt.Errorf("Failed to read certificate file '%s'. %v", cert.Path, err)
+	}
+
+	return []Blob{{Path: cert.Path, Data: certData}}, nil
+}
+
+// contentBlobs returns the inline content of a certificate.
+//
+// Parameters:
+//   - cert: Certificate
+//     A Certificate struct with inline content.
+//
+// Returns:
+//   - []Blob
+//     A slice containing the decoded content.
+//   - error
+//     An error if the type can't be inferred or the content is not valid base64.
+func contentBlobs(cert Certificate) ([]Blob, error) {
+	data := []byte(cert.Content)
+
+	certType := cert.Type
+	if certType == "" {
+		certType = inferCertificateType("", data)
+	}
+	if certType == "" {
+		return nil, fmt.Errorf("Certificate '%s' has no 'type' defined. Type can't be inferred from the content.", cert.Name)
+	}
+
+	if canonicalType := FileExtensionsToType[certType]; canonicalType != "pem" && canonicalType != "p7" {
+		// Binary content can't be stored as text, so it is base64 encoded
+		decoded, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(cert.Content), ""))
+		if err != nil {
+			return nil, fmt.Errorf("Failed to decode base64 content of certificate '%s'. %v", cert.Name, err)
+		}
+		data = decoded
+	}
+
+	return []Blob{{Type: certType, Data: data}}, nil
+}
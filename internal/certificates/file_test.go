@@ -0,0 +1,43 @@
+package certificates
+
+import (
+	"encoding/base64"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFetchFileCertificate(t *testing.T) {
+	final := readTestFile(t, "../../tests/certs/pem/final.crt")
+	p12 := readTestFile(t, "../../tests/certs/p12/with_password.p12")
+
+	t.Run("reads file", func(t *testing.T) {
+		blobs, err := FetchFileCertificate(Certificate{Name: "file", Path: "../../tests/certs/pem/final.crt"})
+		assert.NoError(t, err)
+		assert.Equal(t, []Blob{{Path: "../../tests/certs/pem/final.crt", Data: final}}, blobs)
+	})
+
+	t.Run("infers type of PEM content", func(t *testing.T) {
+		blobs, err := FetchFileCertificate(Certificate{Name: "inline", Content: string(final)})
+		assert.NoError(t, err)
+		assert.Equal(t, []Blob{{Type: "pem", Data: final}}, blobs)
+	})
+
+	t.Run("decodes base64 content", func(t *testing.T) {
+		encoded := base64.StdEncoding.EncodeToString(p12)
+		content := encoded[:64] + "\n" + encoded[64:] + "\n"
+		result, err := Process([]Certificate{{Name: "inline", Type: "p12", Password: "password", Content: content}}, true)
+		assert.NoError(t, err)
+		validateCertificateInfo(t, []CertificateInfo{{Name: "inline", Subject: "CN=with_password", Epoch: 1722925469, Type: "p12"}}, result)
+	})
+
+	t.Run("invalid base64 content", func(t *testing.T) {
+		_, err := FetchFileCertificate(Certificate{Name: "inline", Type: "jks", Content: "not base64!"})
+		assert.EqualError(t, err, "Failed to decode base64 content of certificate 'inline'. illegal base64 data at input byte 9")
+	})
+
+	t.Run("type can't be inferred", func(t *testing.T) {
+		_, err := FetchFileCertificate(Certificate{Name: "inline", Content: base64.StdEncoding.EncodeToString(p12)})
+		assert.EqualError(t, err, "Certificate 'inline' has no 'type' defined. Type can't be inferred from the content.")
+	})
+}
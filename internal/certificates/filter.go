@@ -0,0 +1,215 @@
+package certificates
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+)
+
+// FilterFields contains the fields of a certificate a filter can match.
+var FilterFields = []string{"alias", "fingerprint", "issuer", "san", "subject"}
+
+// compiledFilter represents a filter with its compiled regular expression.
+type compiledFilter struct {
+	Filter
+	regex *regexp.Regexp
+}
+
+// Validate checks if the filter has a known field and either a value or a valid regular expression.
+//
+// Returns:
+//   - error
+//     An error describing the invalid setting.
+func (f Filter) Validate() error {
+	if !slices.Contains(FilterFields, f.Field) {
+		return fmt.Errorf("Invalid filter field '%s'. Must be one of '%s'.", f.Field, strings.Join(FilterFields, "', '"))
+	}
+
+	if (f.Value == "") == (f.Regex == "") {
+		return fmt.Errorf("Filter on '%s' must define either 'value' or 'regex'.", f.Field)
+	}
+
+	if f.Regex != "" {
+		if _, err := regexp.Compile(f.Regex); err != nil {
+			return fmt.Errorf("Filter on '%s' has an invalid regex '%s'. %v", f.Field, f.Regex, err)
+		}
+	}
+
+	return nil
+}
+
+// applyFilters removes the certificates not matching the filters of a certificate config.
+//
+// A certificate is kept if it matches at least one include filter (or no include filter is defined),
+// matches no exclude filter and, if onlyLeaf is set, is not a CA certificate. Entries describing a failed
+// extraction are always kept, so errors are still reported.
+//
+// Parameters:
+//   - filters: *Filters
+//     The filters of the certificate config. If nil, all certificates are kept.
+//   - certInfoList: []CertificateInfo
+//     The extracted certificates.
+//
+// Returns:
+//   - []CertificateInfo
+//     The certificates passing the filters.
+//   - error
+//     An error if a filter is invalid.
+func applyFilters(filters *Filters, certInfoList []CertificateInfo) ([]CertificateInfo, error) {
+	if filters == nil {
+		return certInfoList, nil
+	}
+
+	include, err := compileFilters(filters.Include)
+	if err != nil {
+		return nil, err
+	}
+	exclude, err := compileFilters(filters.Exclude)
+	if err != nil {
+		return nil, err
+	}
+
+	var filtered []CertificateInfo
+	for _, certInfo := range certInfoList {
+		if certInfo.certificate == nil {
+			filtered = append(filtered, certInfo)
+			continue
+		}
+
+		if len(include) > 0 && !matchesAnyFilter(include, certInfo) {
+			log.Debug().Msgf("Skip certificate '%s' of '%s' as it matches no include filter", certInfo.Subject, certInfo.Name)
+			continue
+		}
+
+		if matchesAnyFilter(exclude, certInfo) {
+			log.Debug().Msgf("Skip certificate '%s' of '%s' as it matches an exclude filter", certInfo.Subject, certInfo.Name)
+			continue
+		}
+
+		if filters.OnlyLeaf && isCACertificate(certInfo.certificate) {
+			log.Debug().Msgf("Skip certificate '%s' of '%s' as it is a CA certificate", certInfo.Subject, certInfo.Name)
+			continue
+		}
+
+		filtered = append(filtered, certInfo)
+	}
+
+	return filtered, nil
+}
+
+// compileFilters validates the filters and compiles their regular expressions.
+func compileFilters(filters []Filter) ([]compiledFilter, error) {
+	compiled := make([]compiledFilter, 0, len(filters))
+	for _, f := range filters {
+		if err := f.Validate(); err != nil {
+			return nil, err
+		}
+		cf := compiledFilter{Filter: f}
+		if f.Regex != "" {
+			cf.regex = regexp.MustCompile(f.Regex)
+		}
+		compiled = append(compiled, cf)
+	}
+	return compiled, nil
+}
+
+// matchesAnyFilter reports whether the certificate matches one of the filters.
+func matchesAnyFilter(filters []compiledFilter, certInfo CertificateInfo) bool {
+	for _, f := range filters {
+		if f.matches(certInfo) {
+			return true
+		}
+	}
+	return false
+}
+
+// matches reports whether one of the values of the filtered field matches the filter.
+func (f compiledFilter) matches(certInfo CertificateInfo) bool {
+	value := f.Value
+	if f.Field == "fingerprint" {
+		value = normalizeFingerprint(value)
+	}
+
+	for _, candidate := range filterFieldValues(f.Field, certInfo) {
+		if f.regex != nil && f.regex.MatchString(candidate) {
+			return true
+		}
+		if f.regex == nil && candidate == value {
+			return true
+		}
+	}
+	return false
+}
+
+// filterFieldValues returns the values of a field of a certificate.
+//
+// Parameters:
+//   - field: string
+//     One of FilterFields.
+//   - certInfo: CertificateInfo
+//     The certificate.
+//
+// Returns:
+//   - []string
+//     The values of the field. Subject alternative names can have multiple values.
+func filterFieldValues(field string, certInfo CertificateInfo) []string {
+	cert := certInfo.certificate
+
+	switch field {
+	case "subject":
+		return []string{certInfo.Subject}
+	case "issuer":
+		return []string{cert.Issuer.ToRDNSequence().String()}
+	case "alias":
+		return []string{certInfo.alias}
+	case "fingerprint":
+		return []string{certificateFingerprint(cert)}
+	case "san":
+		values := append([]string{}, cert.DNSNames...)
+		for _, ip := range cert.IPAddresses {
+			values = append(values, ip.String())
+		}
+		values = append(values, cert.EmailAddresses...)
+		for _, uri := range cert.URIs {
+			values = append(values, uri.String())
+		}
+		return values
+	}
+	return nil
+}
+
+// certificateFingerprint returns the SHA-256 fingerprint of a certificate as lowercase hex string.
+func certificateFingerprint(cert *x509.Certificate) string {
+	sum := sha256.Sum256(cert.Raw)
+	return hex.EncodeToString(sum[:])
+}
+
+// normalizeFingerprint converts a fingerprint to lowercase hex without separators,
+// e.g. 'AB:CD:EF' becomes 'abcdef'.
+func normalizeFingerprint(fingerprint string) string {
+	return strings.ToLower(strings.NewReplacer(":", "", " ", "").Replace(fingerprint))
+}
+
+// isCACertificate reports whether a certificate is a CA certificate. Certificates without basic
+// constraints (e.g. X.509 v1 roots) are considered CA certificates if they are self-signed.
+func isCACertificate(cert *x509.Certificate) bool {
+	if cert.BasicConstraintsValid {
+		return cert.IsCA
+	}
+	return isSelfSigned(cert)
+}
+
+// isSelfSigned reports whether a certificate is issued by itself.
+func isSelfSigned(cert *x509.Certificate) bool {
+	if !bytes.Equal(cert.RawSubject, cert.RawIssuer) {
+		return false
+	}
+	return cert.CheckSignature(cert.SignatureAlgorithm, cert.RawTBSCertificate, cert.Signature) == nil
+}
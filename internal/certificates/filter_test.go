@@ -0,0 +1,194 @@
+package certificates
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// writeLeafBundle writes a bundle with a leaf certificate for 'www.example.com' signed by a CA 'CN=bundle-ca'
+// followed by the CA certificate and returns the path of the bundle.
+func writeLeafBundle(t *testing.T) string {
+	caKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	caTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "bundle-ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(24 * time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, &caKey.PublicKey, caKey)
+	if err != nil {
+		t.Fatalf("Failed to create CA certificate: %v", err)
+	}
+
+	leafKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	leafTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(2),
+		Subject:               pkix.Name{CommonName: "www.example.com"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(24 * time.Hour),
+		DNSNames:              []string{"www.example.com", "example.com"},
+		IPAddresses:           []net.IP{net.ParseIP("10.0.0.1")},
+		BasicConstraintsValid: true,
+	}
+	leafDER, err := x509.CreateCertificate(rand.Reader, leafTemplate, caTemplate, &leafKey.PublicKey, caKey)
+	if err != nil {
+		t.Fatalf("Failed to create leaf certificate: %v", err)
+	}
+
+	data := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: leafDER})
+	data = append(data, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: caDER})...)
+
+	path := filepath.Join(t.TempDir(), "bundle.pem")
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatalf("Failed to write bundle: %v", err)
+	}
+	return path
+}
+
+func TestProcessFilters(t *testing.T) {
+	chain := Certificate{Name: "chain", Path: "../../tests/certs/pem/chain.pem", Type: "pem"}
+	bundle := Certificate{Name: "bundle", Path: writeLeafBundle(t), Type: "pem"}
+
+	testCases := []struct {
+		Name     string
+		Cert     Certificate
+		Filters  *Filters
+		Subjects []string
+	}{
+		{
+			Name:     "no filters",
+			Cert:     chain,
+			Subjects: []string{"CN=final", "CN=intermediate", "CN=root"},
+		},
+		{
+			Name:     "include subject regex",
+			Cert:     chain,
+			Filters:  &Filters{Include: []Filter{{Field: "subject", Regex: "inter|root"}}},
+			Subjects: []string{"CN=intermediate", "CN=root"},
+		},
+		{
+			Name: "exclude fingerprint",
+			Cert: chain,
+			Filters: &Filters{Exclude: []Filter{
+				{Field: "fingerprint", Value: "42:7B:AC:57:FE:27:6A:A6:5A:22:0E:C6:3D:F5:A1:37:DA:C5:D3:E1:00:CA:71:69:51:CE:07:FC:73:12:06:34"},
+			}},
+			Subjects: []string{"CN=final", "CN=intermediate"},
+		},
+		{
+			Name: "include issuer and exclude subject",
+			Cert: chain,
+			Filters: &Filters{
+				Include: []Filter{{Field: "issuer", Value: "CN=root"}, {Field: "issuer", Value: "CN=final"}},
+				Exclude: []Filter{{Field: "subject", Value: "CN=final"}},
+			},
+			Subjects: []string{"CN=root"},
+		},
+		{
+			Name:     "include san",
+			Cert:     bundle,
+			Filters:  &Filters{Include: []Filter{{Field: "san", Value: "10.0.0.1"}}},
+			Subjects: []string{"CN=www.example.com"},
+		},
+		{
+			Name:     "only leaf",
+			Cert:     bundle,
+			Filters:  &Filters{OnlyLeaf: true},
+			Subjects: []string{"CN=www.example.com"},
+		},
+		{
+			Name:     "only leaf drops self-signed CA certificates",
+			Cert:     chain,
+			Filters:  &Filters{OnlyLeaf: true},
+			Subjects: nil,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			cert := tc.Cert
+			cert.Filters = tc.Filters
+
+			result, err := Process([]Certificate{cert}, true)
+			assert.NoError(t, err)
+
+			var subjects []string
+			for _, certInfo := range result {
+				subjects = append(subjects, certInfo.Subject)
+			}
+			assert.ElementsMatch(t, tc.Subjects, subjects)
+		})
+	}
+
+	t.Run("filter by alias", func(t *testing.T) {
+		cert := Certificate{Name: "jks", Path: "../../tests/certs/jks/regular.jks", Type: "jks", Password: "password"}
+
+		cert.Filters = &Filters{Exclude: []Filter{{Field: "alias", Regex: ".*"}}}
+		result, err := Process([]Certificate{cert}, true)
+		assert.NoError(t, err)
+		assert.Empty(t, result)
+	})
+
+	t.Run("invalid filter", func(t *testing.T) {
+		cert := chain
+		cert.Filters = &Filters{Include: []Filter{{Field: "serial", Value: "1"}}}
+
+		_, err := Process([]Certificate{cert}, true)
+		assert.EqualError(t, err, "Certificate 'chain' has an invalid filter. Invalid filter field 'serial'. Must be one of 'alias', 'fingerprint', 'issuer', 'san', 'subject'.")
+
+		result, err := Process([]Certificate{cert}, false)
+		assert.NoError(t, err)
+		assert.Len(t, result, 1)
+		assert.Equal(t, "Certificate 'chain' has an invalid filter. Invalid filter field 'serial'. Must be one of 'alias', 'fingerprint', 'issuer', 'san', 'subject'.", result[0].Error)
+	})
+
+	t.Run("keeps errors", func(t *testing.T) {
+		cert := Certificate{Name: "broken", Path: "../../tests/certs/pem/broken.pem", Type: "pem"}
+		cert.Filters = &Filters{Include: []Filter{{Field: "subject", Value: "CN=nothing"}}}
+
+		result, err := applyFilters(cert.Filters, []CertificateInfo{{Name: "broken", Type: "pem", Error: "Failed to parse certificate."}})
+		assert.NoError(t, err)
+		assert.Len(t, result, 1)
+		assert.Equal(t, "Failed to parse certificate.", result[0].Error)
+	})
+}
+
+func TestFilterValidate(t *testing.T) {
+	testCases := []struct {
+		Name          string
+		Filter        Filter
+		ExpectedError string
+	}{
+		{Name: "value", Filter: Filter{Field: "subject", Value: "CN=root"}},
+		{Name: "regex", Filter: Filter{Field: "san", Regex: `\.example\.com$`}},
+		{Name: "unknown field", Filter: Filter{Field: "serial", Value: "1"}, ExpectedError: "Invalid filter field 'serial'. Must be one of 'alias', 'fingerprint', 'issuer', 'san', 'subject'."},
+		{Name: "neither value nor regex", Filter: Filter{Field: "issuer"}, ExpectedError: "Filter on 'issuer' must define either 'value' or 'regex'."},
+		{Name: "value and regex", Filter: Filter{Field: "issuer", Value: "CN=root", Regex: "root"}, ExpectedError: "Filter on 'issuer' must define either 'value' or 'regex'."},
+		{Name: "invalid regex", Filter: Filter{Field: "alias", Regex: "("}, ExpectedError: "Filter on 'alias' has an invalid regex '('. error parsing regexp: missing closing ): `(`"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			err := tc.Filter.Validate()
+			if tc.ExpectedError == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tc.ExpectedError)
+			}
+		})
+	}
+}
@@ -0,0 +1,213 @@
+package certificates
+
+import (
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/rs/zerolog/log"
+)
+
+func init() {
+	registerSource("git", FetchGitCertificates, true)
+}
+
+const (
+	gitDefaultRef  = "HEAD"  // Ref scanned if no ref is configured
+	maxGitFileSize = 1 << 20 // Files in a repository larger than this are not considered as certificates
+)
+
+// gitRevision represents a commit of a repository to scan.
+type gitRevision struct {
+	Ref  string
+	Hash plumbing.Hash
+}
+
+// FetchGitCertificates reads the certificate files committed to a local git repository.
+//
+// The tree of the configured ref (HEAD by default) is walked directly in the object database, so no
+// checkout is required. If tags are enabled, the trees of all tags are walked too. If paths are set,
+// only files matching one of the glob patterns are considered, otherwise all files with a known
+// certificate file extension. Files whose content doesn't look like a certificate file are skipped.
+// Each blob is labeled with the repository, the ref and the path of the file.
+//
+// Parameters:
+//   - cert: Certificate
+//     A Certificate struct with the path of the repository and the 'git' settings.
+//
+// Returns:
+//   - []Blob
+//     A slice containing all certificate files found in the repository.
+//   - error
+//     An error if the repository can't be opened or a ref can't be resolved.
+func FetchGitCertificates(cert Certificate) ([]Blob, error) {
+	var opts GitSource
+	if cert.Git != nil {
+		opts = *cert.Git
+	}
+
+	repo, err := git.PlainOpen(cert.Path)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to open git repository '%s'. %v", cert.Path, err)
+	}
+
+	revisions, err := gitRevisions(repo, opts)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read git repository '%s'. %v", cert.Path, err)
+	}
+
+	var blobs []Blob
+	for _, revision := range revisions {
+		revisionBlobs, err := gitRevisionBlobs(repo, revision, opts.Paths)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to read ref '%s' of git repository '%s'. %v", revision.Ref, cert.Path, err)
+		}
+		for i := range revisionBlobs {
+			revisionBlobs[i].Labels["repository"] = cert.Path
+		}
+		blobs = append(blobs, revisionBlobs...)
+	}
+
+	log.Debug().Msgf("Found %d certificate files in git repository '%s' for certificate '%s'", len(blobs), cert.Path, cert.Name)
+
+	return blobs, nil
+}
+
+// gitRevisions returns the commits to scan.
+//
+// Parameters:
+//   - repo: *git.Repository
+//     The repository.
+//   - opts: GitSource
+//     The settings of the git source.
+//
+// Returns:
+//   - []gitRevision
+//     The commit of the configured ref followed by the commits of the tags, sorted by name.
+//   - error
+//     An error if a ref can't be resolved.
+func gitRevisions(repo *git.Repository, opts GitSource) ([]gitRevision, error) {
+	ref := opts.Ref
+	if ref == "" {
+		ref = gitDefaultRef
+	}
+
+	hash, err := repo.ResolveRevision(plumbing.Revision(ref))
+	if err != nil {
+		return nil, fmt.Errorf("ref '%s' not found. %v", ref, err)
+	}
+	revisions := []gitRevision{{Ref: ref, Hash: *hash}}
+
+	if !opts.Tags {
+		return revisions, nil
+	}
+
+	tags, err := repo.Tags()
+	if err != nil {
+		return nil, err
+	}
+
+	var tagRevisions []gitRevision
+	err = tags.ForEach(func(tag *plumbing.Reference) error {
+		hash, err := repo.ResolveRevision(plumbing.Revision(tag.Name().String()))
+		if err != nil {
+			// Tags can point to other objects than commits, e.g. a tree or a blob
+			log.Debug().Msgf("Skip tag '%s' as it does not point to a commit. %v", tag.Name().Short(), err)
+			return nil
+		}
+		// The configured ref is already scanned, a second scan would report the same series
+		if tag.Name().Short() == ref {
+			return nil
+		}
+		tagRevisions = append(tagRevisions, gitRevision{Ref: tag.Name().Short(), Hash: *hash})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(tagRevisions, func(i, j int) bool { return tagRevisions[i].Ref < tagRevisions[j].Ref })
+
+	return append(revisions, tagRevisions...), nil
+}
+
+// gitRevisionBlobs returns the certificate files in the tree of a commit.
+//
+// Parameters:
+//   - repo: *git.Repository
+//     The repository.
+//   - revision: gitRevision
+//     The commit to scan.
+//   - patterns: []string
+//     Glob patterns the paths of the files must match. If empty, the file extension must be known.
+//
+// Returns:
+//   - []Blob
+//     The certificate files, sorted by path.
+//   - error
+//     An error if the tree can't be read.
+func gitRevisionBlobs(repo *git.Repository, revision gitRevision, patterns []string) ([]Blob, error) {
+	commit, err := repo.CommitObject(revision.Hash)
+	if err != nil {
+		return nil, err
+	}
+
+	tree, err := commit.Tree()
+	if err != nil {
+		return nil, err
+	}
+
+	var blobs []Blob
+	err = tree.Files().ForEach(func(f *object.File) error {
+		if (f.Mode != filemode.Regular && f.Mode != filemode.Executable) || f.Size > maxGitFileSize {
+			return nil
+		}
+
+		if len(patterns) > 0 {
+			if !matchesAnyPattern(f.Name, patterns) {
+				return nil
+			}
+		} else if _, found := FileExtensionsToType[strings.ToLower(strings.TrimPrefix(path.Ext(f.Name), "."))]; !found {
+			return nil
+		}
+
+		reader, err := f.Reader()
+		if err != nil {
+			return err
+		}
+		defer reader.Close()
+
+		data, err := io.ReadAll(reader)
+		if err != nil {
+			return err
+		}
+
+		certType := inferCertificateType(f.Name, data)
+		if certType == "" || !looksLikeCertificateType(certType, data) {
+			return nil
+		}
+
+		blobs = append(blobs, Blob{
+			Path:   f.Name,
+			Type:   certType,
+			Data:   data,
+			Labels: map[string]string{"ref": revision.Ref, "path": f.Name},
+
+			Discovered: true,
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(blobs, func(i, j int) bool { return blobs[i].Path < blobs[j].Path })
+
+	return blobs, nil
+}
@@ -0,0 +1,137 @@
+package certificates
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/stretchr/testify/assert"
+)
+
+// commitFiles writes the given files to the worktree, removes the files with nil content and commits the changes.
+func commitFiles(t *testing.T, repo *git.Repository, dir string, files map[string][]byte) {
+	worktree, err := repo.Worktree()
+	if err != nil {
+		t.Fatalf("Failed to get worktree: %v", err)
+	}
+
+	for name, data := range files {
+		if data == nil {
+			if _, err := worktree.Remove(name); err != nil {
+				t.Fatalf("Failed to remove file: %v", err)
+			}
+			continue
+		}
+		p := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatalf("Failed to create dir: %v", err)
+		}
+		if err := os.WriteFile(p, data, 0o644); err != nil {
+			t.Fatalf("Failed to write file: %v", err)
+		}
+		if _, err := worktree.Add(name); err != nil {
+			t.Fatalf("Failed to add file: %v", err)
+		}
+	}
+
+	signature := &object.Signature{Name: "certalert", Email: "certalert@example.com", When: time.Now()}
+	if _, err := worktree.Commit("update certificates", &git.CommitOptions{Author: signature}); err != nil {
+		t.Fatalf("Failed to commit: %v", err)
+	}
+}
+
+func TestFetchGitCertificates(t *testing.T) {
+	final := readTestFile(t, "../../tests/certs/pem/final.crt")
+	root := readTestFile(t, "../../tests/certs/pem/root.crt")
+	jks := readTestFile(t, "../../tests/certs/jks/regular.jks")
+
+	dir := t.TempDir()
+	repo, err := git.PlainInit(dir, false)
+	if err != nil {
+		t.Fatalf("Failed to init repository: %v", err)
+	}
+
+	commitFiles(t, repo, dir, map[string][]byte{
+		"certs/final.crt":     final,
+		"certs/old.pem":       root,
+		"app/keystore.jks":    jks,
+		"app/index.ts":        []byte("export const x = 1;\n"),
+		"certs/bundle":        root,
+		"docs/certificate.md": []byte("# Certificates\n"),
+	})
+	head, _ := repo.Head()
+	if _, err := repo.CreateTag("v1.0", head.Hash(), &git.CreateTagOptions{Message: "v1.0", Tagger: &object.Signature{Name: "certalert", When: time.Now()}}); err != nil {
+		t.Fatalf("Failed to create tag: %v", err)
+	}
+	commitFiles(t, repo, dir, map[string][]byte{"certs/old.pem": nil})
+	head, _ = repo.Head()
+	if _, err := repo.CreateTag("v1.1", head.Hash(), nil); err != nil {
+		t.Fatalf("Failed to create tag: %v", err)
+	}
+
+	// Uncommitted files in the worktree are not scanned
+	if err := os.WriteFile(filepath.Join(dir, "certs", "uncommitted.crt"), final, 0o644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+
+	t.Run("scans HEAD", func(t *testing.T) {
+		blobs, err := FetchGitCertificates(Certificate{Name: "repo", Path: dir})
+		assert.NoError(t, err)
+		assert.Len(t, blobs, 2)
+		assert.Equal(t, "app/keystore.jks", blobs[0].Path)
+		assert.Equal(t, "jks", blobs[0].Type)
+		assert.Equal(t, map[string]string{"repository": dir, "ref": "HEAD", "path": "certs/final.crt"}, blobs[1].Labels)
+	})
+
+	t.Run("scans ref and tags", func(t *testing.T) {
+		blobs, err := FetchGitCertificates(Certificate{Name: "repo", Path: dir, Git: &GitSource{Ref: "v1.0", Tags: true, Paths: []string{"certs/*"}}})
+		assert.NoError(t, err)
+
+		var refs []string
+		for _, blob := range blobs {
+			refs = append(refs, blob.Labels["ref"]+":"+blob.Path)
+		}
+		assert.Equal(t, []string{
+			"v1.0:certs/bundle", "v1.0:certs/final.crt", "v1.0:certs/old.pem",
+			"v1.1:certs/bundle", "v1.1:certs/final.crt",
+		}, refs)
+	})
+
+	t.Run("Process extracts certificates", func(t *testing.T) {
+		result, err := Process([]Certificate{{Name: "repo", Source: "git", Path: dir, Password: "password"}}, true)
+		assert.NoError(t, err)
+		validateCertificateInfo(t, []CertificateInfo{
+			{Name: "repo", Subject: "CN=regular,OU=MyOrganization,O=MyCompany,L=MyCity,ST=MyState,C=MyCountry", Type: "jks"},
+			{Name: "repo", Subject: "CN=final", Type: "pem"},
+		}, result)
+	})
+
+	t.Run("Process labels certificates of each ref", func(t *testing.T) {
+		result, err := Process([]Certificate{{Name: "repo", Source: "git", Path: dir, Git: &GitSource{Tags: true, Paths: []string{"certs/final.crt"}}}}, true)
+		assert.NoError(t, err)
+
+		// HEAD and the tags hold the same certificate, the labels keep their series apart
+		var labels []string
+		for _, ci := range result {
+			labels = append(labels, ci.LabelsString())
+		}
+		assert.Equal(t, []string{
+			"path=certs/final.crt,ref=HEAD,repository=" + dir,
+			"path=certs/final.crt,ref=v1.0,repository=" + dir,
+			"path=certs/final.crt,ref=v1.1,repository=" + dir,
+		}, labels)
+	})
+
+	t.Run("unknown ref", func(t *testing.T) {
+		_, err := FetchGitCertificates(Certificate{Name: "repo", Path: dir, Git: &GitSource{Ref: "v2.0"}})
+		assert.EqualError(t, err, "Failed to read git repository '"+dir+"'. ref 'v2.0' not found. reference not found")
+	})
+
+	t.Run("not a repository", func(t *testing.T) {
+		_, err := FetchGitCertificates(Certificate{Name: "repo", Path: t.TempDir()})
+		assert.ErrorContains(t, err, "Failed to open git repository")
+	})
+}
@@ -81,10 +81,12 @@ func ExtractJKSCertificatesInfo(cert Certificate, certificateData []byte, failOn
 
 			subject := generateCertificateSubject(x509Cert.Subject.ToRDNSequence().String(), len(certificateInfoList)+1)
 			certificateInfo := CertificateInfo{
-				Name:    cert.Name,
-				Subject: subject,
-				Epoch:   x509Cert.NotAfter.Unix(),
-				Type:    "jks",
+				Name:        cert.Name,
+				Subject:     subject,
+				Epoch:       x509Cert.NotAfter.Unix(),
+				Type:        "jks",
+				certificate: x509Cert,
+				alias:       alias,
 			}
 			certificateInfoList = append(certificateInfoList, certificateInfo)
 
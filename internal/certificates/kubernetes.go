@@ -0,0 +1,307 @@
+package certificates
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"path"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+func init() {
+	registerSource("kubernetes", FetchKubernetesCertificates, false)
+}
+
+const (
+	kubernetesTimeout        = 30 * time.Second // Timeout for all requests to the Kubernetes API of a single certificate
+	kubernetesSecrets        = "secrets"
+	kubernetesConfigMaps     = "configmaps"
+	kubernetesIngresses      = "ingresses"
+	kubernetesGateways       = "gateways"
+	kubernetesPasswordKey    = "password"  // Generic sibling key holding the password of all keystores in a resource
+	kubernetesPasswordSuffix = ".password" // Suffix of sibling keys holding the password of a single keystore
+)
+
+// kubernetesResources contains the resources the kubernetes source can read certificates from.
+var kubernetesResources = []string{kubernetesConfigMaps, kubernetesGateways, kubernetesIngresses, kubernetesSecrets}
+
+// newKubernetesClient creates the Kubernetes client used by the kubernetes source.
+// It is a variable so tests can replace it with a fake clientset.
+var newKubernetesClient = func(opts KubernetesSource) (kubernetes.Interface, error) {
+	config, err := kubernetesRestConfig(opts)
+	if err != nil {
+		return nil, err
+	}
+	return kubernetes.NewForConfig(config)
+}
+
+// kubernetesRestConfig returns the config to connect to the Kubernetes API.
+//
+// If no kubeconfig is set, the in-cluster config is used. Outside a cluster, the default
+// kubeconfig loading rules ($KUBECONFIG, ~/.kube/config) apply.
+//
+// Parameters:
+//   - opts: KubernetesSource
+//     The settings of the kubernetes source.
+//
+// Returns:
+//   - *rest.Config
+//     The config to connect to the Kubernetes API.
+//   - error
+//     An error if no config could be loaded.
+func kubernetesRestConfig(opts KubernetesSource) (*rest.Config, error) {
+	if opts.Kubeconfig == "" && opts.Context == "" {
+		if config, err := rest.InClusterConfig(); err == nil {
+			return config, nil
+		}
+	}
+
+	loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
+	if opts.Kubeconfig != "" {
+		loadingRules.ExplicitPath = opts.Kubeconfig
+	}
+	overrides := &clientcmd.ConfigOverrides{CurrentContext: opts.Context}
+
+	return clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loadingRules, overrides).ClientConfig()
+}
+
+// FetchKubernetesCertificates reads the certificates stored in Kubernetes Secrets and ConfigMaps.
+//
+// The function lists 'kubernetes.io/tls' and 'Opaque' Secrets as well as ConfigMaps in the configured
+// namespaces matching the label selector. Every key with a known certificate file extension
+// (e.g. 'tls.crt', 'ca.crt' or 'keystore.jks') is returned as a blob. The password of a keystore is
+// read from a sibling key, see keystorePassword. Each blob is labeled with the namespace, the name
+// of the resource and the key.
+//
+// If requested, Ingresses and Gateways are listed instead of the Secrets directly and the Secrets
+// referenced in their TLS configuration are resolved, see fetchIngressCertificates and
+// fetchGatewayCertificates.
+//
+// Parameters:
+//   - cert: Certificate
+//     A Certificate struct with the 'kubernetes' settings.
+//
+// Returns:
+//   - []Blob
+//     A slice containing all certificate files found in the resources.
+//   - error
+//     An error if the resources can't be listed.
+func FetchKubernetesCertificates(cert Certificate) ([]Blob, error) {
+	var opts KubernetesSource
+	if cert.Kubernetes != nil {
+		opts = *cert.Kubernetes
+	}
+
+	client, err := newKubernetesClient(opts)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to create Kubernetes client. %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), kubernetesTimeout)
+	defer cancel()
+
+	resources := opts.Resources
+	if len(resources) == 0 {
+		resources = []string{kubernetesSecrets, kubernetesConfigMaps}
+	}
+	for _, resource := range resources {
+		if !slices.Contains(kubernetesResources, resource) {
+			return nil, fmt.Errorf("Unknown Kubernetes resource '%s'. Must be one of '%s'.", resource, strings.Join(kubernetesResources, "', '"))
+		}
+	}
+
+	namespaces := opts.Namespaces
+	if len(namespaces) == 0 {
+		namespaces = []string{metav1.NamespaceAll}
+	}
+
+	listOpts := metav1.ListOptions{LabelSelector: opts.LabelSelector}
+
+	var blobs []Blob
+	for _, namespace := range namespaces {
+		if slices.Contains(resources, kubernetesSecrets) {
+			secrets, err := client.CoreV1().Secrets(namespace).List(ctx, listOpts)
+			if err != nil {
+				return nil, fmt.Errorf("Failed to list secrets in namespace '%s'. %v", namespace, err)
+			}
+			for _, secret := range secrets.Items {
+				blobs = append(blobs, secretBlobs(secret, opts.PasswordKey)...)
+			}
+		}
+
+		if slices.Contains(resources, kubernetesConfigMaps) {
+			configMaps, err := client.CoreV1().ConfigMaps(namespace).List(ctx, listOpts)
+			if err != nil {
+				return nil, fmt.Errorf("Failed to list configmaps in namespace '%s'. %v", namespace, err)
+			}
+			for _, configMap := range configMaps.Items {
+				blobs = append(blobs, configMapBlobs(configMap, opts.PasswordKey)...)
+			}
+		}
+
+		if slices.Contains(resources, kubernetesIngresses) {
+			ingressBlobs, err := fetchIngressCertificates(ctx, client, namespace, listOpts, opts.PasswordKey)
+			if err != nil {
+				return nil, err
+			}
+			blobs = append(blobs, ingressBlobs...)
+		}
+
+		if slices.Contains(resources, kubernetesGateways) {
+			gatewayBlobs, err := fetchGatewayCertificates(ctx, client, opts, namespace, listOpts)
+			if err != nil {
+				return nil, err
+			}
+			blobs = append(blobs, gatewayBlobs...)
+		}
+	}
+
+	log.Debug().Msgf("Found %d certificate files in Kubernetes for certificate '%s'", len(blobs), cert.Name)
+
+	return blobs, nil
+}
+
+// secretBlobs returns the certificate files stored in a 'kubernetes.io/tls' or 'Opaque' Secret.
+//
+// Parameters:
+//   - secret: corev1.Secret
+//     The secret to read.
+//   - passwordKey: string
+//     The key holding the password of the keystores. If empty, the sibling keys are searched.
+//
+// Returns:
+//   - []Blob
+//     The certificate files of the secret.
+func secretBlobs(secret corev1.Secret, passwordKey string) []Blob {
+	if !isSupportedSecretType(secret.Type) {
+		return nil
+	}
+
+	return dataBlobs(secret.Data, passwordKey, map[string]string{
+		"namespace": secret.Namespace,
+		"secret":    secret.Name,
+	})
+}
+
+// isSupportedSecretType reports whether certificates are read from Secrets of the given type.
+// Secrets without a type are handled as 'Opaque' Secrets.
+func isSupportedSecretType(secretType corev1.SecretType) bool {
+	return secretType == corev1.SecretTypeTLS || secretType == corev1.SecretTypeOpaque || secretType == ""
+}
+
+// configMapBlobs returns the certificate files (e.g. CA bundles) stored in a ConfigMap.
+//
+// Parameters:
+//   - configMap: corev1.ConfigMap
+//     The ConfigMap to read.
+//   - passwordKey: string
+//     The key holding the password of the keystores. If empty, the sibling keys are searched.
+//
+// Returns:
+//   - []Blob
+//     The certificate files of the ConfigMap.
+func configMapBlobs(configMap corev1.ConfigMap, passwordKey string) []Blob {
+	data := make(map[string][]byte, len(configMap.Data)+len(configMap.BinaryData))
+	for key, value := range configMap.Data {
+		data[key] = []byte(value)
+	}
+	for key, value := range configMap.BinaryData {
+		data[key] = value
+	}
+
+	return dataBlobs(data, passwordKey, map[string]string{
+		"namespace": configMap.Namespace,
+		"configmap": configMap.Name,
+	})
+}
+
+// dataBlobs returns the certificate files stored in the data of a Secret or ConfigMap.
+//
+// Only keys with a known certificate file extension whose content matches the type are returned.
+//
+// Parameters:
+//   - data: map[string][]byte
+//     The data of the resource.
+//   - passwordKey: string
+//     The key holding the password of the keystores. If empty, the sibling keys are searched.
+//   - labels: map[string]string
+//     The labels identifying the resource.
+//
+// Returns:
+//   - []Blob
+//     The certificate files, sorted by key.
+func dataBlobs(data map[string][]byte, passwordKey string, labels map[string]string) []Blob {
+	keys := make([]string, 0, len(data))
+	for key := range data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var blobs []Blob
+	for _, key := range keys {
+		ext := strings.TrimPrefix(path.Ext(key), ".")
+		certType, found := FileExtensionsToType[ext]
+		if !found || !looksLikeCertificateType(certType, data[key]) {
+			continue
+		}
+
+		blobLabels := map[string]string{"key": key}
+		maps.Copy(blobLabels, labels)
+
+		blobs = append(blobs, Blob{
+			Path:     key,
+			Type:     certType,
+			Data:     data[key],
+			Password: keystorePassword(data, key, passwordKey),
+			Labels:   blobLabels,
+		})
+	}
+
+	return blobs
+}
+
+// keystorePassword returns the password of a keystore stored next to it in the same resource.
+//
+// The password is read from the configured password key. Otherwise the first existing key of
+// '<key>.password' (e.g. 'keystore.jks.password'), '<key without extension>.password'
+// (e.g. 'keystore.password') and 'password' is used.
+//
+// Parameters:
+//   - data: map[string][]byte
+//     The data of the resource.
+//   - key: string
+//     The key of the keystore.
+//   - passwordKey: string
+//     The configured key holding the password.
+//
+// Returns:
+//   - string
+//     The password or an empty string if no password is found.
+func keystorePassword(data map[string][]byte, key, passwordKey string) string {
+	candidates := []string{
+		key + kubernetesPasswordSuffix,
+		strings.TrimSuffix(key, path.Ext(key)) + kubernetesPasswordSuffix,
+		kubernetesPasswordKey,
+	}
+	if passwordKey != "" {
+		candidates = []string{passwordKey}
+	}
+
+	for _, candidate := range candidates {
+		if password, found := data[candidate]; found {
+			return strings.TrimSpace(string(password))
+		}
+	}
+	return ""
+}
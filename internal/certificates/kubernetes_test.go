@@ -0,0 +1,123 @@
+package certificates
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// useFakeKubernetesClient replaces the Kubernetes client with a fake clientset holding the given objects.
+func useFakeKubernetesClient(t *testing.T, client kubernetes.Interface) {
+	oldNewKubernetesClient := newKubernetesClient
+	newKubernetesClient = func(opts KubernetesSource) (kubernetes.Interface, error) {
+		return client, nil
+	}
+	t.Cleanup(func() { newKubernetesClient = oldNewKubernetesClient })
+}
+
+// readTestFile reads a file from the tests directory.
+func readTestFile(t *testing.T, path string) []byte {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read file '%s': %v", path, err)
+	}
+	return data
+}
+
+func TestFetchKubernetesCertificates(t *testing.T) {
+	chain := readTestFile(t, "../../tests/certs/pem/chain.crt")
+	root := readTestFile(t, "../../tests/certs/pem/root.crt")
+	jks := readTestFile(t, "../../tests/certs/jks/regular.jks")
+
+	useFakeKubernetesClient(t, fake.NewClientset(
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "web-tls", Namespace: "shop", Labels: map[string]string{"certalert": "true"}},
+			Type:       corev1.SecretTypeTLS,
+			Data:       map[string][]byte{"tls.crt": chain, "tls.key": []byte("key"), "ca.crt": root},
+		},
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "keystores", Namespace: "shop", Labels: map[string]string{"certalert": "true"}},
+			Type:       corev1.SecretTypeOpaque,
+			Data:       map[string][]byte{"keystore.jks": jks, "keystore.password": []byte("password\n")},
+		},
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "token", Namespace: "shop", Labels: map[string]string{"certalert": "true"}},
+			Type:       corev1.SecretTypeServiceAccountToken,
+			Data:       map[string][]byte{"ca.crt": root},
+		},
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "unlabeled", Namespace: "shop"},
+			Type:       corev1.SecretTypeTLS,
+			Data:       map[string][]byte{"tls.crt": chain},
+		},
+		&corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "ca-bundle", Namespace: "infra", Labels: map[string]string{"certalert": "true"}},
+			Data:       map[string]string{"ca-bundle.crt": string(root), "README.md": "bundle"},
+		},
+	))
+
+	t.Run("lists secrets and configmaps by label selector", func(t *testing.T) {
+		cert := Certificate{Name: "k8s", Kubernetes: &KubernetesSource{LabelSelector: "certalert=true"}}
+		blobs, err := FetchKubernetesCertificates(cert)
+		assert.NoError(t, err)
+		assert.Len(t, blobs, 4)
+
+		byKey := map[string]Blob{}
+		for _, blob := range blobs {
+			byKey[blob.Labels["key"]] = blob
+		}
+		assert.Equal(t, map[string]string{"namespace": "shop", "secret": "web-tls", "key": "tls.crt"}, byKey["tls.crt"].Labels)
+		assert.Equal(t, "pem", byKey["ca.crt"].Type)
+		assert.Equal(t, "jks", byKey["keystore.jks"].Type)
+		assert.Equal(t, "password", byKey["keystore.jks"].Password)
+		assert.Equal(t, map[string]string{"namespace": "infra", "configmap": "ca-bundle", "key": "ca-bundle.crt"}, byKey["ca-bundle.crt"].Labels)
+	})
+
+	t.Run("filters by namespace and resource", func(t *testing.T) {
+		cert := Certificate{Name: "k8s", Kubernetes: &KubernetesSource{Namespaces: []string{"infra"}, Resources: []string{"configmaps"}}}
+		blobs, err := FetchKubernetesCertificates(cert)
+		assert.NoError(t, err)
+		assert.Len(t, blobs, 1)
+		assert.Equal(t, "ca-bundle", blobs[0].Labels["configmap"])
+	})
+
+	t.Run("unknown resource", func(t *testing.T) {
+		cert := Certificate{Name: "k8s", Kubernetes: &KubernetesSource{Resources: []string{"pods"}}}
+		_, err := FetchKubernetesCertificates(cert)
+		assert.EqualError(t, err, "Unknown Kubernetes resource 'pods'. Must be one of 'configmaps', 'gateways', 'ingresses', 'secrets'.")
+	})
+
+	t.Run("Process extracts certificates", func(t *testing.T) {
+		cert := Certificate{Name: "k8s", Source: "kubernetes", Kubernetes: &KubernetesSource{Namespaces: []string{"shop"}, LabelSelector: "certalert=true"}}
+		result, err := Process([]Certificate{cert}, true)
+		assert.NoError(t, err)
+		validateCertificateInfo(t, []CertificateInfo{
+			{Name: "k8s", Subject: "CN=root", Type: "pem"},
+			{Name: "k8s", Subject: "CN=final", Type: "pem"},
+			{Name: "k8s", Subject: "CN=intermediate", Type: "pem"},
+			{Name: "k8s", Subject: "CN=root", Type: "pem"},
+			{Name: "k8s", Subject: "CN=regular,OU=MyOrganization,O=MyCompany,L=MyCity,ST=MyState,C=MyCountry", Type: "jks"},
+		}, result)
+	})
+}
+
+func TestKeystorePassword(t *testing.T) {
+	data := map[string][]byte{
+		"keystore.jks.password": []byte("exact"),
+		"keystore.password":     []byte("basename"),
+		"password":              []byte("generic"),
+		"custom":                []byte("custom"),
+	}
+
+	assert.Equal(t, "exact", keystorePassword(data, "keystore.jks", ""))
+	assert.Equal(t, "basename", keystorePassword(data, "keystore.p12", ""))
+	assert.Equal(t, "generic", keystorePassword(data, "truststore.p12", ""))
+	assert.Equal(t, "custom", keystorePassword(data, "keystore.jks", "custom"))
+	assert.Equal(t, "", keystorePassword(data, "keystore.jks", "missing"))
+}
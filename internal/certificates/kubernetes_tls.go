@@ -0,0 +1,304 @@
+package certificates
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+)
+
+const gatewayGroup = "gateway.networking.k8s.io"
+
+// gatewayResource is the Gateway API resource listed by the kubernetes source.
+var gatewayResource = schema.GroupVersionResource{Group: gatewayGroup, Version: "v1", Resource: "gateways"}
+
+// referenceGrantResource is the Gateway API resource allowing references to Secrets in other namespaces.
+var referenceGrantResource = schema.GroupVersionResource{Group: gatewayGroup, Version: "v1beta1", Resource: "referencegrants"}
+
+// newKubernetesDynamicClient creates the dynamic Kubernetes client used to list Gateway API resources.
+// It is a variable so tests can replace it with a fake client.
+var newKubernetesDynamicClient = func(opts KubernetesSource) (dynamic.Interface, error) {
+	config, err := kubernetesRestConfig(opts)
+	if err != nil {
+		return nil, err
+	}
+	return dynamic.NewForConfig(config)
+}
+
+// gateway represents the fields of a Gateway API Gateway needed to resolve its certificates.
+type gateway struct {
+	metav1.ObjectMeta `json:"metadata"`
+	Spec              struct {
+		Listeners []struct {
+			Name     string `json:"name"`
+			Hostname string `json:"hostname,omitempty"`
+			TLS      *struct {
+				CertificateRefs []struct {
+					Group     string `json:"group,omitempty"`
+					Kind      string `json:"kind,omitempty"`
+					Name      string `json:"name"`
+					Namespace string `json:"namespace,omitempty"`
+				} `json:"certificateRefs,omitempty"`
+			} `json:"tls,omitempty"`
+		} `json:"listeners"`
+	} `json:"spec"`
+}
+
+// referenceGrant represents the fields of a Gateway API ReferenceGrant needed to check a reference.
+type referenceGrant struct {
+	metav1.ObjectMeta `json:"metadata"`
+	Spec              struct {
+		From []referenceGrantFrom `json:"from"`
+		To   []referenceGrantTo   `json:"to"`
+	} `json:"spec"`
+}
+
+// referenceGrantFrom represents the resources a ReferenceGrant allows references from.
+type referenceGrantFrom struct {
+	Group     string `json:"group"`
+	Kind      string `json:"kind"`
+	Namespace string `json:"namespace"`
+}
+
+// referenceGrantTo represents the resources a ReferenceGrant allows references to.
+type referenceGrantTo struct {
+	Group string `json:"group"`
+	Kind  string `json:"kind"`
+	Name  string `json:"name,omitempty"`
+}
+
+// referenceGrants checks references of Gateways to Secrets in other namespaces against the ReferenceGrants of
+// the namespaces of the Secrets. The ReferenceGrants of a namespace are listed once.
+type referenceGrants struct {
+	client      dynamic.Interface
+	byNamespace map[string][]referenceGrant
+}
+
+// allows reports whether a ReferenceGrant in the namespace of the Secret allows Gateways of the given
+// namespace to reference the Secret.
+//
+// Parameters:
+//   - ctx: context.Context
+//     The context of the requests.
+//   - gatewayNamespace: string
+//     The namespace of the Gateway.
+//   - secretNamespace: string
+//     The namespace of the Secret.
+//   - secretName: string
+//     The name of the Secret.
+//
+// Returns:
+//   - bool
+//     True if the reference is allowed; otherwise, false.
+//   - error
+//     An error if the ReferenceGrants can't be listed.
+func (g *referenceGrants) allows(ctx context.Context, gatewayNamespace, secretNamespace, secretName string) (bool, error) {
+	grants, found := g.byNamespace[secretNamespace]
+	if !found {
+		list, err := g.client.Resource(referenceGrantResource).Namespace(secretNamespace).List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return false, fmt.Errorf("Failed to list referencegrants in namespace '%s'. %v", secretNamespace, err)
+		}
+		for _, item := range list.Items {
+			var grant referenceGrant
+			if err := runtime.DefaultUnstructuredConverter.FromUnstructured(item.Object, &grant); err != nil {
+				return false, fmt.Errorf("Failed to decode referencegrant '%s/%s'. %v", item.GetNamespace(), item.GetName(), err)
+			}
+			grants = append(grants, grant)
+		}
+		g.byNamespace[secretNamespace] = grants
+	}
+
+	for _, grant := range grants {
+		fromGateway := slices.ContainsFunc(grant.Spec.From, func(from referenceGrantFrom) bool {
+			return from.Group == gatewayGroup && from.Kind == "Gateway" && from.Namespace == gatewayNamespace
+		})
+		toSecret := slices.ContainsFunc(grant.Spec.To, func(to referenceGrantTo) bool {
+			return (to.Group == "" || to.Group == "core") && to.Kind == "Secret" && (to.Name == "" || to.Name == secretName)
+		})
+		if fromGateway && toSecret {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// fetchIngressCertificates resolves the Secrets referenced in 'spec.tls' of the Ingresses in a namespace.
+//
+// Each blob is labeled with the name of the Ingress and the hostnames of the TLS entry.
+//
+// Parameters:
+//   - ctx: context.Context
+//     The context of the requests.
+//   - client: kubernetes.Interface
+//     The Kubernetes client.
+//   - namespace: string
+//     The namespace to list the Ingresses in. Empty for all namespaces.
+//   - listOpts: metav1.ListOptions
+//     The options (e.g. the label selector) to list the Ingresses.
+//   - passwordKey: string
+//     The key holding the password of the keystores. If empty, the sibling keys are searched.
+//
+// Returns:
+//   - []Blob
+//     The certificate files of the referenced Secrets.
+//   - error
+//     An error if the Ingresses can't be listed.
+func fetchIngressCertificates(ctx context.Context, client kubernetes.Interface, namespace string, listOpts metav1.ListOptions, passwordKey string) ([]Blob, error) {
+	ingresses, err := client.NetworkingV1().Ingresses(namespace).List(ctx, listOpts)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to list ingresses in namespace '%s'. %v", namespace, err)
+	}
+
+	var blobs []Blob
+	for _, ingress := range ingresses.Items {
+		for _, tls := range ingress.Spec.TLS {
+			if tls.SecretName == "" {
+				continue
+			}
+			owner := map[string]string{
+				"ingress": ingress.Name,
+				"hosts":   strings.Join(tls.Hosts, ","),
+			}
+			blobs = append(blobs, referencedSecretBlobs(ctx, client, ingress.Namespace, tls.SecretName, passwordKey, owner)...)
+		}
+	}
+
+	return blobs, nil
+}
+
+// fetchGatewayCertificates resolves the Secrets referenced in the listener 'certificateRefs' of the
+// Gateways in a namespace.
+//
+// Each blob is labeled with the name of the Gateway, the name of the listener and its hostname.
+// References to other kinds than Secrets are skipped. Like the Gateway controller, references to Secrets in
+// other namespaces are only followed if a ReferenceGrant in the namespace of the Secret allows them; other
+// references are skipped with a warning.
+//
+// Parameters:
+//   - ctx: context.Context
+//     The context of the requests.
+//   - client: kubernetes.Interface
+//     The Kubernetes client.
+//   - opts: KubernetesSource
+//     The settings of the kubernetes source, used to create the dynamic client.
+//   - namespace: string
+//     The namespace to list the Gateways in. Empty for all namespaces.
+//   - listOpts: metav1.ListOptions
+//     The options (e.g. the label selector) to list the Gateways.
+//
+// Returns:
+//   - []Blob
+//     The certificate files of the referenced Secrets.
+//   - error
+//     An error if the Gateways can't be listed.
+func fetchGatewayCertificates(ctx context.Context, client kubernetes.Interface, opts KubernetesSource, namespace string, listOpts metav1.ListOptions) ([]Blob, error) {
+	dynamicClient, err := newKubernetesDynamicClient(opts)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to create Kubernetes client. %v", err)
+	}
+
+	list, err := dynamicClient.Resource(gatewayResource).Namespace(namespace).List(ctx, listOpts)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to list gateways in namespace '%s'. %v", namespace, err)
+	}
+
+	grants := &referenceGrants{client: dynamicClient, byNamespace: map[string][]referenceGrant{}}
+
+	var blobs []Blob
+	for _, item := range list.Items {
+		var gw gateway
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(item.Object, &gw); err != nil {
+			return nil, fmt.Errorf("Failed to decode gateway '%s/%s'. %v", item.GetNamespace(), item.GetName(), err)
+		}
+
+		for _, listener := range gw.Spec.Listeners {
+			if listener.TLS == nil {
+				continue
+			}
+			for _, ref := range listener.TLS.CertificateRefs {
+				if (ref.Group != "" && ref.Group != "core") || (ref.Kind != "" && ref.Kind != "Secret") {
+					log.Debug().Msgf("Skip certificate reference of kind '%s' in gateway '%s/%s'", ref.Kind, gw.Namespace, gw.Name)
+					continue
+				}
+
+				secretNamespace := ref.Namespace
+				if secretNamespace == "" {
+					secretNamespace = gw.Namespace
+				}
+				if secretNamespace != gw.Namespace {
+					allowed, err := grants.allows(ctx, gw.Namespace, secretNamespace, ref.Name)
+					if err != nil {
+						log.Warn().Msgf("Skip certificate reference to secret '%s/%s' in gateway '%s/%s'. %v", secretNamespace, ref.Name, gw.Namespace, gw.Name, err)
+						continue
+					}
+					if !allowed {
+						log.Warn().Msgf("Skip certificate reference to secret '%s/%s' in gateway '%s/%s' as no referencegrant allows it", secretNamespace, ref.Name, gw.Namespace, gw.Name)
+						continue
+					}
+				}
+				owner := map[string]string{
+					"gateway":  gw.Name,
+					"listener": listener.Name,
+					"hosts":    listener.Hostname,
+				}
+				blobs = append(blobs, referencedSecretBlobs(ctx, client, secretNamespace, ref.Name, opts.PasswordKey, owner)...)
+			}
+		}
+	}
+
+	return blobs, nil
+}
+
+// referencedSecretBlobs returns the certificate files of a Secret referenced by another resource.
+//
+// If the Secret can't be read, a blob with the error is returned, so the dangling reference is
+// reported for the owning resource.
+//
+// Parameters:
+//   - ctx: context.Context
+//     The context of the requests.
+//   - client: kubernetes.Interface
+//     The Kubernetes client.
+//   - namespace: string
+//     The namespace of the Secret.
+//   - name: string
+//     The name of the Secret.
+//   - passwordKey: string
+//     The key holding the password of the keystores. If empty, the sibling keys are searched.
+//   - owner: map[string]string
+//     The labels identifying the owning resource.
+//
+// Returns:
+//   - []Blob
+//     The certificate files of the Secret, labeled with the owner.
+func referencedSecretBlobs(ctx context.Context, client kubernetes.Interface, namespace, name, passwordKey string, owner map[string]string) []Blob {
+	labels := map[string]string{"namespace": namespace, "secret": name}
+	maps.Copy(labels, owner)
+
+	secret, err := client.CoreV1().Secrets(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return []Blob{{Labels: labels, Error: fmt.Sprintf("Failed to get secret '%s/%s'. %v", namespace, name, err)}}
+	}
+
+	if !isSupportedSecretType(secret.Type) {
+		return []Blob{{Labels: labels, Error: fmt.Sprintf("Secret '%s/%s' has unsupported type '%s'", namespace, name, secret.Type)}}
+	}
+
+	blobs := secretBlobs(*secret, passwordKey)
+	for i := range blobs {
+		maps.Copy(blobs[i].Labels, owner)
+	}
+
+	return blobs
+}
@@ -0,0 +1,153 @@
+package certificates
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// useFakeKubernetesDynamicClient replaces the dynamic Kubernetes client with a fake client holding the given
+// Gateways and ReferenceGrants.
+func useFakeKubernetesDynamicClient(t *testing.T, objects ...*unstructured.Unstructured) {
+	client := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(
+		runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{gatewayResource: "GatewayList", referenceGrantResource: "ReferenceGrantList"},
+	)
+	for _, obj := range objects {
+		resource := gatewayResource
+		if obj.GetKind() == "ReferenceGrant" {
+			resource = referenceGrantResource
+		}
+		if _, err := client.Resource(resource).Namespace(obj.GetNamespace()).Create(context.Background(), obj, metav1.CreateOptions{}); err != nil {
+			t.Fatalf("Failed to create %s: %v", obj.GetKind(), err)
+		}
+	}
+
+	oldNewKubernetesDynamicClient := newKubernetesDynamicClient
+	newKubernetesDynamicClient = func(opts KubernetesSource) (dynamic.Interface, error) {
+		return client, nil
+	}
+	t.Cleanup(func() { newKubernetesDynamicClient = oldNewKubernetesDynamicClient })
+}
+
+func TestFetchKubernetesTLSReferences(t *testing.T) {
+	final := readTestFile(t, "../../tests/certs/pem/final.crt")
+
+	useFakeKubernetesClient(t, fake.NewClientset(
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "web-tls", Namespace: "shop"},
+			Type:       corev1.SecretTypeTLS,
+			Data:       map[string][]byte{"tls.crt": final, "tls.key": []byte("key")},
+		},
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "shared-tls", Namespace: "certs"},
+			Type:       corev1.SecretTypeTLS,
+			Data:       map[string][]byte{"tls.crt": final, "tls.key": []byte("key")},
+		},
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "private-tls", Namespace: "certs"},
+			Type:       corev1.SecretTypeTLS,
+			Data:       map[string][]byte{"tls.crt": final, "tls.key": []byte("key")},
+		},
+		&networkingv1.Ingress{
+			ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "shop"},
+			Spec: networkingv1.IngressSpec{TLS: []networkingv1.IngressTLS{
+				{Hosts: []string{"shop.example.com", "www.shop.example.com"}, SecretName: "web-tls"},
+				{Hosts: []string{"old.example.com"}, SecretName: "missing-tls"},
+			}},
+		},
+	))
+
+	useFakeKubernetesDynamicClient(t, &unstructured.Unstructured{Object: map[string]any{
+		"apiVersion": "gateway.networking.k8s.io/v1",
+		"kind":       "Gateway",
+		"metadata":   map[string]any{"name": "public", "namespace": "shop"},
+		"spec": map[string]any{
+			"listeners": []any{
+				map[string]any{"name": "http", "protocol": "HTTP", "port": int64(80)},
+				map[string]any{
+					"name":     "https",
+					"hostname": "shop.example.com",
+					"tls": map[string]any{"certificateRefs": []any{
+						map[string]any{"name": "web-tls"},
+						map[string]any{"group": "example.com", "kind": "Certificate", "name": "other"},
+						map[string]any{"name": "shared-tls", "namespace": "certs"},
+						map[string]any{"name": "private-tls", "namespace": "certs"},
+					}},
+				},
+			},
+		},
+	}}, &unstructured.Unstructured{Object: map[string]any{
+		"apiVersion": "gateway.networking.k8s.io/v1beta1",
+		"kind":       "ReferenceGrant",
+		"metadata":   map[string]any{"name": "shop-gateways", "namespace": "certs"},
+		"spec": map[string]any{
+			"from": []any{map[string]any{"group": "gateway.networking.k8s.io", "kind": "Gateway", "namespace": "shop"}},
+			"to":   []any{map[string]any{"group": "", "kind": "Secret", "name": "shared-tls"}},
+		},
+	}})
+
+	t.Run("resolves ingress TLS secrets", func(t *testing.T) {
+		cert := Certificate{Name: "ingresses", Kubernetes: &KubernetesSource{Resources: []string{"ingresses"}}}
+		blobs, err := FetchKubernetesCertificates(cert)
+		assert.NoError(t, err)
+		assert.Len(t, blobs, 2)
+		assert.Equal(t, map[string]string{
+			"namespace": "shop",
+			"secret":    "web-tls",
+			"key":       "tls.crt",
+			"ingress":   "web",
+			"hosts":     "shop.example.com,www.shop.example.com",
+		}, blobs[0].Labels)
+		assert.Equal(t, `Failed to get secret 'shop/missing-tls'. secrets "missing-tls" not found`, blobs[1].Error)
+		assert.Equal(t, "old.example.com", blobs[1].Labels["hosts"])
+	})
+
+	t.Run("resolves gateway certificate references", func(t *testing.T) {
+		cert := Certificate{Name: "gateways", Kubernetes: &KubernetesSource{Namespaces: []string{"shop"}, Resources: []string{"gateways"}}}
+		blobs, err := FetchKubernetesCertificates(cert)
+		assert.NoError(t, err)
+		assert.Len(t, blobs, 2)
+		assert.Equal(t, map[string]string{
+			"namespace": "shop",
+			"secret":    "web-tls",
+			"key":       "tls.crt",
+			"gateway":   "public",
+			"listener":  "https",
+			"hosts":     "shop.example.com",
+		}, blobs[0].Labels)
+	})
+
+	t.Run("follows references to other namespaces only with a referencegrant", func(t *testing.T) {
+		cert := Certificate{Name: "gateways", Kubernetes: &KubernetesSource{Namespaces: []string{"shop"}, Resources: []string{"gateways"}}}
+		blobs, err := FetchKubernetesCertificates(cert)
+		assert.NoError(t, err)
+		var secrets []string
+		for _, blob := range blobs {
+			secrets = append(secrets, blob.Labels["namespace"]+"/"+blob.Labels["secret"])
+		}
+		assert.Equal(t, []string{"shop/web-tls", "certs/shared-tls"}, secrets)
+	})
+
+	t.Run("Process reports dangling references", func(t *testing.T) {
+		cert := Certificate{Name: "ingresses", Source: "kubernetes", Kubernetes: &KubernetesSource{Resources: []string{"ingresses"}}}
+		result, err := Process([]Certificate{cert}, false)
+		assert.NoError(t, err)
+		assert.Len(t, result, 2)
+		assert.Equal(t, "CN=final", result[0].Subject)
+		assert.Equal(t, "web", result[0].Labels["ingress"])
+		assert.Equal(t, `Failed to get secret 'shop/missing-tls'. secrets "missing-tls" not found`, result[1].Error)
+		assert.Equal(t, "missing-tls", result[1].Labels["secret"])
+	})
+}
@@ -0,0 +1,133 @@
+package certificates
+
+import (
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// labelNamePattern matches the label names allowed by Prometheus.
+var labelNamePattern = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// ReservedLabels contains the label names set by CertAlert itself, which can't be used as custom labels.
+var ReservedLabels = []string{"fingerprint", "instance", "job", "location", "mismatches", "reason", "role", "rule", "severity", "state", "subject", "type"}
+
+// ValidateLabels checks if the names of custom labels follow the Prometheus naming rules and are not reserved.
+//
+// Parameters:
+//   - labels: map[string]string
+//     The custom labels to validate.
+//
+// Returns:
+//   - error
+//     An error for the first invalid label name, sorted by name.
+func ValidateLabels(labels map[string]string) error {
+	for _, name := range slices.Sorted(maps.Keys(labels)) {
+		if !labelNamePattern.MatchString(name) || strings.HasPrefix(name, "__") {
+			return fmt.Errorf("Invalid label name '%s'. Must match '[a-zA-Z_][a-zA-Z0-9_]*' and must not start with '__'.", name)
+		}
+		if slices.Contains(ReservedLabels, name) {
+			return fmt.Errorf("Label name '%s' is reserved. Must not be one of '%s'.", name, strings.Join(ReservedLabels, "', '"))
+		}
+	}
+
+	return nil
+}
+
+// FormatLabels returns the labels sorted by key as comma separated 'key=value' pairs, e.g. to show the labels
+// of the source of a certificate in a single column.
+//
+// Parameters:
+//   - labels: map[string]string
+//     The labels to format.
+//
+// Returns:
+//   - string
+//     The formatted labels, empty if there are no labels.
+func FormatLabels(labels map[string]string) string {
+	pairs := make([]string, 0, len(labels))
+	for _, key := range slices.Sorted(maps.Keys(labels)) {
+		pairs = append(pairs, fmt.Sprintf("%s=%s", key, labels[key]))
+	}
+	return strings.Join(pairs, ",")
+}
+
+// CustomLabelNames returns the names of the custom labels of all certificates, e.g. to render a column per label.
+//
+// Parameters:
+//   - certInfoList: []CertificateInfo
+//     The certificate information returned by Process.
+//
+// Returns:
+//   - []string
+//     The sorted names of the custom labels.
+func CustomLabelNames(certInfoList []CertificateInfo) []string {
+	var names []string
+	for _, certInfo := range certInfoList {
+		for name := range certInfo.CustomLabels {
+			if !slices.Contains(names, name) {
+				names = append(names, name)
+			}
+		}
+	}
+	slices.Sort(names)
+	return names
+}
+
+// CustomLabelsByName returns the custom labels of all certificates keyed by the name of the certificate.
+//
+// Parameters:
+//   - certInfoList: []CertificateInfo
+//     The certificate information returned by Process.
+//   - exclude: []string
+//     The names of labels to leave out, e.g. because they are set in another way.
+//
+// Returns:
+//   - map[string]map[string]string
+//     The custom labels keyed by the name of the certificate.
+func CustomLabelsByName(certInfoList []CertificateInfo, exclude ...string) map[string]map[string]string {
+	labelsByName := make(map[string]map[string]string)
+	for _, certInfo := range certInfoList {
+		for name, value := range certInfo.CustomLabels {
+			if slices.Contains(exclude, name) {
+				continue
+			}
+			if labelsByName[certInfo.Name] == nil {
+				labelsByName[certInfo.Name] = make(map[string]string)
+			}
+			labelsByName[certInfo.Name][name] = value
+		}
+	}
+	return labelsByName
+}
+
+// withCustomLabels attaches the custom labels of the certificate configs to the certificate information
+// extracted from them, including the entries describing a failed extraction.
+//
+// Parameters:
+//   - certInfoList: []CertificateInfo
+//     The certificate information returned by Process.
+//   - certificates: []Certificate
+//     The certificate configs the information was extracted from.
+//
+// Returns:
+//   - []CertificateInfo
+//     The certificate information with the custom labels.
+func withCustomLabels(certInfoList []CertificateInfo, certificates []Certificate) []CertificateInfo {
+	labelsByName := make(map[string]map[string]string, len(certificates))
+	for _, cert := range certificates {
+		if len(cert.Labels) > 0 {
+			labelsByName[cert.Name] = cert.Labels
+		}
+	}
+
+	for i := range certInfoList {
+		if labels, found := labelsByName[certInfoList[i].Name]; found {
+			certInfoList[i].CustomLabels = maps.Clone(labels)
+		}
+	}
+
+	return certInfoList
+}
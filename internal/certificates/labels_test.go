@@ -0,0 +1,74 @@
+package certificates
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateLabels(t *testing.T) {
+	testCases := []struct {
+		Name          string
+		Labels        map[string]string
+		ExpectedError string
+	}{
+		{
+			Name:   "valid labels",
+			Labels: map[string]string{"team": "web", "_env": "prod", "cost_center2": "42"},
+		},
+		{
+			Name:   "no labels",
+			Labels: nil,
+		},
+		{
+			Name:          "invalid character",
+			Labels:        map[string]string{"team-name": "web"},
+			ExpectedError: "Invalid label name 'team-name'. Must match '[a-zA-Z_][a-zA-Z0-9_]*' and must not start with '__'.",
+		},
+		{
+			Name:          "leading digit",
+			Labels:        map[string]string{"1team": "web"},
+			ExpectedError: "Invalid label name '1team'. Must match '[a-zA-Z_][a-zA-Z0-9_]*' and must not start with '__'.",
+		},
+		{
+			Name:          "reserved prefix",
+			Labels:        map[string]string{"__name__": "web"},
+			ExpectedError: "Invalid label name '__name__'. Must match '[a-zA-Z_][a-zA-Z0-9_]*' and must not start with '__'.",
+		},
+		{
+			Name:          "reserved name",
+			Labels:        map[string]string{"subject": "web"},
+			ExpectedError: "Label name 'subject' is reserved. Must not be one of 'fingerprint', 'instance', 'job', 'location', 'mismatches', 'reason', 'role', 'rule', 'severity', 'state', 'subject', 'type'.",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			err := ValidateLabels(tc.Labels)
+			if tc.ExpectedError == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.EqualError(t, err, tc.ExpectedError)
+		})
+	}
+}
+
+func TestCustomLabels(t *testing.T) {
+	certs := []Certificate{
+		{Name: "web", Labels: map[string]string{"team": "web", "env": "prod"}},
+		{Name: "db"},
+	}
+	certInfoList := withCustomLabels([]CertificateInfo{
+		{Name: "web", Subject: "leaf"},
+		{Name: "web", Error: "Failed to extract certificate"},
+		{Name: "db"},
+	}, certs)
+
+	assert.Equal(t, map[string]string{"team": "web", "env": "prod"}, certInfoList[0].CustomLabels)
+	assert.Equal(t, map[string]string{"team": "web", "env": "prod"}, certInfoList[1].CustomLabels, "failed extractions must have the labels too")
+	assert.Nil(t, certInfoList[2].CustomLabels)
+
+	assert.Equal(t, []string{"env", "team"}, CustomLabelNames(certInfoList))
+	assert.Equal(t, map[string]map[string]string{"web": {"team": "web"}}, CustomLabelsByName(certInfoList, "env"))
+}
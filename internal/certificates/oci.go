@@ -0,0 +1,587 @@
+package certificates
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+)
+
+func init() {
+	registerSource("oci", FetchOCICertificates, true)
+}
+
+const (
+	maxImageFileSize   = 1 << 20        // Files in an image larger than this are not considered as certificates
+	whiteoutPrefix     = ".wh."         // Prefix of whiteout files which remove a file from lower layers
+	whiteoutOpaqueName = ".wh..wh..opq" // Whiteout file which removes all content of a directory from lower layers
+
+	mediaTypeOCIIndex      = "application/vnd.oci.image.index.v1+json"
+	mediaTypeDockerList    = "application/vnd.docker.distribution.manifest.list.v2+json"
+	annotationRefName      = "org.opencontainers.image.ref.name"
+	annotationImageName    = "io.containerd.image.name"
+	defaultRegistry        = "docker.io"
+	defaultRepositoryOwner = "library"
+)
+
+// ociDescriptor represents an OCI content descriptor.
+type ociDescriptor struct {
+	MediaType   string            `json:"mediaType"`
+	Digest      string            `json:"digest"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+	Platform    *struct {
+		Architecture string `json:"architecture"`
+		OS           string `json:"os"`
+		Variant      string `json:"variant,omitempty"`
+	} `json:"platform,omitempty"`
+}
+
+// ociIndex represents an OCI image index (index.json).
+type ociIndex struct {
+	Manifests []ociDescriptor `json:"manifests"`
+}
+
+// ociManifest represents an OCI image manifest.
+type ociManifest struct {
+	MediaType string          `json:"mediaType"`
+	Layers    []ociDescriptor `json:"layers"`
+	Manifests []ociDescriptor `json:"manifests"` // only set if the manifest is an index
+}
+
+// dockerManifest represents an entry of the manifest.json of a 'docker save' tarball.
+type dockerManifest struct {
+	RepoTags []string `json:"RepoTags"`
+	Layers   []string `json:"Layers"`
+}
+
+// imageArchive provides access to the files of an OCI image layout or a 'docker save' tarball.
+type imageArchive interface {
+	open(name string) (io.ReadCloser, error)
+}
+
+// dirArchive is an image archive stored as a directory (OCI image layout).
+type dirArchive string
+
+func (d dirArchive) open(name string) (io.ReadCloser, error) {
+	return os.Open(filepath.Join(string(d), filepath.FromSlash(name)))
+}
+
+// tarArchive is an image archive stored as an (optionally gzip compressed) tarball.
+//
+// The entries of the tarball are indexed in a single pass, so every file is read directly at its offset
+// instead of scanning the tarball again. A gzip compressed tarball is decompressed once into a temporary
+// file, as it can't be read at an offset.
+type tarArchive struct {
+	file    *os.File
+	temp    bool                // temp is set if file is a temporary file which is removed on close
+	entries map[string]tarEntry // entries are the regular files of the tarball, keyed by their cleaned path
+}
+
+// tarEntry is the location of the content of a file inside a tarball.
+type tarEntry struct {
+	offset int64
+	size   int64
+}
+
+// openTarArchive opens and indexes a tarball.
+//
+// Parameters:
+//   - name: string
+//     The path of the tarball.
+//
+// Returns:
+//   - *tarArchive
+//     The indexed tarball, which must be closed.
+//   - error
+//     An error if the tarball can't be read.
+func openTarArchive(name string) (*tarArchive, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	archive := &tarArchive{file: f}
+
+	magic := make([]byte, 2)
+	if _, err := io.ReadFull(f, magic); err == nil && bytes.Equal(magic, []byte{0x1f, 0x8b}) {
+		if err := archive.decompress(); err != nil {
+			archive.close()
+			return nil, err
+		}
+	}
+
+	if err := archive.index(); err != nil {
+		archive.close()
+		return nil, err
+	}
+
+	return archive, nil
+}
+
+// decompress replaces the gzip compressed file of the archive with a decompressed temporary file.
+func (t *tarArchive) decompress() error {
+	if _, err := t.file.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	gr, err := gzip.NewReader(t.file)
+	if err != nil {
+		return err
+	}
+
+	temp, err := os.CreateTemp("", "certalert-image-*.tar")
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(temp, gr); err != nil {
+		temp.Close()
+		os.Remove(temp.Name())
+		return err
+	}
+
+	t.file.Close()
+	t.file, t.temp = temp, true
+	return nil
+}
+
+// index records the offset and size of every regular file of the tarball.
+func (t *tarArchive) index() error {
+	if _, err := t.file.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	t.entries = map[string]tarEntry{}
+	// tar.Reader reads the file without buffering, so the position of the file is the start of the content
+	tr := tar.NewReader(t.file)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		offset, err := t.file.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return err
+		}
+		t.entries[cleanArchivePath(hdr.Name)] = tarEntry{offset: offset, size: hdr.Size}
+	}
+}
+
+func (t *tarArchive) open(name string) (io.ReadCloser, error) {
+	entry, found := t.entries[name]
+	if !found {
+		return nil, fmt.Errorf("open %s: %w", name, os.ErrNotExist)
+	}
+	return io.NopCloser(io.NewSectionReader(t.file, entry.offset, entry.size)), nil
+}
+
+// close closes the tarball and removes the temporary decompressed file, if any.
+func (t *tarArchive) close() {
+	t.file.Close()
+	if t.temp {
+		os.Remove(t.file.Name())
+	}
+}
+
+// FetchOCICertificates reads all certificates embedded in the layers of a container image.
+//
+// The image is read from a local OCI image layout directory or a 'docker save' tarball, so no
+// registry access is needed. The layers are applied in order, honoring whiteouts, and every file
+// of the resulting file system is checked for certificates by its file extension or its content.
+// Each returned blob is labeled with the image reference and the path inside the image.
+//
+// Parameters:
+//   - cert: Certificate
+//     A Certificate struct with the path to the image and optional 'oci' settings.
+//
+// Returns:
+//   - []Blob
+//     A slice containing all certificate files found in the image.
+//   - error
+//     An error if the image can't be read.
+func FetchOCICertificates(cert Certificate) ([]Blob, error) {
+	var opts OCISource
+	if cert.OCI != nil {
+		opts = *cert.OCI
+	}
+
+	info, err := os.Stat(cert.Path)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to open image '%s'. %v", cert.Path, err)
+	}
+
+	var archive imageArchive = dirArchive(cert.Path)
+	if !info.IsDir() {
+		tarball, err := openTarArchive(cert.Path)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to read image '%s'. %v", cert.Path, err)
+		}
+		defer tarball.close()
+		archive = tarball
+	}
+
+	reference, layers, err := resolveImageLayers(archive, opts)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read image '%s'. %v", cert.Path, err)
+	}
+	if reference == "" {
+		reference = filepath.Base(cert.Path)
+	}
+
+	files := map[string]Blob{}
+	for _, layer := range layers {
+		if err := applyImageLayer(archive, layer, files); err != nil {
+			return nil, fmt.Errorf("Failed to read layer '%s' of image '%s'. %v", layer, reference, err)
+		}
+	}
+
+	var blobs []Blob
+	for name, blob := range files {
+		if len(opts.Paths) > 0 && !matchesAnyPattern("/"+name, opts.Paths) {
+			continue
+		}
+		blob.Labels = map[string]string{"image": reference, "path": "/" + name}
+		blobs = append(blobs, blob)
+	}
+	sort.Slice(blobs, func(i, j int) bool { return blobs[i].Path < blobs[j].Path })
+
+	log.Debug().Msgf("Found %d certificate files in image '%s'", len(blobs), reference)
+
+	return blobs, nil
+}
+
+// resolveImageLayers returns the reference and the ordered list of layer files of the selected image.
+//
+// Parameters:
+//   - archive: imageArchive
+//     The image archive to read.
+//   - opts: OCISource
+//     The settings used to select the image and platform.
+//
+// Returns:
+//   - string
+//     The reference of the selected image, if known.
+//   - []string
+//     The paths of the layer files inside the archive, lowest layer first.
+//   - error
+//     An error if no matching image is found.
+func resolveImageLayers(archive imageArchive, opts OCISource) (string, []string, error) {
+	var index ociIndex
+	err := readArchiveJSON(archive, "index.json", &index)
+	if err == nil {
+		return resolveOCILayers(archive, index, opts)
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		return "", nil, err
+	}
+
+	var manifests []dockerManifest
+	if err := readArchiveJSON(archive, "manifest.json", &manifests); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return "", nil, errors.New("neither 'index.json' nor 'manifest.json' found")
+		}
+		return "", nil, err
+	}
+
+	for _, m := range manifests {
+		if opts.Reference == "" {
+			return firstOrEmpty(m.RepoTags), m.Layers, nil
+		}
+		for _, tag := range m.RepoTags {
+			if referencesEqual(opts.Reference, tag) {
+				return tag, m.Layers, nil
+			}
+		}
+	}
+
+	return "", nil, fmt.Errorf("image '%s' not found", opts.Reference)
+}
+
+// resolveOCILayers returns the reference and layer files of the selected image of an OCI image layout.
+func resolveOCILayers(archive imageArchive, index ociIndex, opts OCISource) (string, []string, error) {
+	for _, desc := range index.Manifests {
+		reference := desc.Annotations[annotationImageName]
+		if reference == "" {
+			reference = desc.Annotations[annotationRefName]
+		}
+
+		if opts.Reference != "" &&
+			!referencesEqual(opts.Reference, desc.Annotations[annotationImageName]) &&
+			opts.Reference != desc.Annotations[annotationRefName] {
+			continue
+		}
+		if opts.Reference != "" {
+			reference = opts.Reference
+		}
+
+		manifest, err := readOCIManifest(archive, desc)
+		if err != nil {
+			return "", nil, err
+		}
+
+		// Multi platform image, select the requested platform
+		if desc.MediaType == mediaTypeOCIIndex || desc.MediaType == mediaTypeDockerList || len(manifest.Manifests) > 0 {
+			platformDesc, err := selectPlatform(manifest.Manifests, opts.Platform)
+			if err != nil {
+				return "", nil, err
+			}
+			if manifest, err = readOCIManifest(archive, platformDesc); err != nil {
+				return "", nil, err
+			}
+		}
+
+		var layers []string
+		for _, layer := range manifest.Layers {
+			layers = append(layers, blobPath(layer.Digest))
+		}
+		return reference, layers, nil
+	}
+
+	return "", nil, fmt.Errorf("image '%s' not found", opts.Reference)
+}
+
+// selectPlatform selects the manifest of the requested platform (os/architecture[/variant]).
+// If no platform is requested, the first manifest of a known platform is selected.
+func selectPlatform(manifests []ociDescriptor, platform string) (ociDescriptor, error) {
+	for _, m := range manifests {
+		if m.Platform == nil {
+			continue
+		}
+		current := m.Platform.OS + "/" + m.Platform.Architecture
+		if platform == "" && current != "unknown/unknown" {
+			return m, nil
+		}
+		if platform == current || (m.Platform.Variant != "" && platform == current+"/"+m.Platform.Variant) {
+			return m, nil
+		}
+	}
+	return ociDescriptor{}, fmt.Errorf("platform '%s' not found", platform)
+}
+
+// readOCIManifest reads the manifest referenced by the given descriptor.
+func readOCIManifest(archive imageArchive, desc ociDescriptor) (ociManifest, error) {
+	var manifest ociManifest
+	err := readArchiveJSON(archive, blobPath(desc.Digest), &manifest)
+	return manifest, err
+}
+
+// readArchiveJSON reads and decodes a JSON file of an image archive.
+func readArchiveJSON(archive imageArchive, name string, v any) error {
+	f, err := archive.open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if err := json.NewDecoder(f).Decode(v); err != nil {
+		return fmt.Errorf("Failed to decode '%s': %v", name, err)
+	}
+	return nil
+}
+
+// applyImageLayer applies a layer on top of the already collected certificate files.
+//
+// Files removed by whiteouts or replaced by files which are no certificates are removed,
+// certificate files are added or replaced.
+//
+// Parameters:
+//   - archive: imageArchive
+//     The image archive to read the layer from.
+//   - layer: string
+//     The path of the layer inside the archive.
+//   - files: map[string]Blob
+//     The certificate files collected from the lower layers, keyed by path.
+//
+// Returns:
+//   - error
+//     An error if the layer can't be read.
+func applyImageLayer(archive imageArchive, layer string, files map[string]Blob) error {
+	f, err := archive.open(layer)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	r, err := decompress(f)
+	if err != nil {
+		return err
+	}
+
+	// Whiteouts only affect lower layers, so they are applied before the content of this layer
+	var removed, replaced, opaque []string
+	added := map[string]Blob{}
+
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		name := cleanArchivePath(hdr.Name)
+		dir, base := path.Split(name)
+
+		switch {
+		case base == whiteoutOpaqueName:
+			opaque = append(opaque, strings.TrimSuffix(dir, "/"))
+			continue
+		case strings.HasPrefix(base, whiteoutPrefix):
+			removed = append(removed, dir+strings.TrimPrefix(base, whiteoutPrefix))
+			continue
+		}
+
+		if hdr.Typeflag == tar.TypeDir {
+			continue
+		}
+
+		replaced = append(replaced, name) // A new file always replaces the file of a lower layer
+		if hdr.Typeflag != tar.TypeReg || hdr.Size > maxImageFileSize {
+			continue
+		}
+
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			return err
+		}
+
+		certType := detectImageFileType(name, data)
+		if certType == "" {
+			continue
+		}
+		added[name] = Blob{Path: name, Type: certType, Data: data, Discovered: true}
+	}
+
+	for _, dir := range opaque {
+		removeArchivePath(files, dir, false)
+	}
+	for _, name := range removed {
+		removeArchivePath(files, name, true)
+	}
+	for _, name := range replaced {
+		delete(files, name)
+	}
+	for name, blob := range added {
+		files[name] = blob
+	}
+
+	return nil
+}
+
+// detectImageFileType returns the certificate type of a file found in an image or an empty string
+// if the file is not a certificate.
+func detectImageFileType(name string, data []byte) string {
+	certType := inferCertificateType(name, data)
+	if certType == "" {
+		return ""
+	}
+
+	if canonicalType := FileExtensionsToType[certType]; !looksLikeCertificateType(canonicalType, data) {
+		return ""
+	}
+
+	return certType
+}
+
+// removeArchivePath removes all files below the given path. If self is true, the path itself is removed too.
+func removeArchivePath(files map[string]Blob, name string, self bool) {
+	if self {
+		delete(files, name)
+	}
+	prefix := name + "/"
+	if name == "" {
+		prefix = ""
+	}
+	for file := range files {
+		if strings.HasPrefix(file, prefix) {
+			delete(files, file)
+		}
+	}
+}
+
+// decompress returns a reader which transparently decompresses gzip compressed data.
+func decompress(r io.Reader) (io.Reader, error) {
+	br := bufio.NewReader(r)
+	magic, err := br.Peek(2)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	if bytes.Equal(magic, []byte{0x1f, 0x8b}) {
+		return gzip.NewReader(br)
+	}
+	return br, nil
+}
+
+// cleanArchivePath normalizes a path of a tar entry (e.g. './etc/ssl/' becomes 'etc/ssl').
+func cleanArchivePath(name string) string {
+	return strings.TrimPrefix(path.Clean("/"+name), "/")
+}
+
+// blobPath returns the path of a blob inside an OCI image layout for the given digest.
+func blobPath(digest string) string {
+	return "blobs/" + strings.Replace(digest, ":", "/", 1)
+}
+
+// referencesEqual reports whether two image references point to the same image,
+// e.g. 'nginx' and 'docker.io/library/nginx:latest'.
+func referencesEqual(a, b string) bool {
+	return a != "" && b != "" && normalizeReference(a) == normalizeReference(b)
+}
+
+// normalizeReference adds the default registry, repository owner and tag to an image reference.
+func normalizeReference(ref string) string {
+	name, tag := ref, ""
+	if i := strings.Index(name, "@"); i != -1 {
+		name, tag = name[:i], name[i:]
+	} else if i := strings.LastIndex(name, ":"); i > strings.LastIndex(name, "/") {
+		name, tag = name[:i], name[i:]
+	}
+	if tag == "" {
+		tag = ":latest"
+	}
+
+	parts := strings.SplitN(name, "/", 2)
+	switch {
+	case len(parts) == 1:
+		name = defaultRegistry + "/" + defaultRepositoryOwner + "/" + name
+	case !strings.ContainsAny(parts[0], ".:") && parts[0] != "localhost":
+		name = defaultRegistry + "/" + name
+	}
+
+	return name + tag
+}
+
+// matchesAnyPattern reports whether the path matches one of the given glob patterns.
+func matchesAnyPattern(name string, patterns []string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// firstOrEmpty returns the first element of a slice or an empty string.
+func firstOrEmpty(s []string) string {
+	if len(s) == 0 {
+		return ""
+	}
+	return s[0]
+}
@@ -0,0 +1,256 @@
+package certificates
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// layerEntry represents a file or directory in a test image layer.
+type layerEntry struct {
+	Name string
+	Data []byte
+	Dir  bool
+}
+
+// buildLayer creates an uncompressed tar layer with the given entries.
+func buildLayer(t *testing.T, entries []layerEntry) []byte {
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for _, e := range entries {
+		hdr := &tar.Header{Name: e.Name, Mode: 0o644, Size: int64(len(e.Data)), Typeflag: tar.TypeReg}
+		if e.Dir {
+			hdr = &tar.Header{Name: e.Name, Mode: 0o755, Typeflag: tar.TypeDir}
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			t.Fatalf("Failed to write tar header: %v", err)
+		}
+		if _, err := tw.Write(e.Data); err != nil {
+			t.Fatalf("Failed to write tar content: %v", err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatalf("Failed to close tar writer: %v", err)
+	}
+	return buf.Bytes()
+}
+
+// buildTestLayers returns two layers where the upper one removes files of the lower one.
+func buildTestLayers(t *testing.T) [][]byte {
+	chain, err := os.ReadFile("../../tests/certs/pem/chain.pem")
+	if err != nil {
+		t.Fatalf("Failed to read certificate: %v", err)
+	}
+	final, err := os.ReadFile("../../tests/certs/pem/final.crt")
+	if err != nil {
+		t.Fatalf("Failed to read certificate: %v", err)
+	}
+	jks, err := os.ReadFile("../../tests/certs/jks/regular.jks")
+	if err != nil {
+		t.Fatalf("Failed to read certificate: %v", err)
+	}
+
+	lower := buildLayer(t, []layerEntry{
+		{Name: "etc/", Dir: true},
+		{Name: "etc/ssl/", Dir: true},
+		{Name: "etc/ssl/certs/ca.pem", Data: chain},
+		{Name: "etc/ssl/old.crt", Data: final},
+		{Name: "etc/ssl/bundle", Data: final},
+		{Name: "opt/app/keystore.jks", Data: jks},
+		{Name: "opt/app/index.ts", Data: []byte("export const x = 1;\n")},
+	})
+	upper := buildLayer(t, []layerEntry{
+		{Name: "./etc/ssl/", Dir: true},
+		{Name: "./etc/ssl/.wh.old.crt"},
+		{Name: "./opt/app/.wh..wh..opq"},
+		{Name: "./opt/app/readme.txt", Data: []byte("nothing to see")},
+	})
+	return [][]byte{lower, upper}
+}
+
+// writeTar writes a tarball with the given files.
+func writeTar(t *testing.T, path string, files map[string][]byte) {
+	var entries []layerEntry
+	for name, data := range files {
+		entries = append(entries, layerEntry{Name: name, Data: data})
+	}
+	if err := os.WriteFile(path, buildLayer(t, entries), 0o644); err != nil {
+		t.Fatalf("Failed to write tarball: %v", err)
+	}
+}
+
+// writeOCILayout writes an OCI image layout with the given gzip compressed layers to dir.
+func writeOCILayout(t *testing.T, dir string, layers [][]byte) {
+	writeBlob := func(data []byte) string {
+		digest := fmt.Sprintf("sha256:%x", sha256.Sum256(data))
+		p := filepath.Join(dir, "blobs", "sha256", digest[len("sha256:"):])
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatalf("Failed to create blob dir: %v", err)
+		}
+		if err := os.WriteFile(p, data, 0o644); err != nil {
+			t.Fatalf("Failed to write blob: %v", err)
+		}
+		return digest
+	}
+	marshal := func(v any) []byte {
+		b, err := json.Marshal(v)
+		if err != nil {
+			t.Fatalf("Failed to marshal: %v", err)
+		}
+		return b
+	}
+
+	var layerDescs []map[string]any
+	for _, layer := range layers {
+		var buf bytes.Buffer
+		gw := gzip.NewWriter(&buf)
+		gw.Write(layer)
+		gw.Close()
+		layerDescs = append(layerDescs, map[string]any{
+			"mediaType": "application/vnd.oci.image.layer.v1.tar+gzip",
+			"digest":    writeBlob(buf.Bytes()),
+		})
+	}
+
+	manifest := writeBlob(marshal(map[string]any{
+		"mediaType": "application/vnd.oci.image.manifest.v1+json",
+		"layers":    layerDescs,
+	}))
+	platformIndex := writeBlob(marshal(map[string]any{
+		"mediaType": mediaTypeOCIIndex,
+		"manifests": []map[string]any{
+			{"digest": "sha256:0000", "platform": map[string]string{"os": "unknown", "architecture": "unknown"}},
+			{"digest": manifest, "platform": map[string]string{"os": "linux", "architecture": "amd64"}},
+		},
+	}))
+	index := marshal(map[string]any{
+		"manifests": []map[string]any{{
+			"mediaType": mediaTypeOCIIndex,
+			"digest":    platformIndex,
+			"annotations": map[string]string{
+				annotationImageName: "registry.example.com/shop/web:1.0",
+				annotationRefName:   "1.0",
+			},
+		}},
+	})
+	if err := os.WriteFile(filepath.Join(dir, "index.json"), index, 0o644); err != nil {
+		t.Fatalf("Failed to write index: %v", err)
+	}
+}
+
+func TestFetchOCICertificates(t *testing.T) {
+	layers := buildTestLayers(t)
+
+	dockerSave := filepath.Join(t.TempDir(), "image.tar")
+	writeTar(t, dockerSave, map[string][]byte{
+		"manifest.json":   []byte(`[{"RepoTags":["shop/web:1.0"],"Layers":["lower/layer.tar","upper/layer.tar"]}]`),
+		"lower/layer.tar": layers[0],
+		"upper/layer.tar": layers[1],
+	})
+
+	ociLayout := t.TempDir()
+	writeOCILayout(t, ociLayout, layers)
+
+	t.Run("docker save tarball honors whiteouts", func(t *testing.T) {
+		blobs, err := FetchOCICertificates(Certificate{Name: "image", Path: dockerSave})
+		assert.NoError(t, err)
+		assert.Len(t, blobs, 2)
+		assert.Equal(t, "etc/ssl/bundle", blobs[0].Path)
+		assert.Equal(t, "pem", blobs[0].Type)
+		assert.Equal(t, map[string]string{"image": "shop/web:1.0", "path": "/etc/ssl/bundle"}, blobs[0].Labels)
+		assert.Equal(t, "etc/ssl/certs/ca.pem", blobs[1].Path)
+	})
+
+	t.Run("docker save tarball with reference", func(t *testing.T) {
+		blobs, err := FetchOCICertificates(Certificate{Name: "image", Path: dockerSave, OCI: &OCISource{Reference: "docker.io/shop/web:1.0"}})
+		assert.NoError(t, err)
+		assert.Len(t, blobs, 2)
+	})
+
+	t.Run("docker save tarball with unknown reference", func(t *testing.T) {
+		_, err := FetchOCICertificates(Certificate{Name: "image", Path: dockerSave, OCI: &OCISource{Reference: "shop/web:2.0"}})
+		assert.EqualError(t, err, fmt.Sprintf("Failed to read image '%s'. image 'shop/web:2.0' not found", dockerSave))
+	})
+
+	t.Run("OCI layout with platform index", func(t *testing.T) {
+		blobs, err := FetchOCICertificates(Certificate{Name: "image", Path: ociLayout, OCI: &OCISource{Reference: "1.0", Paths: []string{"/etc/ssl/certs/*"}}})
+		assert.NoError(t, err)
+		assert.Len(t, blobs, 1)
+		assert.Equal(t, map[string]string{"image": "1.0", "path": "/etc/ssl/certs/ca.pem"}, blobs[0].Labels)
+	})
+
+	t.Run("OCI layout with unknown platform", func(t *testing.T) {
+		_, err := FetchOCICertificates(Certificate{Name: "image", Path: ociLayout, OCI: &OCISource{Platform: "linux/arm64"}})
+		assert.EqualError(t, err, fmt.Sprintf("Failed to read image '%s'. platform 'linux/arm64' not found", ociLayout))
+	})
+
+	t.Run("Process extracts certificates of image", func(t *testing.T) {
+		result, err := Process([]Certificate{{Name: "image", Source: "oci", Path: ociLayout}}, true)
+		assert.NoError(t, err)
+		validateCertificateInfo(t, []CertificateInfo{
+			{Name: "image", Subject: "CN=final", Type: "pem"},
+			{Name: "image", Subject: "CN=final", Type: "pem"},
+			{Name: "image", Subject: "CN=intermediate", Type: "pem"},
+			{Name: "image", Subject: "CN=root", Type: "pem"},
+		}, result)
+		assert.Equal(t, "registry.example.com/shop/web:1.0", result[0].Labels["image"])
+	})
+
+	t.Run("gzip compressed docker save tarball", func(t *testing.T) {
+		data, err := os.ReadFile(dockerSave)
+		assert.NoError(t, err)
+		var buf bytes.Buffer
+		gw := gzip.NewWriter(&buf)
+		gw.Write(data)
+		gw.Close()
+		compressed := filepath.Join(t.TempDir(), "image.tar.gz")
+		assert.NoError(t, os.WriteFile(compressed, buf.Bytes(), 0o644))
+
+		blobs, err := FetchOCICertificates(Certificate{Name: "image", Path: compressed})
+		assert.NoError(t, err)
+		assert.Len(t, blobs, 2)
+		assert.Equal(t, "etc/ssl/bundle", blobs[0].Path)
+	})
+
+	t.Run("Process skips files which only look like certificates", func(t *testing.T) {
+		image := filepath.Join(t.TempDir(), "image.tar")
+		writeTar(t, image, map[string][]byte{
+			"manifest.json": []byte(`[{"RepoTags":["shop/web:1.0"],"Layers":["layer.tar"]}]`),
+			"layer.tar": buildLayer(t, []layerEntry{
+				{Name: "usr/share/doc/howto.txt", Data: []byte("Paste the certificate:\n-----BEGIN CERTIFICATE-----\n...\n-----END CERTIFICATE-----\n")},
+				{Name: "etc/ssl/broken.pem", Data: []byte("-----BEGIN CERTIFICATE-----\nbroken\n-----END CERTIFICATE-----\n")},
+			}),
+		})
+
+		result, err := Process([]Certificate{{Name: "image", Source: "oci", Path: image}}, true)
+		assert.NoError(t, err)
+		assert.Empty(t, result)
+	})
+
+	t.Run("missing image", func(t *testing.T) {
+		_, err := FetchOCICertificates(Certificate{Name: "image", Path: "missing.tar"})
+		assert.EqualError(t, err, "Failed to open image 'missing.tar'. stat missing.tar: no such file or directory")
+	})
+}
+
+func TestNormalizeReference(t *testing.T) {
+	tests := map[string]string{
+		"nginx":                          "docker.io/library/nginx:latest",
+		"nginx:1.25":                     "docker.io/library/nginx:1.25",
+		"shop/web":                       "docker.io/shop/web:latest",
+		"localhost:5000/web":             "localhost:5000/web:latest",
+		"registry.example.com/a/b:1.0":   "registry.example.com/a/b:1.0",
+		"localhost/web@sha256:abcdef012": "localhost/web@sha256:abcdef012",
+	}
+	for ref, expected := range tests {
+		assert.Equal(t, expected, normalizeReference(ref), ref)
+	}
+}
@@ -53,10 +53,11 @@ func ExtractP12CertificatesInfo(cert Certificate, certificateData []byte, failOn
 		subject := generateCertificateSubject(certificate.Subject.ToRDNSequence().String(), len(certificateInfoList)+1)
 
 		certificateInfo := CertificateInfo{
-			Name:    cert.Name,
-			Subject: subject,
-			Epoch:   certificate.NotAfter.Unix(),
-			Type:    "p12",
+			Name:        cert.Name,
+			Subject:     subject,
+			Epoch:       certificate.NotAfter.Unix(),
+			Type:        "p12",
+			certificate: certificate,
 		}
 		certificateInfoList = append(certificateInfoList, certificateInfo)
 
@@ -63,10 +63,11 @@ func ExtractP7CertificatesInfo(cert Certificate, certificateData []byte, failOnE
 				subject := generateCertificateSubject(certificate.Subject.ToRDNSequence().String(), len(certificateInfoList)+1)
 
 				certificateInfo := CertificateInfo{
-					Name:    cert.Name,
-					Subject: subject,
-					Epoch:   certificate.NotAfter.Unix(),
-					Type:    "p7",
+					Name:        cert.Name,
+					Subject:     subject,
+					Epoch:       certificate.NotAfter.Unix(),
+					Type:        "p7",
+					certificate: certificate,
 				}
 				certificateInfoList = append(certificateInfoList, certificateInfo)
 
@@ -83,10 +84,11 @@ func ExtractP7CertificatesInfo(cert Certificate, certificateData []byte, failOnE
 			subject := generateCertificateSubject(certificate.Subject.ToRDNSequence().String(), len(certificateInfoList)+1)
 
 			certificateInfo := CertificateInfo{
-				Name:    cert.Name,
-				Subject: subject,
-				Epoch:   certificate.NotAfter.Unix(),
-				Type:    "p7",
+				Name:        cert.Name,
+				Subject:     subject,
+				Epoch:       certificate.NotAfter.Unix(),
+				Type:        "p7",
+				certificate: certificate,
 			}
 			certificateInfoList = append(certificateInfoList, certificateInfo)
 
@@ -20,7 +20,8 @@ func init() {
 //
 // The function parses all PEM blocks from the input certificateData, filters by type ("CERTIFICATE"),
 // and extracts certificate information. It logs information about each certificate, including its
-// subject, expiration time, and type.
+// subject, expiration time, and type. If the file contains an unencrypted private key, every
+// certificate is marked, so the policy engine can report it.
 //
 // Parameters:
 //   - cert: Certificate
@@ -38,6 +39,7 @@ func init() {
 //     function may return a non-nil error along with the partial list of CertificateInfo.
 func ExtractPEMCertificatesInfo(cert Certificate, certificateData []byte, failOnError bool) ([]CertificateInfo, error) {
 	var certificateInfoList []CertificateInfo
+	var unencryptedPrivateKey bool
 
 	// Parse all PEM blocks and filter by type
 	for {
@@ -58,15 +60,19 @@ func ExtractPEMCertificatesInfo(cert Certificate, certificateData []byte, failOn
 			subject := generateCertificateSubject(certificate.Subject.ToRDNSequence().String(), len(certificateInfoList)+1)
 
 			certificateInfo := CertificateInfo{
-				Name:    cert.Name,
-				Subject: subject,
-				Epoch:   certificate.NotAfter.Unix(),
-				Type:    "pem",
+				Name:        cert.Name,
+				Subject:     subject,
+				Epoch:       certificate.NotAfter.Unix(),
+				Type:        "pem",
+				certificate: certificate,
 			}
 			certificateInfoList = append(certificateInfoList, certificateInfo)
 
 			log.Debug().Msgf("Certificate '%s' expires on %s", subject, certificateInfo.ExpiryAsTime())
 		default:
+			if isUnencryptedPrivateKey(block) {
+				unencryptedPrivateKey = true
+			}
 			log.Debug().Msgf("Skip PEM block of type '%s'", block.Type)
 		}
 
@@ -78,5 +84,9 @@ func ExtractPEMCertificatesInfo(cert Certificate, certificateData []byte, failOn
 		return certificateInfoList, handleFailOnError(&certificateInfoList, cert.Name, "pem", fmt.Sprintf("Failed to decode any certificate in '%s'", cert.Name), failOnError)
 	}
 
+	for i := range certificateInfoList {
+		certificateInfoList[i].unencryptedPrivateKey = unencryptedPrivateKey
+	}
+
 	return certificateInfoList, nil
 }
@@ -0,0 +1,107 @@
+package certificates
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Results of the pin check of a certificate.
+const (
+	PinningMatch    = "match"
+	PinningMismatch = "mismatch"
+)
+
+// spkiPinPrefix is the optional prefix of a SPKI pin, as used by HTTP Public Key Pinning.
+const spkiPinPrefix = "sha256/"
+
+// Validate checks if the pinned fingerprints are SHA-256 fingerprints and the SPKI pins are base64
+// encoded SHA-256 hashes.
+//
+// Returns:
+//   - error
+//     An error describing the first invalid pin.
+func (p *Pins) Validate() error {
+	for _, fingerprint := range p.Fingerprints {
+		if decoded, err := hex.DecodeString(normalizeFingerprint(fingerprint)); err != nil || len(decoded) != sha256.Size {
+			return fmt.Errorf("Invalid fingerprint pin '%s'. Must be a SHA-256 fingerprint.", fingerprint)
+		}
+	}
+
+	for _, pin := range p.SPKI {
+		if decoded, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(pin, spkiPinPrefix)); err != nil || len(decoded) != sha256.Size {
+			return fmt.Errorf("Invalid SPKI pin '%s'. Must be a base64 encoded SHA-256 hash of the public key.", pin)
+		}
+	}
+
+	return nil
+}
+
+// evaluatePins checks the leaf certificates against the pins of the certificate config.
+//
+// Only certificates with the role leaf or self-signed are checked. A certificate matches if its
+// SHA-256 fingerprint or the SHA-256 hash of its public key matches one of the pins, so a certificate
+// renewed with the same key still matches a SPKI pin. If none of the extracted certificates is a
+// leaf, e.g. the source was replaced with a CA bundle, every extracted certificate is reported as a
+// mismatch, as the pinned certificate is missing.
+//
+// Parameters:
+//   - cert: Certificate
+//     The certificate config with the pins.
+//   - certInfoList: []CertificateInfo
+//     The certificates extracted for the certificate config. The result is stored in each checked certificate.
+func evaluatePins(cert Certificate, certInfoList []CertificateInfo) {
+	if cert.Pins == nil || (len(cert.Pins.Fingerprints) == 0 && len(cert.Pins.SPKI) == 0) {
+		return
+	}
+
+	fingerprints := make([]string, 0, len(cert.Pins.Fingerprints))
+	for _, fingerprint := range cert.Pins.Fingerprints {
+		fingerprints = append(fingerprints, normalizeFingerprint(fingerprint))
+	}
+	spkiPins := make([]string, 0, len(cert.Pins.SPKI))
+	for _, pin := range cert.Pins.SPKI {
+		spkiPins = append(spkiPins, strings.TrimPrefix(pin, spkiPinPrefix))
+	}
+
+	checked := false
+	for i := range certInfoList {
+		certInfo := &certInfoList[i]
+		if certInfo.certificate == nil || (certInfo.Role != RoleLeaf && certInfo.Role != RoleSelfSigned) {
+			continue
+		}
+		checked = true
+
+		if slices.Contains(fingerprints, certificateFingerprint(certInfo.certificate)) || slices.Contains(spkiPins, spkiHash(certInfo.certificate.RawSubjectPublicKeyInfo)) {
+			certInfo.Pinning = PinningMatch
+			continue
+		}
+
+		certInfo.Pinning = PinningMismatch
+		log.Warn().Msgf("Certificate '%s' of '%s' doesn't match any of its pins", certInfo.Subject, certInfo.Name)
+	}
+
+	if checked {
+		return
+	}
+
+	for i := range certInfoList {
+		certInfo := &certInfoList[i]
+		if certInfo.certificate == nil {
+			continue
+		}
+		certInfo.Pinning = PinningMismatch
+		log.Warn().Msgf("Certificate '%s' of '%s' doesn't match any of its pins, '%s' contains no leaf certificate", certInfo.Subject, certInfo.Name, cert.Name)
+	}
+}
+
+// spkiHash returns the base64 encoded SHA-256 hash of a DER encoded SubjectPublicKeyInfo.
+func spkiHash(rawSubjectPublicKeyInfo []byte) string {
+	sum := sha256.Sum256(rawSubjectPublicKeyInfo)
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
@@ -0,0 +1,93 @@
+package certificates
+
+import (
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProcessPins(t *testing.T) {
+	bundle := Certificate{Name: "bundle", Path: writeLeafBundle(t), Type: "pem"}
+
+	result, err := Process([]Certificate{bundle}, true)
+	assert.NoError(t, err)
+	leaf := result[0].certificate
+	fingerprint := strings.ToUpper(certificateFingerprint(leaf))
+	spki := spkiHash(leaf.RawSubjectPublicKeyInfo)
+	otherFingerprint := "67:AF:9D:53:15:33:8C:B3:73:AB:3E:4B:93:FE:97:6B:FA:AA:F4:15:D0:EF:CA:BE:4F:E3:B5:A2:9A:BD:27:19"
+
+	testCases := []struct {
+		Name    string
+		Pins    *Pins
+		Pinning string
+	}{
+		{Name: "no pins", Pins: nil, Pinning: ""},
+		{Name: "fingerprint", Pins: &Pins{Fingerprints: []string{otherFingerprint, fingerprint}}, Pinning: PinningMatch},
+		{Name: "spki", Pins: &Pins{SPKI: []string{spkiPinPrefix + spki}}, Pinning: PinningMatch},
+		{Name: "spki without prefix", Pins: &Pins{Fingerprints: []string{otherFingerprint}, SPKI: []string{spki}}, Pinning: PinningMatch},
+		{Name: "mismatch", Pins: &Pins{Fingerprints: []string{otherFingerprint}, SPKI: []string{"47DEQpj8HBSa+/TImW+5JCeuQeRkm5NMpJWZG3hSuFU="}}, Pinning: PinningMismatch},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			cert := bundle
+			cert.Pins = tc.Pins
+
+			result, err := Process([]Certificate{cert}, true)
+			assert.NoError(t, err)
+			assert.Len(t, result, 2)
+			assert.Equal(t, tc.Pinning, result[0].Pinning)
+			// Expiry is still reported and CA certificates are never checked
+			assert.NotZero(t, result[0].Epoch)
+			assert.Empty(t, result[1].Pinning)
+		})
+	}
+}
+
+func TestProcessPinsWithoutLeaf(t *testing.T) {
+	root, rootKey := createTestCertificate(t, "root", true, true, nil, nil)
+	intermediate, _ := createTestCertificate(t, "intermediate", true, true, root, rootKey)
+	path := filepath.Join(t.TempDir(), "ca-bundle.pem")
+	data := append(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: intermediate.Raw}), pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: root.Raw})...)
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatalf("Failed to write certificates: %v", err)
+	}
+
+	// The pinned leaf was replaced with a bundle of CA certificates
+	pins := &Pins{SPKI: []string{"47DEQpj8HBSa+/TImW+5JCeuQeRkm5NMpJWZG3hSuFU="}}
+	result, err := Process([]Certificate{{Name: "partner", Path: path, Type: "pem", Pins: pins}}, true)
+	assert.NoError(t, err)
+	assert.Len(t, result, 2)
+	for _, certInfo := range result {
+		assert.NotEqual(t, RoleLeaf, certInfo.Role)
+		assert.Equal(t, PinningMismatch, certInfo.Pinning)
+	}
+}
+
+func TestPinsValidate(t *testing.T) {
+	testCases := []struct {
+		Name          string
+		Pins          Pins
+		ExpectedError string
+	}{
+		{Name: "valid", Pins: Pins{Fingerprints: []string{"67af9d5315338cb373ab3e4b93fe976bfaaaf415d0efcabe4fe3b5a29abd2719"}, SPKI: []string{"sha256/47DEQpj8HBSa+/TImW+5JCeuQeRkm5NMpJWZG3hSuFU="}}},
+		{Name: "short fingerprint", Pins: Pins{Fingerprints: []string{"AB:CD"}}, ExpectedError: "Invalid fingerprint pin 'AB:CD'. Must be a SHA-256 fingerprint."},
+		{Name: "no hex fingerprint", Pins: Pins{Fingerprints: []string{strings.Repeat("x", 64)}}, ExpectedError: "Invalid fingerprint pin '" + strings.Repeat("x", 64) + "'. Must be a SHA-256 fingerprint."},
+		{Name: "invalid spki", Pins: Pins{SPKI: []string{"sha256/abc"}}, ExpectedError: "Invalid SPKI pin 'sha256/abc'. Must be a base64 encoded SHA-256 hash of the public key."},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			err := tc.Pins.Validate()
+			if tc.ExpectedError == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tc.ExpectedError)
+			}
+		})
+	}
+}
@@ -0,0 +1,212 @@
+package certificates
+
+import (
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// Severities of a policy rule. Rules with the severity 'off' are not evaluated.
+const (
+	SeverityOff     = "off"
+	SeverityInfo    = "info"
+	SeverityWarning = "warning"
+	SeverityError   = "error"
+)
+
+// Severities contains all severities a policy rule can have.
+var Severities = []string{SeverityOff, SeverityInfo, SeverityWarning, SeverityError}
+
+const (
+	defaultMinRSAKeyBits   = 2048 // Minimum size of RSA keys if not configured
+	defaultMaxValidityDays = 398  // Maximum validity of leaf certificates if not configured, as required by browsers
+)
+
+// Policy represents the configuration of the policy rules certificates are linted with.
+type Policy struct {
+	Rules           map[string]string `mapstructure:"rules,omitempty" yaml:"rules,omitempty"`
+	MinRSAKeyBits   int               `mapstructure:"minRSAKeyBits,omitempty" yaml:"minRSAKeyBits,omitempty"`
+	MaxValidityDays int               `mapstructure:"maxValidityDays,omitempty" yaml:"maxValidityDays,omitempty"`
+}
+
+// Finding represents a violation of a policy rule by a certificate.
+type Finding struct {
+	Rule     string `mapstructure:"rule"`
+	Severity string `mapstructure:"severity"`
+	Message  string `mapstructure:"message"`
+}
+
+// String returns the rule and the severity of the finding, e.g. 'weak-key:critical'.
+func (f Finding) String() string {
+	return f.Rule + ":" + f.Severity
+}
+
+// policyRule represents a rule of the policy engine.
+type policyRule struct {
+	Severity string                                               // Severity is the default severity of the rule
+	LeafOnly bool                                                 // LeafOnly restricts the rule to certificates with the role leaf or self-signed
+	Check    func(certInfo CertificateInfo, policy Policy) string // Check returns a message if the certificate violates the rule
+}
+
+// policyRules maps the name of each rule to its implementation.
+var policyRules = map[string]policyRule{
+	"rsa-key-size": {
+		Severity: SeverityError,
+		Check: func(certInfo CertificateInfo, policy Policy) string {
+			key, ok := certInfo.certificate.PublicKey.(*rsa.PublicKey)
+			if !ok || key.N.BitLen() >= policy.minRSAKeyBits() {
+				return ""
+			}
+			return fmt.Sprintf("RSA key has %d bits, less than %d.", key.N.BitLen(), policy.minRSAKeyBits())
+		},
+	},
+	"weak-signature-algorithm": {
+		Severity: SeverityError,
+		Check: func(certInfo CertificateInfo, policy Policy) string {
+			switch algorithm := certInfo.certificate.SignatureAlgorithm; algorithm {
+			case x509.MD2WithRSA, x509.MD5WithRSA, x509.SHA1WithRSA, x509.DSAWithSHA1, x509.ECDSAWithSHA1:
+				return fmt.Sprintf("Signature algorithm '%s' is weak.", algorithm)
+			}
+			return ""
+		},
+	},
+	"dsa-key": {
+		Severity: SeverityError,
+		Check: func(certInfo CertificateInfo, policy Policy) string {
+			if certInfo.certificate.PublicKeyAlgorithm == x509.DSA {
+				return "DSA keys are deprecated."
+			}
+			return ""
+		},
+	},
+	"missing-san": {
+		Severity: SeverityWarning,
+		LeafOnly: true,
+		Check: func(certInfo CertificateInfo, policy Policy) string {
+			cert := certInfo.certificate
+			if len(cert.DNSNames)+len(cert.IPAddresses)+len(cert.EmailAddresses)+len(cert.URIs) > 0 {
+				return ""
+			}
+			return "Certificate has no subject alternative names."
+		},
+	},
+	"overlong-validity": {
+		Severity: SeverityWarning,
+		LeafOnly: true,
+		Check: func(certInfo CertificateInfo, policy Policy) string {
+			cert := certInfo.certificate
+			days := int(cert.NotAfter.Sub(cert.NotBefore).Hours() / 24)
+			if days <= policy.maxValidityDays() {
+				return ""
+			}
+			return fmt.Sprintf("Validity of %d days exceeds %d days.", days, policy.maxValidityDays())
+		},
+	},
+	"unencrypted-private-key": {
+		Severity: SeverityWarning,
+		Check: func(certInfo CertificateInfo, policy Policy) string {
+			if !certInfo.unencryptedPrivateKey {
+				return ""
+			}
+			return "File contains an unencrypted private key."
+		},
+	},
+}
+
+// PolicyRules contains the names of all policy rules, sorted by name.
+var PolicyRules = slices.Sorted(maps.Keys(policyRules))
+
+// Validate checks if the policy only configures known rules with known severities.
+//
+// Returns:
+//   - error
+//     An error describing the first invalid setting.
+func (p Policy) Validate() error {
+	for _, rule := range slices.Sorted(maps.Keys(p.Rules)) {
+		if _, found := policyRules[rule]; !found {
+			return fmt.Errorf("Unknown policy rule '%s'. Must be one of '%s'.", rule, strings.Join(PolicyRules, "', '"))
+		}
+		if !slices.Contains(Severities, p.Rules[rule]) {
+			return fmt.Errorf("Invalid severity '%s' for policy rule '%s'. Must be one of '%s'.", p.Rules[rule], rule, strings.Join(Severities, "', '"))
+		}
+	}
+
+	if p.MinRSAKeyBits < 0 {
+		return fmt.Errorf("'minRSAKeyBits' must not be negative.")
+	}
+	if p.MaxValidityDays < 0 {
+		return fmt.Errorf("'maxValidityDays' must not be negative.")
+	}
+
+	return nil
+}
+
+// Severity returns the configured severity of a rule, falling back to the default severity of the rule.
+func (p Policy) Severity(rule string) string {
+	if severity, found := p.Rules[rule]; found {
+		return severity
+	}
+	return policyRules[rule].Severity
+}
+
+// minRSAKeyBits returns the configured minimum size of RSA keys, falling back to defaultMinRSAKeyBits.
+func (p Policy) minRSAKeyBits() int {
+	if p.MinRSAKeyBits == 0 {
+		return defaultMinRSAKeyBits
+	}
+	return p.MinRSAKeyBits
+}
+
+// maxValidityDays returns the configured maximum validity of leaf certificates, falling back to defaultMaxValidityDays.
+func (p Policy) maxValidityDays() int {
+	if p.MaxValidityDays == 0 {
+		return defaultMaxValidityDays
+	}
+	return p.MaxValidityDays
+}
+
+// Lint evaluates the policy rules for every certificate and stores the violations as findings.
+//
+// Rules with the severity 'off' are skipped. Some rules, like 'missing-san', only apply to certificates
+// with the role leaf or self-signed. Entries describing a failed extraction are left untouched.
+//
+// Parameters:
+//   - certInfoList: []CertificateInfo
+//     The certificates returned by Process. The findings are stored in each certificate.
+//   - policy: Policy
+//     The policy with the severities of the rules.
+func Lint(certInfoList []CertificateInfo, policy Policy) {
+	for i := range certInfoList {
+		certInfo := &certInfoList[i]
+		if certInfo.certificate == nil {
+			continue
+		}
+
+		certInfo.Findings = nil
+		isLeaf := certInfo.Role == RoleLeaf || certInfo.Role == RoleSelfSigned
+		for _, name := range PolicyRules {
+			rule := policyRules[name]
+			severity := policy.Severity(name)
+			if severity == SeverityOff || (rule.LeafOnly && !isLeaf) {
+				continue
+			}
+
+			if message := rule.Check(*certInfo, policy); message != "" {
+				certInfo.Findings = append(certInfo.Findings, Finding{Rule: name, Severity: severity, Message: message})
+			}
+		}
+	}
+}
+
+// isUnencryptedPrivateKey reports whether a PEM block is a private key which is not encrypted.
+// Keys in the OpenSSH format are not considered, as their encryption is not visible in the PEM block.
+func isUnencryptedPrivateKey(block *pem.Block) bool {
+	if !strings.HasSuffix(block.Type, "PRIVATE KEY") || block.Type == "ENCRYPTED PRIVATE KEY" || block.Type == "OPENSSH PRIVATE KEY" {
+		return false
+	}
+	return !strings.Contains(block.Headers["Proc-Type"], "ENCRYPTED")
+}
@@ -0,0 +1,121 @@
+package certificates
+
+import (
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLint(t *testing.T) {
+	now := time.Now()
+	weak := &x509.Certificate{
+		SignatureAlgorithm: x509.SHA1WithRSA,
+		PublicKeyAlgorithm: x509.RSA,
+		PublicKey:          &rsa.PublicKey{N: new(big.Int).Lsh(big.NewInt(1), 1023), E: 65537},
+		NotBefore:          now,
+		NotAfter:           now.Add(3 * 365 * 24 * time.Hour),
+	}
+	dsaCert := &x509.Certificate{
+		SignatureAlgorithm: x509.DSAWithSHA256,
+		PublicKeyAlgorithm: x509.DSA,
+		NotBefore:          now,
+		NotAfter:           now.Add(90 * 24 * time.Hour),
+		DNSNames:           []string{"www.example.com"},
+	}
+
+	t.Run("default policy", func(t *testing.T) {
+		certInfoList := []CertificateInfo{
+			{Name: "weak", Role: RoleLeaf, certificate: weak},
+			{Name: "weak-ca", Role: RoleRoot, certificate: weak, unencryptedPrivateKey: true},
+			{Name: "dsa", Role: RoleLeaf, certificate: dsaCert},
+			{Name: "failed", Error: "Failed to extract certificate."},
+		}
+
+		Lint(certInfoList, Policy{})
+
+		assert.Equal(t, []Finding{
+			{Rule: "missing-san", Severity: SeverityWarning, Message: "Certificate has no subject alternative names."},
+			{Rule: "overlong-validity", Severity: SeverityWarning, Message: "Validity of 1095 days exceeds 398 days."},
+			{Rule: "rsa-key-size", Severity: SeverityError, Message: "RSA key has 1024 bits, less than 2048."},
+			{Rule: "weak-signature-algorithm", Severity: SeverityError, Message: "Signature algorithm 'SHA1-RSA' is weak."},
+		}, certInfoList[0].Findings)
+		// Leaf only rules are not evaluated for CA certificates
+		assert.Equal(t, []Finding{
+			{Rule: "rsa-key-size", Severity: SeverityError, Message: "RSA key has 1024 bits, less than 2048."},
+			{Rule: "unencrypted-private-key", Severity: SeverityWarning, Message: "File contains an unencrypted private key."},
+			{Rule: "weak-signature-algorithm", Severity: SeverityError, Message: "Signature algorithm 'SHA1-RSA' is weak."},
+		}, certInfoList[1].Findings)
+		assert.Equal(t, []Finding{{Rule: "dsa-key", Severity: SeverityError, Message: "DSA keys are deprecated."}}, certInfoList[2].Findings)
+		assert.Empty(t, certInfoList[3].Findings)
+	})
+
+	t.Run("configured policy", func(t *testing.T) {
+		certInfoList := []CertificateInfo{{Name: "weak", Role: RoleLeaf, certificate: weak}}
+
+		Lint(certInfoList, Policy{
+			Rules:           map[string]string{"missing-san": SeverityOff, "weak-signature-algorithm": SeverityInfo},
+			MinRSAKeyBits:   1024,
+			MaxValidityDays: 1100,
+		})
+
+		assert.Equal(t, []Finding{
+			{Rule: "weak-signature-algorithm", Severity: SeverityInfo, Message: "Signature algorithm 'SHA1-RSA' is weak."},
+		}, certInfoList[0].Findings)
+	})
+
+	t.Run("processed certificates", func(t *testing.T) {
+		result, err := Process([]Certificate{
+			{Name: "chain", Path: "../../tests/certs/pem/chain.pem", Type: "pem"},
+			{Name: "bundle", Path: writeLeafBundle(t), Type: "pem"},
+		}, true)
+		assert.NoError(t, err)
+
+		Lint(result, Policy{})
+
+		for _, certInfo := range result {
+			if certInfo.Name == "chain" {
+				assert.Equal(t, []Finding{{Rule: "unencrypted-private-key", Severity: SeverityWarning, Message: "File contains an unencrypted private key."}}, certInfo.Findings)
+			} else {
+				assert.Empty(t, certInfo.Findings)
+			}
+		}
+	})
+}
+
+func TestPolicyValidate(t *testing.T) {
+	testCases := []struct {
+		Name          string
+		Policy        Policy
+		ExpectedError string
+	}{
+		{Name: "empty", Policy: Policy{}},
+		{Name: "valid", Policy: Policy{Rules: map[string]string{"dsa-key": SeverityWarning, "missing-san": SeverityOff}, MinRSAKeyBits: 3072}},
+		{Name: "unknown rule", Policy: Policy{Rules: map[string]string{"sha1": SeverityError}}, ExpectedError: "Unknown policy rule 'sha1'. Must be one of 'dsa-key', 'missing-san', 'overlong-validity', 'rsa-key-size', 'unencrypted-private-key', 'weak-signature-algorithm'."},
+		{Name: "unknown severity", Policy: Policy{Rules: map[string]string{"dsa-key": "critical"}}, ExpectedError: "Invalid severity 'critical' for policy rule 'dsa-key'. Must be one of 'off', 'info', 'warning', 'error'."},
+		{Name: "negative validity", Policy: Policy{MaxValidityDays: -1}, ExpectedError: "'maxValidityDays' must not be negative."},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			err := tc.Policy.Validate()
+			if tc.ExpectedError == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tc.ExpectedError)
+			}
+		})
+	}
+}
+
+func TestIsUnencryptedPrivateKey(t *testing.T) {
+	assert.True(t, isUnencryptedPrivateKey(&pem.Block{Type: "PRIVATE KEY"}))
+	assert.True(t, isUnencryptedPrivateKey(&pem.Block{Type: "RSA PRIVATE KEY"}))
+	assert.False(t, isUnencryptedPrivateKey(&pem.Block{Type: "RSA PRIVATE KEY", Headers: map[string]string{"Proc-Type": "4,ENCRYPTED"}}))
+	assert.False(t, isUnencryptedPrivateKey(&pem.Block{Type: "ENCRYPTED PRIVATE KEY"}))
+	assert.False(t, isUnencryptedPrivateKey(&pem.Block{Type: "CERTIFICATE"}))
+}
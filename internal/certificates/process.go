@@ -2,7 +2,8 @@ package certificates
 
 import (
 	"fmt"
-	"os"
+	"maps"
+	"slices"
 
 	"github.com/rs/zerolog/log"
 )
@@ -14,9 +15,11 @@ import (
 // information about each certificate.
 //
 // The function iterates through each certificate, checking for disabled status and logging
-// processing details. It reads the raw certificate data from the specified file, infers the type
+// processing details. It reads the raw certificate data from the configured source, infers the type
 // if not explicitly specified, and calls the corresponding extraction function. The extracted
-// certificate information is then added to the result list.
+// certificate information is filtered by the filters of the certificate, checked against the
+// expected names, issuer and pins of the certificate, gets its status from the thresholds of the certificate
+// and is then added to the result list. Every entry gets the custom labels of its certificate config.
 //
 // Parameters:
 //   - certificates: []Certificate
@@ -41,43 +44,157 @@ func Process(certificates []Certificate, failOnError bool) (certificatesInfo []C
 
 		log.Debug().Msgf("Processing certificate '%s'", cert.Name)
 
-		certData, err := os.ReadFile(cert.Path)
-		if err != nil {
-			// Accessibility of the file is checked in the config validation, if reached
-			// here, the file exists but can't be read for some reason.
-			if err := handleFailOnError(&certInfoList, cert.Name, cert.Type, fmt.Sprintf("Failed to read certificate file '%s'. %v", cert.Path, err), failOnError); err != nil {
+		fetchFunc, found := SourceToFetchFunction[cert.SourceName()]
+		if !found {
+			// This should never happen as the config validation ensures that the source is valid
+			if err := handleFailOnError(&certInfoList, cert.Name, cert.Type, fmt.Sprintf("Unknown certificate source '%s'", cert.Source), failOnError); err != nil {
 				return nil, err
 			}
 			continue
 		}
 
-		// If user specify the type, we need to convert it to the canonical type
-		inferredType, found := FileExtensionsToType[cert.Type]
-		if !found {
-			// This should never happen as the config validation ensures that the type is valid
-			if err := handleFailOnError(&certInfoList, cert.Name, cert.Type, fmt.Sprintf("Unknown certificate type '%s'", cert.Type), failOnError); err != nil {
+		blobs, err := fetchFunc(cert)
+		if err != nil {
+			if err := handleFailOnError(&certInfoList, cert.Name, cert.Type, err.Error(), failOnError); err != nil {
 				return nil, err
 			}
 			continue
 		}
 
-		extractFunc, found := TypeToExtractionFunction[inferredType]
-		if !found {
-			// This should never happen as the config validation ensures that the type is valid
-			if err := handleFailOnError(&certInfoList, cert.Name, cert.Type, fmt.Sprintf("Unknown certificate type '%s'", cert.Type), failOnError); err != nil {
+		var extracted []CertificateInfo
+		for _, blob := range blobs {
+			certs, err := processBlob(cert, blob, failOnError)
+			if err != nil {
 				return nil, err
 			}
-			continue
+			extracted = append(extracted, certs...)
 		}
 
-		certs, err := extractFunc(cert, certData, failOnError)
+		filtered, err := applyFilters(cert.Filters, extracted)
 		if err != nil {
-			// err is only returned if failOnError is true
-			return nil, fmt.Errorf("Error extracting certificate information: %v", err)
+			if err := handleFailOnError(&certInfoList, cert.Name, cert.Type, fmt.Sprintf("Certificate '%s' has an invalid filter. %v", cert.Name, err), failOnError); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		evaluateExpectations(cert, filtered)
+		evaluatePins(cert, filtered)
+		evaluateThresholds(cert, filtered)
+		certInfoList = append(certInfoList, filtered...)
+	}
+
+	return withCustomLabels(certInfoList, certificates), nil
+}
+
+// processBlob extracts the certificate information from a single blob read from a source.
+//
+// The type of the blob is taken from the blob itself, the certificate config or inferred from
+// the path and content of the blob, in this order. Every extracted certificate is classified
+// by its role in the chain of the blob, gets its validity state and is labeled with the labels
+// of the blob. If the source failed to read the blob, its error is reported. Certificates which can't
+// be extracted from a discovered blob are skipped.
+//
+// Parameters:
+//   - cert: Certificate
+//     The certificate config the blob belongs to.
+//   - blob: Blob
+//     The raw certificate data to extract.
+//   - failOnError: bool
+//     A flag indicating whether to fail immediately on encountering an error.
+//
+// Returns:
+//   - []CertificateInfo
+//     A slice of CertificateInfo structs containing information about each certificate in the blob.
+//   - error
+//     An error, only returned if failOnError is true.
+func processBlob(cert Certificate, blob Blob, failOnError bool) ([]CertificateInfo, error) {
+	var certInfoList []CertificateInfo
+
+	if blob.Error != "" {
+		if err := handleFailOnError(&certInfoList, cert.Name, blob.Type, blob.Error, failOnError); err != nil {
+			return nil, err
+		}
+		return withLabels(certInfoList, blob.Labels), nil
+	}
+
+	certType := blob.Type
+	if certType == "" {
+		certType = cert.Type
+	}
+	if certType == "" {
+		certType = inferCertificateType(blob.Path, blob.Data)
+	}
+
+	// If user specify the type, we need to convert it to the canonical type
+	inferredType, found := FileExtensionsToType[certType]
+	if !found {
+		// This should never happen as the config validation ensures that the type is valid
+		if err := handleFailOnError(&certInfoList, cert.Name, certType, fmt.Sprintf("Unknown certificate type '%s'", certType), failOnError); err != nil {
+			return nil, err
+		}
+		return withLabels(certInfoList, blob.Labels), nil
+	}
+
+	extractFunc, found := TypeToExtractionFunction[inferredType]
+	if !found {
+		// This should never happen as the config validation ensures that the type is valid
+		if err := handleFailOnError(&certInfoList, cert.Name, certType, fmt.Sprintf("Unknown certificate type '%s'", certType), failOnError); err != nil {
+			return nil, err
+		}
+		return withLabels(certInfoList, blob.Labels), nil
+	}
+
+	if blob.Password != "" {
+		cert.Password = blob.Password
+	}
+
+	if blob.Discovered {
+		// A file which only looks like a certificate, e.g. a text file containing '-----BEGIN ', must not fail the
+		// whole source, so only the certificates which could be extracted are reported
+		certs, _ := extractFunc(cert, blob.Data, false)
+		certs = slices.DeleteFunc(certs, func(ci CertificateInfo) bool { return ci.Error != "" })
+		if len(certs) == 0 {
+			log.Debug().Msgf("Skip '%s' of certificate '%s' as it contains no valid certificate", blob.Path, cert.Name)
+			return nil, nil
 		}
+		classifyRoles(certs)
+		setValidity(certs)
+		return withLabels(certs, blob.Labels), nil
+	}
+
+	certs, err := extractFunc(cert, blob.Data, failOnError)
+	if err != nil {
+		// err is only returned if failOnError is true
+		return nil, fmt.Errorf("Error extracting certificate information: %v", err)
+	}
+	classifyRoles(certs)
+	setValidity(certs)
 
-		certInfoList = append(certInfoList, certs...)
+	return withLabels(certs, blob.Labels), nil
+}
+
+// withLabels attaches the given labels to every certificate information in the list.
+//
+// Parameters:
+//   - certInfoList: []CertificateInfo
+//     The list of certificate information to label.
+//   - labels: map[string]string
+//     The labels to attach. Existing labels with the same name are overwritten.
+//
+// Returns:
+//   - []CertificateInfo
+//     The labeled list of certificate information.
+func withLabels(certInfoList []CertificateInfo, labels map[string]string) []CertificateInfo {
+	if len(labels) == 0 {
+		return certInfoList
+	}
+
+	for i := range certInfoList {
+		if certInfoList[i].Labels == nil {
+			certInfoList[i].Labels = make(map[string]string, len(labels))
+		}
+		maps.Copy(certInfoList[i].Labels, labels)
 	}
 
-	return certInfoList, nil
+	return certInfoList
 }
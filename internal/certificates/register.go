@@ -70,3 +70,44 @@ func registerCertificateType(certType string, e extractFunction, extensions ...s
 	// Append the extensions to the sorted list
 	FileExtensionsTypesSorted = append(FileExtensionsTypesSorted, extensions...)
 }
+
+// fetchFunction is a function type representing the signature for reading the raw certificate data of a certificate.
+type fetchFunction func(cert Certificate) ([]Blob, error)
+
+// SourceToFetchFunction maps each source to its corresponding fetch function.
+// The map allows dynamic selection of the appropriate fetch function based on the certificate source.
+var SourceToFetchFunction = map[string]fetchFunction{}
+
+// SourcesWithLocalPath contains the sources which read the 'path' of a certificate from the local file system.
+// The accessibility of the path of these sources is checked during the config validation.
+var SourcesWithLocalPath []string
+
+// registerSource registers a certificate source along with its fetch function.
+//
+// This function adds an entry to the SourceToFetchFunction map, mapping the provided source to the
+// corresponding fetchFunction. If the source reads the 'path' from the local file system, it is added
+// to SourcesWithLocalPath.
+//
+// If the source is already registered, the function panics with a corresponding error message.
+//
+// Parameters:
+//   - source: string
+//     The certificate source to register.
+//   - f: fetchFunction
+//     The fetch function associated with the source.
+//   - localPath: bool
+//     Whether the source reads the 'path' of a certificate from the local file system.
+//
+// Panics:
+//   - If source is already registered.
+func registerSource(source string, f fetchFunction, localPath bool) {
+	if _, exists := SourceToFetchFunction[source]; exists {
+		panic(fmt.Sprintf("Certificate source '%s' is already registered", source))
+	}
+
+	SourceToFetchFunction[source] = f
+
+	if localPath {
+		SourcesWithLocalPath = append(SourcesWithLocalPath, source)
+	}
+}
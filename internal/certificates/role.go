@@ -0,0 +1,76 @@
+package certificates
+
+import (
+	"bytes"
+	"crypto/x509"
+)
+
+// Roles of a certificate within a chain.
+const (
+	RoleLeaf         = "leaf"         // RoleLeaf is an end-entity certificate, e.g. of a service.
+	RoleIntermediate = "intermediate" // RoleIntermediate is a CA certificate issued by another CA.
+	RoleRoot         = "root"         // RoleRoot is a self-signed CA certificate.
+	RoleSelfSigned   = "self-signed"  // RoleSelfSigned is a self-signed certificate which is not a CA.
+)
+
+// Roles contains all roles a certificate can be classified as.
+var Roles = []string{RoleLeaf, RoleIntermediate, RoleRoot, RoleSelfSigned}
+
+// classifyRoles sets the role of every certificate extracted from the same certificate file.
+//
+// Certificates with basic constraints are classified by IsCA and by matching their issuer and subject.
+// Certificates without basic constraints (e.g. X.509 v1) are classified by their position in the
+// chain: they are an intermediate if they issued another certificate of the same file.
+//
+// Parameters:
+//   - certInfoList: []CertificateInfo
+//     The certificates extracted from a single certificate file. Entries describing a failed
+//     extraction are left untouched.
+func classifyRoles(certInfoList []CertificateInfo) {
+	var chain []*x509.Certificate
+	for _, certInfo := range certInfoList {
+		if certInfo.certificate != nil {
+			chain = append(chain, certInfo.certificate)
+		}
+	}
+
+	for i := range certInfoList {
+		if cert := certInfoList[i].certificate; cert != nil {
+			certInfoList[i].Role = certificateRole(cert, chain)
+		}
+	}
+}
+
+// certificateRole returns the role of a certificate.
+//
+// Parameters:
+//   - cert: *x509.Certificate
+//     The certificate to classify.
+//   - chain: []*x509.Certificate
+//     All certificates of the file the certificate was extracted from.
+//
+// Returns:
+//   - string
+//     One of Roles.
+func certificateRole(cert *x509.Certificate, chain []*x509.Certificate) string {
+	if isSelfSigned(cert) {
+		if isCACertificate(cert) {
+			return RoleRoot
+		}
+		return RoleSelfSigned
+	}
+
+	if cert.BasicConstraintsValid {
+		if cert.IsCA {
+			return RoleIntermediate
+		}
+		return RoleLeaf
+	}
+
+	for _, other := range chain {
+		if other != cert && bytes.Equal(other.RawIssuer, cert.RawSubject) {
+			return RoleIntermediate
+		}
+	}
+	return RoleLeaf
+}
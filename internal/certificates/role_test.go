@@ -0,0 +1,85 @@
+package certificates
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// createTestCertificate creates a certificate with the given common name signed by the parent.
+// If parent is nil, the certificate is self-signed.
+func createTestCertificate(t *testing.T, cn string, isCA, basicConstraints bool, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) (*x509.Certificate, *ecdsa.PrivateKey) {
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: cn},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(24 * time.Hour),
+		IsCA:                  isCA,
+		BasicConstraintsValid: basicConstraints,
+	}
+	if parent == nil {
+		parent, parentKey = template, key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	if err != nil {
+		t.Fatalf("Failed to create certificate: %v", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("Failed to parse certificate: %v", err)
+	}
+	return cert, key
+}
+
+func TestCertificateRole(t *testing.T) {
+	root, rootKey := createTestCertificate(t, "root", true, true, nil, nil)
+	intermediate, intermediateKey := createTestCertificate(t, "intermediate", true, true, root, rootKey)
+	leaf, _ := createTestCertificate(t, "leaf", false, true, intermediate, intermediateKey)
+	selfSigned, _ := createTestCertificate(t, "self-signed", false, true, nil, nil)
+
+	legacyRoot, legacyRootKey := createTestCertificate(t, "legacy-root", false, false, nil, nil)
+	legacyIntermediate, legacyIntermediateKey := createTestCertificate(t, "legacy-intermediate", false, false, legacyRoot, legacyRootKey)
+	legacyLeaf, _ := createTestCertificate(t, "legacy-leaf", false, false, legacyIntermediate, legacyIntermediateKey)
+
+	chain := []*x509.Certificate{leaf, intermediate, root}
+	assert.Equal(t, RoleRoot, certificateRole(root, chain))
+	assert.Equal(t, RoleIntermediate, certificateRole(intermediate, chain))
+	assert.Equal(t, RoleLeaf, certificateRole(leaf, chain))
+	assert.Equal(t, RoleSelfSigned, certificateRole(selfSigned, []*x509.Certificate{selfSigned}))
+
+	legacyChain := []*x509.Certificate{legacyLeaf, legacyIntermediate, legacyRoot}
+	assert.Equal(t, RoleRoot, certificateRole(legacyRoot, legacyChain))
+	assert.Equal(t, RoleIntermediate, certificateRole(legacyIntermediate, legacyChain))
+	assert.Equal(t, RoleLeaf, certificateRole(legacyLeaf, legacyChain))
+	// Without the certificate it issued, the position in the chain is unknown
+	assert.Equal(t, RoleLeaf, certificateRole(legacyIntermediate, []*x509.Certificate{legacyIntermediate}))
+}
+
+func TestProcessRoles(t *testing.T) {
+	result, err := Process([]Certificate{
+		{Name: "bundle", Path: writeLeafBundle(t), Type: "pem"},
+		{Name: "root", Path: "../../tests/certs/pem/root.crt", Type: "pem"},
+		{Name: "missing", Path: "../../tests/certs/pem/missing.crt", Type: "pem"},
+	}, false)
+	assert.NoError(t, err)
+
+	roles := map[string]string{}
+	for _, certInfo := range result {
+		roles[certInfo.Name+"/"+certInfo.Subject] = certInfo.Role
+	}
+	assert.Equal(t, map[string]string{
+		"bundle/CN=www.example.com": RoleLeaf,
+		"bundle/CN=bundle-ca":       RoleRoot,
+		"root/CN=root":              RoleRoot,
+		"missing/":                  "",
+	}, roles)
+}
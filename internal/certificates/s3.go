@@ -0,0 +1,418 @@
+package certificates
+
+import (
+	"certalert/internal/resolve"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+func init() {
+	registerSource("s3", FetchS3Certificates, false)
+}
+
+const (
+	s3DefaultRegion = "us-east-1"                                                        // Region used if no region is configured
+	s3EmptyHash     = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855" // SHA-256 of an empty payload
+)
+
+// s3Credentials represents the resolved credentials to sign S3 requests.
+type s3Credentials struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+}
+
+// s3ListBucketResult represents the fields of a ListObjectsV2 response used by the s3 source.
+type s3ListBucketResult struct {
+	IsTruncated           bool   `xml:"IsTruncated"`
+	NextContinuationToken string `xml:"NextContinuationToken"`
+	Contents              []struct {
+		Key  string `xml:"Key"`
+		Size int64  `xml:"Size"`
+	} `xml:"Contents"`
+}
+
+// s3Error represents an error response of S3.
+type s3Error struct {
+	Code    string `xml:"Code"`
+	Message string `xml:"Message"`
+}
+
+// FetchS3Certificates downloads the certificate files stored in an S3 compatible bucket.
+//
+// The objects of the bucket are listed with ListObjectsV2, optionally restricted to a prefix. If patterns
+// are set, only objects whose key matches one of the glob patterns are downloaded, otherwise all objects
+// with a known certificate file extension. Objects whose content doesn't look like a certificate file are
+// skipped. Requests are signed with AWS Signature Version 4 if credentials are configured. The credentials
+// are resolved with resolve.ResolveVariable. Each blob is labeled with the bucket and the key of the object.
+//
+// Parameters:
+//   - cert: Certificate
+//     A Certificate struct with the 's3' settings.
+//
+// Returns:
+//   - []Blob
+//     A slice containing all certificate files found in the bucket.
+//   - error
+//     An error if the objects can't be listed or downloaded.
+func FetchS3Certificates(cert Certificate) ([]Blob, error) {
+	if cert.S3 == nil || cert.S3.Bucket == "" {
+		return nil, fmt.Errorf("Certificate '%s' has no 's3.bucket' defined.", cert.Name)
+	}
+	opts := *cert.S3
+
+	if opts.Region == "" {
+		opts.Region = s3DefaultRegion
+	}
+
+	baseURL, err := s3BucketURL(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var creds s3Credentials
+	if creds.AccessKeyID, err = resolve.ResolveVariable(opts.AccessKeyID); err != nil {
+		return nil, fmt.Errorf("Failed to resolve S3 access key ID. %v", err)
+	}
+	if creds.SecretAccessKey, err = resolve.ResolveVariable(opts.SecretAccessKey); err != nil {
+		return nil, fmt.Errorf("Failed to resolve S3 secret access key. %v", err)
+	}
+	if creds.SessionToken, err = resolve.ResolveVariable(opts.SessionToken); err != nil {
+		return nil, fmt.Errorf("Failed to resolve S3 session token. %v", err)
+	}
+
+	client, err := newHTTPClient(opts.Timeout, opts.CAFile, opts.InsecureSkipVerify)
+	if err != nil {
+		return nil, err
+	}
+
+	keys, err := listS3Objects(client, baseURL, opts, creds)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to list objects of bucket '%s'. %v", opts.Bucket, err)
+	}
+
+	var blobs []Blob
+	for _, key := range keys {
+		data, err := getS3Object(client, baseURL, key, opts.Region, creds)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to get object '%s' of bucket '%s'. %v", key, opts.Bucket, err)
+		}
+
+		certType := inferCertificateType(key, data)
+		if certType == "" || !looksLikeCertificateType(certType, data) {
+			log.Debug().Msgf("Skip object '%s' of bucket '%s' as it is not a certificate file", key, opts.Bucket)
+			continue
+		}
+
+		blobs = append(blobs, Blob{
+			Path:   key,
+			Type:   certType,
+			Data:   data,
+			Labels: map[string]string{"bucket": opts.Bucket, "key": key},
+		})
+	}
+
+	log.Debug().Msgf("Found %d certificate files in bucket '%s' for certificate '%s'", len(blobs), opts.Bucket, cert.Name)
+
+	return blobs, nil
+}
+
+// s3BucketURL returns the URL of the bucket.
+//
+// Without an endpoint, the AWS endpoint of the region is used. With path style addressing, the bucket is
+// part of the path ('https://endpoint/bucket'), otherwise of the host ('https://bucket.endpoint').
+//
+// Parameters:
+//   - opts: S3Source
+//     The settings of the s3 source.
+//
+// Returns:
+//   - *url.URL
+//     The URL of the bucket.
+//   - error
+//     An error if the endpoint is invalid.
+func s3BucketURL(opts S3Source) (*url.URL, error) {
+	endpoint := opts.Endpoint
+	if endpoint == "" {
+		endpoint = fmt.Sprintf("https://s3.%s.amazonaws.com", opts.Region)
+	}
+
+	u, err := url.Parse(strings.TrimSuffix(endpoint, "/"))
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("Invalid S3 endpoint '%s'.", endpoint)
+	}
+
+	if opts.PathStyle {
+		u.Path += "/" + opts.Bucket
+	} else {
+		u.Host = opts.Bucket + "." + u.Host
+	}
+
+	return u, nil
+}
+
+// listS3Objects returns the keys of the objects to download.
+//
+// Parameters:
+//   - client: *http.Client
+//     The HTTP client.
+//   - baseURL: *url.URL
+//     The URL of the bucket.
+//   - opts: S3Source
+//     The settings of the s3 source.
+//   - creds: s3Credentials
+//     The credentials to sign the requests.
+//
+// Returns:
+//   - []string
+//     The keys of the objects matching the patterns or with a known certificate file extension.
+//   - error
+//     An error if the objects can't be listed.
+func listS3Objects(client *http.Client, baseURL *url.URL, opts S3Source, creds s3Credentials) ([]string, error) {
+	var keys []string
+	continuationToken := ""
+
+	for {
+		query := url.Values{"list-type": {"2"}}
+		if opts.Prefix != "" {
+			query.Set("prefix", opts.Prefix)
+		}
+		if continuationToken != "" {
+			query.Set("continuation-token", continuationToken)
+		}
+
+		u := *baseURL
+		u.Path += "/"
+		u.RawQuery = s3EncodeQuery(query)
+
+		body, err := doS3Request(client, &u, opts.Region, creds)
+		if err != nil {
+			return nil, err
+		}
+
+		var result s3ListBucketResult
+		if err := xml.Unmarshal(body, &result); err != nil {
+			return nil, fmt.Errorf("failed to decode response. %v", err)
+		}
+
+		for _, object := range result.Contents {
+			if strings.HasSuffix(object.Key, "/") || object.Size > maxURLBodySize {
+				continue
+			}
+			if len(opts.Patterns) > 0 {
+				if matchesAnyPattern(object.Key, opts.Patterns) {
+					keys = append(keys, object.Key)
+				}
+				continue
+			}
+			if _, found := FileExtensionsToType[strings.TrimPrefix(path.Ext(object.Key), ".")]; found {
+				keys = append(keys, object.Key)
+			}
+		}
+
+		if !result.IsTruncated || result.NextContinuationToken == "" {
+			break
+		}
+		continuationToken = result.NextContinuationToken
+	}
+
+	return keys, nil
+}
+
+// getS3Object downloads an object.
+//
+// Parameters:
+//   - client: *http.Client
+//     The HTTP client.
+//   - baseURL: *url.URL
+//     The URL of the bucket.
+//   - key: string
+//     The key of the object.
+//   - region: string
+//     The region used to sign the request.
+//   - creds: s3Credentials
+//     The credentials to sign the request.
+//
+// Returns:
+//   - []byte
+//     The content of the object.
+//   - error
+//     An error if the object can't be downloaded.
+func getS3Object(client *http.Client, baseURL *url.URL, key, region string, creds s3Credentials) ([]byte, error) {
+	u := *baseURL
+	u.Path += "/" + key
+	u.RawPath = s3EncodePath(u.Path)
+
+	return doS3Request(client, &u, region, creds)
+}
+
+// doS3Request sends a signed GET request to S3 and returns the body of the response.
+//
+// Parameters:
+//   - client: *http.Client
+//     The HTTP client.
+//   - u: *url.URL
+//     The URL of the request.
+//   - region: string
+//     The region used to sign the request.
+//   - creds: s3Credentials
+//     The credentials to sign the request. If no access key ID is set, the request is sent anonymously.
+//
+// Returns:
+//   - []byte
+//     The body of the response.
+//   - error
+//     An error if the request fails or S3 responds with an error.
+func doS3Request(client *http.Client, u *url.URL, region string, creds s3Credentials) ([]byte, error) {
+	req, err := http.NewRequest(http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	if creds.AccessKeyID != "" {
+		signS3Request(req, region, creds, time.Now())
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxURLBodySize+1))
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		var s3Err s3Error
+		if xml.Unmarshal(body, &s3Err) == nil && s3Err.Code != "" {
+			return nil, fmt.Errorf("unexpected status code %d. %s: %s", resp.StatusCode, s3Err.Code, s3Err.Message)
+		}
+		return nil, fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+
+	if len(body) > maxURLBodySize {
+		return nil, fmt.Errorf("response exceeds the maximum size of %d bytes", maxURLBodySize)
+	}
+
+	return body, nil
+}
+
+// signS3Request signs a request with AWS Signature Version 4.
+//
+// The host and all headers of the request are signed. The payload is not signed.
+//
+// Parameters:
+//   - req: *http.Request
+//     The request to sign.
+//   - region: string
+//     The region of the bucket.
+//   - creds: s3Credentials
+//     The credentials to sign the request.
+//   - now: time.Time
+//     The time of the request.
+func signS3Request(req *http.Request, region string, creds s3Credentials, now time.Time) {
+	amzDate := now.UTC().Format("20060102T150405Z")
+	date := amzDate[:8]
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", s3EmptyHash)
+	if creds.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", creds.SessionToken)
+	}
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		headers[strings.ToLower(name)] = strings.TrimSpace(strings.Join(values, ","))
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		canonicalHeaders.String(),
+		signedHeaders,
+		s3EmptyHash,
+	}, "\n")
+
+	scope := date + "/" + region + "/s3/aws4_request"
+	hash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(hash[:])
+
+	key := hmacSHA256([]byte("AWS4"+creds.SecretAccessKey), date)
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s", creds.AccessKeyID, scope, signedHeaders, signature))
+}
+
+// hmacSHA256 returns the HMAC-SHA256 of data with the given key.
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+// s3EncodeQuery encodes query parameters sorted by key as required by AWS Signature Version 4.
+func s3EncodeQuery(query url.Values) string {
+	keys := make([]string, 0, len(query))
+	for key := range query {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	parts := make([]string, 0, len(keys))
+	for _, key := range keys {
+		for _, value := range query[key] {
+			parts = append(parts, s3Escape(key, true)+"="+s3Escape(value, true))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+// s3EncodePath encodes every segment of a path as required by AWS Signature Version 4.
+func s3EncodePath(p string) string {
+	return s3Escape(p, false)
+}
+
+// s3Escape percent-encodes all characters except the unreserved characters of RFC 3986.
+// Slashes are only encoded if encodeSlash is true.
+func s3Escape(s string, encodeSlash bool) string {
+	var b strings.Builder
+	for _, c := range []byte(s) {
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9', c == '-', c == '_', c == '.', c == '~':
+			b.WriteByte(c)
+		case c == '/' && !encodeSlash:
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
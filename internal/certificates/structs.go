@@ -1,28 +1,248 @@
 package certificates
 
 import (
+	"crypto/x509"
 	"time"
 )
 
+// DefaultSource is the source used when a certificate does not define one.
+const DefaultSource = "file"
+
 // Certificate represents a certificate configuration.
 type Certificate struct {
-	Name     string `mapstructure:"name"`
-	Enabled  *bool  `mapstructure:"enabled,omitempty" yaml:"enabled,omitempty"`
-	Path     string `mapstructure:"path"`
-	Password string `mapstructure:"password,omitempty" yaml:"password,omitempty"`
-	Type     string `mapstructure:"type" yaml:"type,omitempty"`
+	Name       string            `mapstructure:"name"`
+	Extends    string            `mapstructure:"extends,omitempty" yaml:"extends,omitempty"`
+	Enabled    *bool             `mapstructure:"enabled,omitempty" yaml:"enabled,omitempty"`
+	Source     string            `mapstructure:"source,omitempty" yaml:"source,omitempty"`
+	Path       string            `mapstructure:"path"`
+	Content    string            `mapstructure:"content,omitempty" yaml:"content,omitempty" secret:"true"`
+	Password   string            `mapstructure:"password,omitempty" yaml:"password,omitempty" secret:"true"`
+	Type       string            `mapstructure:"type" yaml:"type,omitempty"`
+	OCI        *OCISource        `mapstructure:"oci,omitempty" yaml:"oci,omitempty"`
+	Kubernetes *KubernetesSource `mapstructure:"kubernetes,omitempty" yaml:"kubernetes,omitempty"`
+	URL        *URLSource        `mapstructure:"url,omitempty" yaml:"url,omitempty"`
+	Vault      *VaultSource      `mapstructure:"vault,omitempty" yaml:"vault,omitempty"`
+	S3         *S3Source         `mapstructure:"s3,omitempty" yaml:"s3,omitempty"`
+	Git        *GitSource        `mapstructure:"git,omitempty" yaml:"git,omitempty"`
+	Filters    *Filters          `mapstructure:"filters,omitempty" yaml:"filters,omitempty"`
+
+	ExpectedDNSNames []string `mapstructure:"expectedDNSNames,omitempty" yaml:"expectedDNSNames,omitempty"`
+	ExpectedIPs      []string `mapstructure:"expectedIPs,omitempty" yaml:"expectedIPs,omitempty"`
+	ExpectedIssuer   string   `mapstructure:"expectedIssuer,omitempty" yaml:"expectedIssuer,omitempty"`
+	Pins             *Pins    `mapstructure:"pins,omitempty" yaml:"pins,omitempty"`
+
+	Labels     map[string]string `mapstructure:"labels,omitempty" yaml:"labels,omitempty"`         // Labels are custom labels attached to the metrics and outputs of the certificate
+	Thresholds Thresholds        `mapstructure:"thresholds,omitempty" yaml:"thresholds,omitempty"` // Thresholds define when the certificate is in the warning or critical status
+}
+
+// Thresholds represents the remaining validity at which a certificate changes to the warning or critical status.
+type Thresholds struct {
+	Warning  string `mapstructure:"warning,omitempty" yaml:"warning,omitempty"`
+	Critical string `mapstructure:"critical,omitempty" yaml:"critical,omitempty"`
+}
+
+// SourceName returns the source of the certificate, falling back to DefaultSource.
+func (c *Certificate) SourceName() string {
+	if c.Source == "" {
+		return DefaultSource
+	}
+	return c.Source
+}
+
+// OCISource represents the config of a certificate read from an OCI image layout or a 'docker save' tarball.
+type OCISource struct {
+	Reference string   `mapstructure:"reference,omitempty" yaml:"reference,omitempty"`
+	Platform  string   `mapstructure:"platform,omitempty" yaml:"platform,omitempty"`
+	Paths     []string `mapstructure:"paths,omitempty" yaml:"paths,omitempty"`
+}
+
+// KubernetesSource represents the config of certificates read from Kubernetes Secrets and ConfigMaps.
+type KubernetesSource struct {
+	Kubeconfig    string   `mapstructure:"kubeconfig,omitempty" yaml:"kubeconfig,omitempty"`
+	Context       string   `mapstructure:"context,omitempty" yaml:"context,omitempty"`
+	Namespaces    []string `mapstructure:"namespaces,omitempty" yaml:"namespaces,omitempty"`
+	LabelSelector string   `mapstructure:"labelSelector,omitempty" yaml:"labelSelector,omitempty"`
+	Resources     []string `mapstructure:"resources,omitempty" yaml:"resources,omitempty"`
+	PasswordKey   string   `mapstructure:"passwordKey,omitempty" yaml:"passwordKey,omitempty" secret:"false"`
+}
+
+// URLSource represents the config of a certificate downloaded from an HTTP(S) URL.
+type URLSource struct {
+	Address            string            `mapstructure:"address" yaml:"address"`
+	Timeout            string            `mapstructure:"timeout,omitempty" yaml:"timeout,omitempty"`
+	CAFile             string            `mapstructure:"caFile,omitempty" yaml:"caFile,omitempty"`
+	InsecureSkipVerify bool              `mapstructure:"insecureSkipVerify,omitempty" yaml:"insecureSkipVerify,omitempty"`
+	Headers            map[string]string `mapstructure:"headers,omitempty" yaml:"headers,omitempty" secret:"true"`
+	Auth               *URLAuth          `mapstructure:"auth,omitempty" yaml:"auth,omitempty"`
+}
+
+// URLAuth represents the auth config of a URL source
+type URLAuth struct {
+	Basic  *URLBasicAuth  `mapstructure:"basic,omitempty" yaml:"basic,omitempty"`
+	Bearer *URLBearerAuth `mapstructure:"bearer,omitempty" yaml:"bearer,omitempty"`
+}
+
+// URLBasicAuth represents the basic auth config of a URL source
+type URLBasicAuth struct {
+	Username string `mapstructure:"username,omitempty" yaml:"username,omitempty" secret:"true"`
+	Password string `mapstructure:"password,omitempty" yaml:"password,omitempty" secret:"true"`
+}
+
+// URLBearerAuth represents the bearer auth config of a URL source
+type URLBearerAuth struct {
+	Token string `mapstructure:"token,omitempty" yaml:"token,omitempty" secret:"true"`
+}
+
+// VaultSource represents the config of certificates read from a HashiCorp Vault PKI or KV secrets engine.
+type VaultSource struct {
+	Address            string    `mapstructure:"address" yaml:"address"`
+	Namespace          string    `mapstructure:"namespace,omitempty" yaml:"namespace,omitempty"`
+	Timeout            string    `mapstructure:"timeout,omitempty" yaml:"timeout,omitempty"`
+	CAFile             string    `mapstructure:"caFile,omitempty" yaml:"caFile,omitempty"`
+	InsecureSkipVerify bool      `mapstructure:"insecureSkipVerify,omitempty" yaml:"insecureSkipVerify,omitempty"`
+	Auth               VaultAuth `mapstructure:"auth" yaml:"auth"`
+	PKI                *VaultPKI `mapstructure:"pki,omitempty" yaml:"pki,omitempty"`
+	KV                 *VaultKV  `mapstructure:"kv,omitempty" yaml:"kv,omitempty"`
+}
+
+// VaultAuth represents the auth config of a Vault source
+type VaultAuth struct {
+	Token   string        `mapstructure:"token,omitempty" yaml:"token,omitempty" secret:"true"`
+	AppRole *VaultAppRole `mapstructure:"appRole,omitempty" yaml:"appRole,omitempty"`
+}
+
+// VaultAppRole represents the AppRole auth config of a Vault source
+type VaultAppRole struct {
+	Mount    string `mapstructure:"mount,omitempty" yaml:"mount,omitempty"`
+	RoleID   string `mapstructure:"roleId" yaml:"roleId"`
+	SecretID string `mapstructure:"secretId,omitempty" yaml:"secretId,omitempty" secret:"true"`
+}
+
+// VaultPKI represents the config of a Vault PKI secrets engine
+type VaultPKI struct {
+	Mount          string `mapstructure:"mount,omitempty" yaml:"mount,omitempty"`
+	IncludeExpired bool   `mapstructure:"includeExpired,omitempty" yaml:"includeExpired,omitempty"`
+}
+
+// VaultKV represents the config of a Vault KV secrets engine
+type VaultKV struct {
+	Mount       string   `mapstructure:"mount,omitempty" yaml:"mount,omitempty"`
+	Version     int      `mapstructure:"version,omitempty" yaml:"version,omitempty"`
+	Paths       []string `mapstructure:"paths" yaml:"paths"`
+	PasswordKey string   `mapstructure:"passwordKey,omitempty" yaml:"passwordKey,omitempty" secret:"false"`
+}
+
+// S3Source represents the config of certificates read from an S3 compatible object storage.
+type S3Source struct {
+	Endpoint           string   `mapstructure:"endpoint,omitempty" yaml:"endpoint,omitempty"`
+	Region             string   `mapstructure:"region,omitempty" yaml:"region,omitempty"`
+	Bucket             string   `mapstructure:"bucket" yaml:"bucket"`
+	Prefix             string   `mapstructure:"prefix,omitempty" yaml:"prefix,omitempty"`
+	Patterns           []string `mapstructure:"patterns,omitempty" yaml:"patterns,omitempty"`
+	PathStyle          bool     `mapstructure:"pathStyle,omitempty" yaml:"pathStyle,omitempty"`
+	AccessKeyID        string   `mapstructure:"accessKeyId,omitempty" yaml:"accessKeyId,omitempty"`
+	SecretAccessKey    string   `mapstructure:"secretAccessKey,omitempty" yaml:"secretAccessKey,omitempty" secret:"true"`
+	SessionToken       string   `mapstructure:"sessionToken,omitempty" yaml:"sessionToken,omitempty" secret:"true"`
+	Timeout            string   `mapstructure:"timeout,omitempty" yaml:"timeout,omitempty"`
+	CAFile             string   `mapstructure:"caFile,omitempty" yaml:"caFile,omitempty"`
+	InsecureSkipVerify bool     `mapstructure:"insecureSkipVerify,omitempty" yaml:"insecureSkipVerify,omitempty"`
+}
+
+// GitSource represents the config of certificates committed to a local git repository.
+type GitSource struct {
+	Ref   string   `mapstructure:"ref,omitempty" yaml:"ref,omitempty"`
+	Tags  bool     `mapstructure:"tags,omitempty" yaml:"tags,omitempty"`
+	Paths []string `mapstructure:"paths,omitempty" yaml:"paths,omitempty"`
+}
+
+// Filters represents the filters applied to the certificates extracted from a certificate file.
+type Filters struct {
+	Include  []Filter `mapstructure:"include,omitempty" yaml:"include,omitempty"`
+	Exclude  []Filter `mapstructure:"exclude,omitempty" yaml:"exclude,omitempty"`
+	OnlyLeaf bool     `mapstructure:"onlyLeaf,omitempty" yaml:"onlyLeaf,omitempty"`
+}
+
+// Filter represents a match on a field of a certificate, either exact or by a regular expression.
+type Filter struct {
+	Field string `mapstructure:"field" yaml:"field"`
+	Value string `mapstructure:"value,omitempty" yaml:"value,omitempty"`
+	Regex string `mapstructure:"regex,omitempty" yaml:"regex,omitempty"`
+}
+
+// Pins represents the pinned fingerprints and public key hashes of a certificate config.
+type Pins struct {
+	Fingerprints []string `mapstructure:"fingerprints,omitempty" yaml:"fingerprints,omitempty"`
+	SPKI         []string `mapstructure:"spki,omitempty" yaml:"spki,omitempty"`
+}
+
+// Blob represents the raw data of a single certificate file read from a source.
+type Blob struct {
+	Path     string            // Path is the location of the data inside the source. It is used to infer the type.
+	Type     string            // Type is the certificate type of the data. If empty, it is inferred.
+	Data     []byte            // Data is the raw certificate data.
+	Password string            // Password overrides the password of the certificate if set.
+	Labels   map[string]string // Labels are attached to every certificate extracted from the data.
+	Error    string            // Error is set if the data could not be read. It is reported instead of extracting the data.
+
+	// Discovered is set if the data was found by its file extension or content instead of a configured path.
+	// Certificates which can't be extracted from discovered data are skipped instead of reported as errors.
+	Discovered bool
 }
 
 // CertificateInfo represents the extracted certificate information.
 type CertificateInfo struct {
-	Name    string `mapstructure:"name"`
-	Subject string `mapstructure:"subject"`
-	Epoch   int64  `mapstructure:"epoch"`
-	Type    string `mapstructure:"type,omitempty"`
-	Error   string `mapstructure:"error"`
+	Name      string            `mapstructure:"name"`
+	Subject   string            `mapstructure:"subject"`
+	Epoch     int64             `mapstructure:"epoch"`
+	NotBefore int64             `mapstructure:"notBefore,omitempty" yaml:"notBefore,omitempty"`
+	Validity  string            `mapstructure:"validity,omitempty" yaml:"validity,omitempty"`
+	Type      string            `mapstructure:"type,omitempty"`
+	Role      string            `mapstructure:"role,omitempty"`
+	Error     string            `mapstructure:"error"`
+	Labels    map[string]string `mapstructure:"labels,omitempty" yaml:"labels,omitempty"`
+
+	Expectation string    `mapstructure:"expectation,omitempty" yaml:"expectation,omitempty"` // Expectation is the result of the expected names and issuer check, if any
+	Mismatches  []string  `mapstructure:"mismatches,omitempty" yaml:"mismatches,omitempty"`   // Mismatches lists the expected names and issuer the certificate doesn't match
+	Pinning     string    `mapstructure:"pinning,omitempty" yaml:"pinning,omitempty"`         // Pinning is the result of the pin check, if any
+	Findings    []Finding `mapstructure:"findings,omitempty" yaml:"findings,omitempty"`       // Findings lists the violated policy rules, set by Lint
+
+	Status                 string  `mapstructure:"status,omitempty" yaml:"status,omitempty"`                                       // Status is the status of the certificate derived from its thresholds
+	WarningThreshold       int64   `mapstructure:"warningThreshold,omitempty" yaml:"warningThreshold,omitempty" print:"seconds"`   // WarningThreshold is the warning threshold in seconds
+	CriticalThreshold      int64   `mapstructure:"criticalThreshold,omitempty" yaml:"criticalThreshold,omitempty" print:"seconds"` // CriticalThreshold is the critical threshold in seconds
+	LifetimeRemainingRatio float64 `mapstructure:"lifetimeRemainingRatio,omitempty" yaml:"lifetimeRemainingRatio,omitempty"`       // LifetimeRemainingRatio is the remaining ratio of the lifetime of the certificate
+
+	CustomLabels map[string]string `mapstructure:"customLabels,omitempty" yaml:"customLabels,omitempty" print:"columns"` // CustomLabels are the custom labels of the certificate config
+
+	certificate           *x509.Certificate // certificate is the parsed certificate, nil if the extraction failed
+	alias                 string            // alias is the alias of the entry in a keystore
+	unencryptedPrivateKey bool              // unencryptedPrivateKey is set if the certificate file contains an unencrypted private key
 }
 
 // ExpiryAsTime returns the expiry date as a time.Time.
 func (ci *CertificateInfo) ExpiryAsTime() time.Time {
 	return time.Unix(ci.Epoch, 0)
 }
+
+// LabelsString returns the labels of the source of the certificate sorted by key as comma separated 'key=value' pairs.
+func (ci *CertificateInfo) LabelsString() string {
+	return FormatLabels(ci.Labels)
+}
+
+// DeduplicatedCertificateInfo represents a certificate found at one or more locations.
+// Name and Type are taken from the first location the certificate was found at.
+type DeduplicatedCertificateInfo struct {
+	Fingerprint string                `mapstructure:"fingerprint"`
+	Name        string                `mapstructure:"name"`
+	Subject     string                `mapstructure:"subject"`
+	Epoch       int64                 `mapstructure:"epoch"`
+	Type        string                `mapstructure:"type,omitempty"`
+	Role        string                `mapstructure:"role,omitempty"`
+	Locations   []CertificateLocation `mapstructure:"locations"`
+}
+
+// CertificateLocation represents a certificate config and the labels of the entry a certificate was extracted from.
+type CertificateLocation struct {
+	Name   string            `mapstructure:"name"`
+	Type   string            `mapstructure:"type,omitempty"`
+	Labels map[string]string `mapstructure:"labels,omitempty" yaml:"labels,omitempty"`
+}
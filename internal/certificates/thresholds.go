@@ -0,0 +1,251 @@
+package certificates
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Statuses of a certificate, derived from its remaining validity and its thresholds.
+const (
+	StatusOK       = "ok"       // StatusOK is the status of a certificate expiring after its warning threshold.
+	StatusWarning  = "warning"  // StatusWarning is the status of a certificate within its warning threshold.
+	StatusCritical = "critical" // StatusCritical is the status of a certificate within its critical threshold.
+	StatusExpired  = "expired"  // StatusExpired is the status of an expired certificate.
+)
+
+// Statuses contains all statuses of a certificate, ordered by urgency. The index is the value of the status metric.
+var Statuses = []string{StatusOK, StatusWarning, StatusCritical, StatusExpired}
+
+// Default thresholds, used if neither the certificate nor the config defines a threshold.
+const (
+	DefaultWarningThreshold  = "30d"
+	DefaultCriticalThreshold = "3d"
+)
+
+// Threshold represents a parsed threshold, either an absolute duration or a ratio of the lifetime of a certificate.
+type Threshold struct {
+	Duration time.Duration // Duration is the remaining validity of an absolute threshold like '30d'
+	Ratio    float64       // Ratio is the remaining ratio of the lifetime of a relative threshold like '33%'
+	Relative bool          // Relative is set if the threshold is relative to the lifetime of the certificate
+}
+
+// For returns the remaining validity of the threshold for a certificate with the given lifetime.
+//
+// Parameters:
+//   - lifetime: time.Duration
+//     The time between the NotBefore and NotAfter date of the certificate.
+//
+// Returns:
+//   - time.Duration
+//     The remaining validity at which the threshold is reached.
+func (t Threshold) For(lifetime time.Duration) time.Duration {
+	if t.Relative {
+		return time.Duration(float64(lifetime) * t.Ratio)
+	}
+	return t.Duration
+}
+
+// ParseThreshold parses a threshold like '30d', '12h', '1d12h' or '33%'. In addition to the units of
+// time.ParseDuration, the unit 'd' for days is supported as the leading unit. A percentage is the
+// remaining ratio of the lifetime of a certificate, which suits short-lived certificates.
+//
+// Parameters:
+//   - threshold: string
+//     The threshold to parse.
+//
+// Returns:
+//   - Threshold
+//     The parsed threshold.
+//   - error
+//     An error if the threshold is invalid or negative.
+func ParseThreshold(threshold string) (Threshold, error) {
+	if percentage, found := strings.CutSuffix(threshold, "%"); found {
+		p, err := strconv.ParseFloat(percentage, 64)
+		if err != nil || math.IsNaN(p) || p < 0 || p > 100 {
+			return Threshold{}, fmt.Errorf("Invalid threshold '%s'. Must be a percentage between 0%% and 100%%.", threshold)
+		}
+		return Threshold{Ratio: p / 100, Relative: true}, nil
+	}
+
+	var days time.Duration
+	rest := threshold
+	if before, after, found := strings.Cut(threshold, "d"); found {
+		n, err := strconv.Atoi(before)
+		if err != nil {
+			return Threshold{}, fmt.Errorf("Invalid threshold '%s'. Must be a duration like '30d' or '12h' or a percentage like '33%%'.", threshold)
+		}
+		days = time.Duration(n) * 24 * time.Hour
+		rest = after
+	}
+
+	var d time.Duration
+	if rest != "" {
+		var err error
+		if d, err = time.ParseDuration(rest); err != nil {
+			return Threshold{}, fmt.Errorf("Invalid threshold '%s'. Must be a duration like '30d' or '12h' or a percentage like '33%%'.", threshold)
+		}
+	}
+
+	if days+d < 0 {
+		return Threshold{}, fmt.Errorf("Invalid threshold '%s'. Must not be negative.", threshold)
+	}
+
+	return Threshold{Duration: days + d}, nil
+}
+
+// Validate checks if the thresholds can be parsed and the critical threshold is shorter than the warning threshold.
+// An absolute and a relative threshold can't be compared, as the lifetime of the certificates is unknown.
+//
+// Returns:
+//   - error
+//     An error if a threshold is invalid.
+func (t Thresholds) Validate() error {
+	warning, critical, err := t.parse()
+	if err != nil {
+		return err
+	}
+
+	if warning.Relative == critical.Relative && (critical.Duration > warning.Duration || critical.Ratio > warning.Ratio) {
+		return fmt.Errorf("The critical threshold '%s' must not be longer than the warning threshold '%s'.", t.orDefault(t.Critical, DefaultCriticalThreshold), t.orDefault(t.Warning, DefaultWarningThreshold))
+	}
+
+	return nil
+}
+
+// Merge fills the thresholds which are not set with the given thresholds, e.g. the global thresholds of the config.
+//
+// Parameters:
+//   - fallback: Thresholds
+//     The thresholds used for unset thresholds.
+//
+// Returns:
+//   - Thresholds
+//     The merged thresholds.
+func (t Thresholds) Merge(fallback Thresholds) Thresholds {
+	if t.Warning == "" {
+		t.Warning = fallback.Warning
+	}
+	if t.Critical == "" {
+		t.Critical = fallback.Critical
+	}
+	return t
+}
+
+// parse parses the thresholds, applying the default thresholds for unset thresholds.
+func (t Thresholds) parse() (warning, critical Threshold, err error) {
+	if warning, err = ParseThreshold(t.orDefault(t.Warning, DefaultWarningThreshold)); err != nil {
+		return Threshold{}, Threshold{}, fmt.Errorf("Invalid warning threshold. %v", err)
+	}
+	if critical, err = ParseThreshold(t.orDefault(t.Critical, DefaultCriticalThreshold)); err != nil {
+		return Threshold{}, Threshold{}, fmt.Errorf("Invalid critical threshold. %v", err)
+	}
+	return warning, critical, nil
+}
+
+// orDefault returns the threshold or the default threshold if it is not set.
+func (t Thresholds) orDefault(threshold, defaultThreshold string) string {
+	if threshold == "" {
+		return defaultThreshold
+	}
+	return threshold
+}
+
+// evaluateThresholds sets the thresholds, the remaining ratio of the lifetime and the status of every
+// extracted certificate. Relative thresholds are resolved with the lifetime of each certificate.
+//
+// Parameters:
+//   - cert: Certificate
+//     The certificate config with the thresholds.
+//   - certInfoList: []CertificateInfo
+//     The extracted certificates. Entries describing a failed extraction are left untouched.
+func evaluateThresholds(cert Certificate, certInfoList []CertificateInfo) {
+	// The thresholds are validated while parsing the config, fall back to the defaults just in case
+	warningThreshold, criticalThreshold, err := cert.Thresholds.parse()
+	if err != nil {
+		warningThreshold, criticalThreshold, _ = Thresholds{}.parse()
+	}
+
+	current := now()
+	for i := range certInfoList {
+		x509Cert := certInfoList[i].certificate
+		if x509Cert == nil {
+			continue
+		}
+
+		lifetime := x509Cert.NotAfter.Sub(x509Cert.NotBefore)
+		remaining := x509Cert.NotAfter.Sub(current)
+		warning := warningThreshold.For(lifetime)
+		critical := criticalThreshold.For(lifetime)
+
+		certInfoList[i].WarningThreshold = int64(warning.Seconds())
+		certInfoList[i].CriticalThreshold = int64(critical.Seconds())
+		certInfoList[i].LifetimeRemainingRatio = lifetimeRemainingRatio(remaining, lifetime)
+		certInfoList[i].Status = status(remaining, warning, critical)
+	}
+}
+
+// lifetimeRemainingRatio returns the remaining ratio of the lifetime of a certificate, rounded to 4 decimals.
+//
+// Parameters:
+//   - remaining: time.Duration
+//     The time until the certificate expires.
+//   - lifetime: time.Duration
+//     The time between the NotBefore and NotAfter date of the certificate.
+//
+// Returns:
+//   - float64
+//     The ratio between 0 for expired and 1 for not yet valid certificates.
+func lifetimeRemainingRatio(remaining, lifetime time.Duration) float64 {
+	if lifetime <= 0 || remaining <= 0 {
+		return 0
+	}
+	ratio := math.Min(float64(remaining)/float64(lifetime), 1)
+	return math.Round(ratio*10000) / 10000
+}
+
+// status returns the status of a certificate with the given remaining validity.
+//
+// Parameters:
+//   - remaining: time.Duration
+//     The time until the certificate expires.
+//   - warning: time.Duration
+//     The warning threshold.
+//   - critical: time.Duration
+//     The critical threshold.
+//
+// Returns:
+//   - string
+//     One of Statuses.
+func status(remaining, warning, critical time.Duration) string {
+	switch {
+	case remaining <= 0:
+		return StatusExpired
+	case remaining <= critical:
+		return StatusCritical
+	case remaining <= warning:
+		return StatusWarning
+	default:
+		return StatusOK
+	}
+}
+
+// StatusValue returns the value of a status for the status metric, i.e. its index in Statuses.
+//
+// Parameters:
+//   - status: string
+//     The status of a certificate.
+//
+// Returns:
+//   - int
+//     The value of the status, -1 if the status is unknown.
+func StatusValue(status string) int {
+	for i, s := range Statuses {
+		if s == status {
+			return i
+		}
+	}
+	return -1
+}
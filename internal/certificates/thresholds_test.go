@@ -0,0 +1,120 @@
+package certificates
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseThreshold(t *testing.T) {
+	testCases := []struct {
+		Threshold     string
+		Expected      Threshold
+		ExpectedError string
+	}{
+		{Threshold: "30d", Expected: Threshold{Duration: 30 * 24 * time.Hour}},
+		{Threshold: "12h", Expected: Threshold{Duration: 12 * time.Hour}},
+		{Threshold: "1d12h", Expected: Threshold{Duration: 36 * time.Hour}},
+		{Threshold: "90m", Expected: Threshold{Duration: 90 * time.Minute}},
+		{Threshold: "0", Expected: Threshold{}},
+		{Threshold: "33%", Expected: Threshold{Ratio: 0.33, Relative: true}},
+		{Threshold: "12.5%", Expected: Threshold{Ratio: 0.125, Relative: true}},
+		{Threshold: "30 days", ExpectedError: "Invalid threshold '30 days'. Must be a duration like '30d' or '12h' or a percentage like '33%'."},
+		{Threshold: "1d2", ExpectedError: "Invalid threshold '1d2'. Must be a duration like '30d' or '12h' or a percentage like '33%'."},
+		{Threshold: "-1d", ExpectedError: "Invalid threshold '-1d'. Must not be negative."},
+		{Threshold: "120%", ExpectedError: "Invalid threshold '120%'. Must be a percentage between 0% and 100%."},
+		{Threshold: "NaN%", ExpectedError: "Invalid threshold 'NaN%'. Must be a percentage between 0% and 100%."},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Threshold, func(t *testing.T) {
+			threshold, err := ParseThreshold(tc.Threshold)
+			if tc.ExpectedError != "" {
+				assert.EqualError(t, err, tc.ExpectedError)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.Expected, threshold)
+		})
+	}
+
+	t.Run("for lifetime", func(t *testing.T) {
+		assert.Equal(t, 8*time.Hour, Threshold{Ratio: 0.25, Relative: true}.For(32*time.Hour))
+		assert.Equal(t, 30*time.Minute, Threshold{Duration: 30 * time.Minute}.For(32*time.Hour))
+	})
+}
+
+func TestThresholdsValidate(t *testing.T) {
+	assert.NoError(t, Thresholds{}.Validate())
+	assert.NoError(t, Thresholds{Warning: "14d", Critical: "7d"}.Validate())
+	assert.EqualError(t, Thresholds{Warning: "2d"}.Validate(), "The critical threshold '3d' must not be longer than the warning threshold '2d'.")
+	assert.EqualError(t, Thresholds{Critical: "soon"}.Validate(), "Invalid critical threshold. Invalid threshold 'soon'. Must be a duration like '30d' or '12h' or a percentage like '33%'.")
+	assert.NoError(t, Thresholds{Warning: "33%", Critical: "10%"}.Validate())
+	assert.NoError(t, Thresholds{Warning: "33%", Critical: "7d"}.Validate(), "absolute and relative thresholds can't be compared")
+	assert.EqualError(t, Thresholds{Warning: "10%", Critical: "33%"}.Validate(), "The critical threshold '33%' must not be longer than the warning threshold '10%'.")
+}
+
+func TestThresholdsMerge(t *testing.T) {
+	merged := Thresholds{Critical: "7d"}.Merge(Thresholds{Warning: "60d", Critical: "14d"})
+	assert.Equal(t, Thresholds{Warning: "60d", Critical: "7d"}, merged)
+}
+
+func TestStatus(t *testing.T) {
+	warning, critical := 30*24*time.Hour, 7*24*time.Hour
+
+	assert.Equal(t, StatusExpired, status(0, warning, critical))
+	assert.Equal(t, StatusCritical, status(critical, warning, critical))
+	assert.Equal(t, StatusWarning, status(critical+time.Second, warning, critical))
+	assert.Equal(t, StatusWarning, status(warning, warning, critical))
+	assert.Equal(t, StatusOK, status(warning+time.Second, warning, critical))
+
+	assert.Equal(t, 0.25, lifetimeRemainingRatio(6*time.Hour, 24*time.Hour))
+	assert.Equal(t, 0.3333, lifetimeRemainingRatio(8*time.Hour, 24*time.Hour))
+	assert.Equal(t, 0.0, lifetimeRemainingRatio(-time.Hour, 24*time.Hour))
+	assert.Equal(t, 1.0, lifetimeRemainingRatio(48*time.Hour, 24*time.Hour), "not yet valid certificates have their whole lifetime left")
+
+	assert.Equal(t, 3, StatusValue(StatusExpired))
+	assert.Equal(t, -1, StatusValue("unknown"))
+}
+
+func TestProcessStatus(t *testing.T) {
+	oldNow := now
+	defer func() { now = oldNow }()
+
+	certs, err := Process([]Certificate{{Name: "final", Path: "../../tests/certs/pem/final.crt", Type: "pem"}}, true)
+	assert.NoError(t, err)
+	notAfter := certs[0].certificate.NotAfter
+	lifetime := notAfter.Sub(certs[0].certificate.NotBefore)
+
+	testCases := []struct {
+		Name       string
+		Now        time.Time
+		Thresholds Thresholds
+		Expected   string
+	}{
+		{Name: "default thresholds", Now: notAfter.Add(-10 * 24 * time.Hour), Expected: StatusWarning},
+		{Name: "custom critical threshold", Now: notAfter.Add(-10 * 24 * time.Hour), Thresholds: Thresholds{Warning: "30d", Critical: "14d"}, Expected: StatusCritical},
+		{Name: "ok", Now: notAfter.Add(-60 * 24 * time.Hour), Expected: StatusOK},
+		{Name: "expired", Now: notAfter.Add(time.Second), Expected: StatusExpired},
+		{Name: "relative warning threshold", Now: notAfter.Add(-lifetime / 4), Thresholds: Thresholds{Warning: "33%", Critical: "10%"}, Expected: StatusWarning},
+		{Name: "relative critical threshold", Now: notAfter.Add(-lifetime / 20), Thresholds: Thresholds{Warning: "33%", Critical: "10%"}, Expected: StatusCritical},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			now = func() time.Time { return tc.Now }
+
+			result, err := Process([]Certificate{{Name: "final", Path: "../../tests/certs/pem/final.crt", Type: "pem", Thresholds: tc.Thresholds}}, true)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.Expected, result[0].Status)
+			assert.NotZero(t, result[0].WarningThreshold)
+		})
+	}
+
+	t.Run("failed extraction", func(t *testing.T) {
+		result, err := Process([]Certificate{{Name: "missing", Path: "../../tests/certs/pem/missing.crt", Type: "pem"}}, false)
+		assert.NoError(t, err)
+		assert.Empty(t, result[0].Status)
+	})
+}
@@ -45,10 +45,11 @@ func ExtractTrustStoreCertificatesInfo(cert Certificate, certificateData []byte,
 		subject := generateCertificateSubject(certificate.Subject.ToRDNSequence().String(), len(certificateInfoList)+1)
 
 		certificateInfo := CertificateInfo{
-			Name:    cert.Name,
-			Subject: subject,
-			Epoch:   certificate.NotAfter.Unix(),
-			Type:    "truststore",
+			Name:        cert.Name,
+			Subject:     subject,
+			Epoch:       certificate.NotAfter.Unix(),
+			Type:        "truststore",
+			certificate: certificate,
 		}
 		certificateInfoList = append(certificateInfoList, certificateInfo)
 
@@ -0,0 +1,364 @@
+package certificates
+
+import (
+	"certalert/internal/resolve"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+func init() {
+	registerSource("url", FetchURLCertificate, false)
+}
+
+const (
+	urlDefaultTimeout = 10 * time.Second // Timeout of a request if no timeout is configured
+	maxURLBodySize    = 10 << 20         // Maximum size of a downloaded certificate file
+)
+
+// urlCacheEntry represents a downloaded certificate file with its validators for conditional requests.
+type urlCacheEntry struct {
+	RequestHash  string // RequestHash is the urlRequestHash of the request the file was downloaded with
+	ETag         string
+	LastModified string
+	Data         []byte
+}
+
+// urlCacheKey identifies a configured URL source in the urlCache.
+type urlCacheKey struct {
+	name    string
+	address string
+}
+
+// urlCache holds the last downloaded content of every URL source, so unchanged files are not downloaded
+// again. The content is only used for a request with the same urlRequestHash, so requests for the same URL
+// with other headers or auth don't share content. Entries of sources removed from the config are dropped by
+// PruneCaches.
+var (
+	urlCacheMutex sync.Mutex
+	urlCache      = map[urlCacheKey]urlCacheEntry{}
+)
+
+// httpClientKey identifies the settings of an HTTP client.
+type httpClientKey struct {
+	timeout            string
+	caFile             string
+	insecureSkipVerify bool
+}
+
+// httpClient represents a cached HTTP client with the modification time of its CA file.
+type httpClient struct {
+	client        *http.Client
+	caFileModTime time.Time
+}
+
+// httpClients holds the HTTP clients by their settings, so the connections of a source are reused across
+// scrapes instead of opening new connections with a new transport on every fetch. Clients of settings no
+// source uses anymore are dropped by PruneCaches.
+var (
+	httpClientsMutex sync.Mutex
+	httpClients      = map[httpClientKey]httpClient{}
+)
+
+// FetchURLCertificate downloads a certificate file from an HTTP(S) URL.
+//
+// The request is sent with the configured headers and auth. Header values, username, password and
+// token are resolved with resolve.ResolveVariable, so they can be read from environment variables or files.
+// If the server returned an 'ETag' or 'Last-Modified' header on a previous download, the request
+// is sent with 'If-None-Match' and 'If-Modified-Since' and the cached content is used if the server
+// responds with '304 Not Modified'.
+//
+// Parameters:
+//   - cert: Certificate
+//     A Certificate struct with the 'url' settings.
+//
+// Returns:
+//   - []Blob
+//     A slice containing the downloaded certificate file.
+//   - error
+//     An error if the file can't be downloaded.
+func FetchURLCertificate(cert Certificate) ([]Blob, error) {
+	if cert.URL == nil || cert.URL.Address == "" {
+		return nil, fmt.Errorf("Certificate '%s' has no 'url.address' defined.", cert.Name)
+	}
+	opts := *cert.URL
+
+	u, err := url.Parse(opts.Address)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("Invalid URL '%s'.", opts.Address)
+	}
+
+	client, err := newHTTPClient(opts.Timeout, opts.CAFile, opts.InsecureSkipVerify)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest(http.MethodGet, opts.Address, nil)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to create request for '%s'. %v", opts.Address, err)
+	}
+	for key, value := range opts.Headers {
+		resolved, err := resolve.ResolveVariable(value)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to resolve header '%s'. %v", key, err)
+		}
+		req.Header.Set(key, resolved)
+	}
+	if err := setURLAuth(req, opts.Auth); err != nil {
+		return nil, err
+	}
+
+	cacheKey := urlCacheKey{name: cert.Name, address: opts.Address}
+	requestHash := urlRequestHash(req)
+	urlCacheMutex.Lock()
+	cached, found := urlCache[cacheKey]
+	urlCacheMutex.Unlock()
+	isCached := found && cached.RequestHash == requestHash
+	if isCached {
+		if cached.ETag != "" {
+			req.Header.Set("If-None-Match", cached.ETag)
+		}
+		if cached.LastModified != "" {
+			req.Header.Set("If-Modified-Since", cached.LastModified)
+		}
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to fetch '%s'. %v", opts.Address, err)
+	}
+	defer resp.Body.Close()
+
+	var data []byte
+	switch {
+	case resp.StatusCode == http.StatusNotModified && isCached:
+		log.Debug().Msgf("Certificate '%s' at '%s' has not been modified", cert.Name, opts.Address)
+		data = cached.Data
+	case resp.StatusCode == http.StatusOK:
+		data, err = io.ReadAll(io.LimitReader(resp.Body, maxURLBodySize+1))
+		if err != nil {
+			return nil, fmt.Errorf("Failed to read response of '%s'. %v", opts.Address, err)
+		}
+		if len(data) > maxURLBodySize {
+			return nil, fmt.Errorf("Response of '%s' exceeds the maximum size of %d bytes.", opts.Address, maxURLBodySize)
+		}
+
+		entry := urlCacheEntry{RequestHash: requestHash, ETag: resp.Header.Get("ETag"), LastModified: resp.Header.Get("Last-Modified"), Data: data}
+		urlCacheMutex.Lock()
+		if entry.ETag != "" || entry.LastModified != "" {
+			urlCache[cacheKey] = entry
+		} else {
+			delete(urlCache, cacheKey)
+		}
+		urlCacheMutex.Unlock()
+	default:
+		return nil, fmt.Errorf("Failed to fetch '%s'. Unexpected status code %d.", opts.Address, resp.StatusCode)
+	}
+
+	return []Blob{{
+		Path:   u.Path,
+		Data:   data,
+		Labels: map[string]string{"url": opts.Address},
+	}}, nil
+}
+
+// urlRequestHash returns the hash of a request stored with its content in the urlCache. It is the SHA-256
+// hash of the URL and the headers of the request, including the auth, so the credentials are not kept as
+// plain text.
+//
+// Parameters:
+//   - req: *http.Request
+//     The request without the conditional headers.
+//
+// Returns:
+//   - string
+//     The hash of the request.
+func urlRequestHash(req *http.Request) string {
+	h := sha256.New()
+	io.WriteString(h, req.URL.String())
+	for _, key := range slices.Sorted(maps.Keys(req.Header)) {
+		fmt.Fprintf(h, "\n%s: %s", key, strings.Join(req.Header[key], ", "))
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// newHTTPClient returns the HTTP client for sources reading from an HTTP(S) server.
+//
+// Clients are created once per timeout, CA file and TLS verification setting and reused by every
+// source with the same settings, so connections are kept alive across scrapes. A changed CA file
+// results in a new client, which replaces the client of the old CA file and closes its idle connections.
+//
+// Parameters:
+//   - timeout: string
+//     The timeout of a request, e.g. '30s'. If empty, urlDefaultTimeout is used.
+//   - caFile: string
+//     The path to a PEM file with the CA certificates used to verify the server.
+//   - insecureSkipVerify: bool
+//     Skip the TLS verification of the server.
+//
+// Returns:
+//   - *http.Client
+//     The HTTP client with the configured timeout and TLS settings.
+//   - error
+//     An error if the timeout is invalid or the CA file can't be read.
+func newHTTPClient(timeout, caFile string, insecureSkipVerify bool) (*http.Client, error) {
+	key := httpClientKey{timeout: timeout, caFile: caFile, insecureSkipVerify: insecureSkipVerify}
+	var caFileModTime time.Time
+	if caFile != "" {
+		if info, err := os.Stat(caFile); err == nil {
+			caFileModTime = info.ModTime()
+		}
+	}
+
+	httpClientsMutex.Lock()
+	defer httpClientsMutex.Unlock()
+	cached, found := httpClients[key]
+	if found && cached.caFileModTime.Equal(caFileModTime) {
+		return cached.client, nil
+	}
+
+	client, err := buildHTTPClient(timeout, caFile, insecureSkipVerify)
+	if err != nil {
+		return nil, err
+	}
+	if found {
+		cached.client.CloseIdleConnections()
+	}
+	httpClients[key] = httpClient{client: client, caFileModTime: caFileModTime}
+	return client, nil
+}
+
+// pruneHTTPClients drops the HTTP clients whose settings are not used by any of the URL or Vault sources
+// and closes their idle connections.
+//
+// Parameters:
+//   - certs: []Certificate
+//     The certificates of the current config.
+func pruneHTTPClients(certs []Certificate) {
+	used := map[httpClientKey]bool{}
+	for _, cert := range certs {
+		if cert.URL != nil {
+			used[httpClientKey{timeout: cert.URL.Timeout, caFile: cert.URL.CAFile, insecureSkipVerify: cert.URL.InsecureSkipVerify}] = true
+		}
+		if cert.Vault != nil {
+			used[httpClientKey{timeout: cert.Vault.Timeout, caFile: cert.Vault.CAFile, insecureSkipVerify: cert.Vault.InsecureSkipVerify}] = true
+		}
+	}
+
+	httpClientsMutex.Lock()
+	defer httpClientsMutex.Unlock()
+	for key, cached := range httpClients {
+		if !used[key] {
+			cached.client.CloseIdleConnections()
+			delete(httpClients, key)
+		}
+	}
+}
+
+// pruneURLCache drops the downloaded content of URL sources which are not in the certificates anymore.
+//
+// Parameters:
+//   - certs: []Certificate
+//     The certificates of the current config.
+func pruneURLCache(certs []Certificate) {
+	used := map[urlCacheKey]bool{}
+	for _, cert := range certs {
+		if cert.URL != nil {
+			used[urlCacheKey{name: cert.Name, address: cert.URL.Address}] = true
+		}
+	}
+
+	urlCacheMutex.Lock()
+	defer urlCacheMutex.Unlock()
+	for key := range urlCache {
+		if !used[key] {
+			delete(urlCache, key)
+		}
+	}
+}
+
+// buildHTTPClient creates an HTTP client with its own transport. See newHTTPClient.
+func buildHTTPClient(timeout, caFile string, insecureSkipVerify bool) (*http.Client, error) {
+	requestTimeout := urlDefaultTimeout
+	if timeout != "" {
+		var err error
+		requestTimeout, err = time.ParseDuration(timeout)
+		if err != nil || requestTimeout <= 0 {
+			return nil, fmt.Errorf("Invalid timeout '%s'.", timeout)
+		}
+	}
+
+	tlsConfig := &tls.Config{InsecureSkipVerify: insecureSkipVerify}
+	if caFile != "" {
+		caCert, err := os.ReadFile(caFile)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to read CA file '%s'. %v", caFile, err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caCert) {
+			return nil, fmt.Errorf("CA file '%s' contains no PEM certificates.", caFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+
+	return &http.Client{Timeout: requestTimeout, Transport: transport}, nil
+}
+
+// setURLAuth sets the basic or bearer auth of a URL source on a request.
+//
+// Parameters:
+//   - req: *http.Request
+//     The request to authenticate.
+//   - auth: *URLAuth
+//     The auth settings. If nil, the request is sent without auth.
+//
+// Returns:
+//   - error
+//     An error if both auth methods are defined or a credential can't be resolved.
+func setURLAuth(req *http.Request, auth *URLAuth) error {
+	if auth == nil {
+		return nil
+	}
+
+	if auth.Basic != nil && auth.Bearer != nil {
+		return fmt.Errorf("Both 'auth.basic' and 'auth.bearer' are defined.")
+	}
+
+	if auth.Basic != nil {
+		username, err := resolve.ResolveVariable(auth.Basic.Username)
+		if err != nil {
+			return fmt.Errorf("Failed to resolve basic auth username. %v", err)
+		}
+		password, err := resolve.ResolveVariable(auth.Basic.Password)
+		if err != nil {
+			return fmt.Errorf("Failed to resolve basic auth password. %v", err)
+		}
+		req.SetBasicAuth(username, password)
+	}
+
+	if auth.Bearer != nil {
+		token, err := resolve.ResolveVariable(auth.Bearer.Token)
+		if err != nil {
+			return fmt.Errorf("Failed to resolve bearer token. %v", err)
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	return nil
+}
@@ -0,0 +1,207 @@
+package certificates
+
+import (
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFetchURLCertificate(t *testing.T) {
+	chain := readTestFile(t, "../../tests/certs/pem/chain.crt")
+	jks := readTestFile(t, "../../tests/certs/jks/regular.jks")
+
+	var downloads int
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ca.pem", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		downloads++
+		w.Header().Set("ETag", `"v1"`)
+		w.Write(chain)
+	})
+	mux.HandleFunc("/protected/keystore", func(w http.ResponseWriter, r *http.Request) {
+		if username, password, ok := r.BasicAuth(); !ok || username != "user" || password != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write(jks)
+	})
+	mux.HandleFunc("/bearer.crt", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" || r.Header.Get("X-Tenant") != "shop" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		w.Write(chain)
+	})
+
+	server := httptest.NewServer(mux)
+	defer server.Close()
+	tlsServer := httptest.NewTLSServer(mux)
+	defer tlsServer.Close()
+
+	t.Run("caches responses with ETag", func(t *testing.T) {
+		cert := Certificate{Name: "ca", URL: &URLSource{Address: server.URL + "/ca.pem"}}
+		for range 2 {
+			blobs, err := FetchURLCertificate(cert)
+			assert.NoError(t, err)
+			assert.Len(t, blobs, 1)
+			assert.Equal(t, chain, blobs[0].Data)
+			assert.Equal(t, "/ca.pem", blobs[0].Path)
+			assert.Equal(t, map[string]string{"url": server.URL + "/ca.pem"}, blobs[0].Labels)
+		}
+		assert.Equal(t, 1, downloads)
+	})
+
+	t.Run("does not share cached content between headers", func(t *testing.T) {
+		mux.HandleFunc("/tenant.pem", func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("If-None-Match") == `"v1"` {
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+			w.Header().Set("ETag", `"v1"`)
+			if r.Header.Get("X-Tenant") == "shop" {
+				w.Write(chain)
+				return
+			}
+			w.Write(jks)
+		})
+
+		shop, err := FetchURLCertificate(Certificate{Name: "shop", URL: &URLSource{Address: server.URL + "/tenant.pem", Headers: map[string]string{"X-Tenant": "shop"}}})
+		assert.NoError(t, err)
+		blog, err := FetchURLCertificate(Certificate{Name: "blog", URL: &URLSource{Address: server.URL + "/tenant.pem", Headers: map[string]string{"X-Tenant": "blog"}}})
+		assert.NoError(t, err)
+		assert.Equal(t, chain, shop[0].Data)
+		assert.Equal(t, jks, blog[0].Data)
+	})
+
+	t.Run("reuses the HTTP client", func(t *testing.T) {
+		first, err := newHTTPClient("5s", "", false)
+		assert.NoError(t, err)
+		second, err := newHTTPClient("5s", "", false)
+		assert.NoError(t, err)
+		other, err := newHTTPClient("5s", "", true)
+		assert.NoError(t, err)
+		assert.Same(t, first, second)
+		assert.NotSame(t, first, other)
+	})
+
+	t.Run("replaces the HTTP client of a changed CA file", func(t *testing.T) {
+		caFile := filepath.Join(t.TempDir(), "ca.pem")
+		caPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: tlsServer.Certificate().Raw})
+		if err := os.WriteFile(caFile, caPEM, 0o644); err != nil {
+			t.Fatalf("Failed to write CA file: %v", err)
+		}
+		first, err := newHTTPClient("5s", caFile, false)
+		assert.NoError(t, err)
+
+		if err := os.Chtimes(caFile, time.Now(), time.Now().Add(time.Hour)); err != nil {
+			t.Fatalf("Failed to change CA file: %v", err)
+		}
+		second, err := newHTTPClient("5s", caFile, false)
+		assert.NoError(t, err)
+		assert.NotSame(t, first, second)
+
+		httpClientsMutex.Lock()
+		cached := httpClients[httpClientKey{timeout: "5s", caFile: caFile}]
+		count := 0
+		for key := range httpClients {
+			if key.caFile == caFile {
+				count++
+			}
+		}
+		httpClientsMutex.Unlock()
+		assert.Same(t, second, cached.client)
+		assert.Equal(t, 1, count)
+	})
+
+	t.Run("prunes sources removed from the config", func(t *testing.T) {
+		kept := Certificate{Name: "kept", URL: &URLSource{Address: server.URL + "/ca.pem", Timeout: "7s"}}
+		removed := Certificate{Name: "removed", URL: &URLSource{Address: server.URL + "/ca.pem", Timeout: "8s"}}
+		for _, cert := range []Certificate{kept, removed} {
+			_, err := FetchURLCertificate(cert)
+			assert.NoError(t, err)
+		}
+
+		PruneCaches([]Certificate{kept})
+
+		urlCacheMutex.Lock()
+		_, keptCached := urlCache[urlCacheKey{name: "kept", address: server.URL + "/ca.pem"}]
+		_, removedCached := urlCache[urlCacheKey{name: "removed", address: server.URL + "/ca.pem"}]
+		urlCacheLen := len(urlCache)
+		urlCacheMutex.Unlock()
+		assert.True(t, keptCached)
+		assert.False(t, removedCached)
+		assert.Equal(t, 1, urlCacheLen)
+
+		httpClientsMutex.Lock()
+		_, keptClient := httpClients[httpClientKey{timeout: "7s"}]
+		_, removedClient := httpClients[httpClientKey{timeout: "8s"}]
+		httpClientsMutex.Unlock()
+		assert.True(t, keptClient)
+		assert.False(t, removedClient)
+	})
+
+	t.Run("resolves basic auth", func(t *testing.T) {
+		t.Setenv("URL_PASSWORD", "secret")
+		cert := Certificate{Name: "keystore", Source: "url", Type: "jks", Password: "password", URL: &URLSource{
+			Address: server.URL + "/protected/keystore",
+			Auth:    &URLAuth{Basic: &URLBasicAuth{Username: "user", Password: "env:URL_PASSWORD"}},
+		}}
+		result, err := Process([]Certificate{cert}, true)
+		assert.NoError(t, err)
+		validateCertificateInfo(t, []CertificateInfo{
+			{Name: "keystore", Subject: "CN=regular,OU=MyOrganization,O=MyCompany,L=MyCity,ST=MyState,C=MyCountry", Type: "jks"},
+		}, result)
+	})
+
+	t.Run("bearer auth and headers", func(t *testing.T) {
+		cert := Certificate{Name: "bearer", URL: &URLSource{
+			Address: server.URL + "/bearer.crt",
+			Headers: map[string]string{"X-Tenant": "shop"},
+			Auth:    &URLAuth{Bearer: &URLBearerAuth{Token: "token"}},
+		}}
+		blobs, err := FetchURLCertificate(cert)
+		assert.NoError(t, err)
+		assert.Len(t, blobs, 1)
+	})
+
+	t.Run("unexpected status code", func(t *testing.T) {
+		cert := Certificate{Name: "keystore", URL: &URLSource{Address: server.URL + "/protected/keystore"}}
+		_, err := FetchURLCertificate(cert)
+		assert.EqualError(t, err, "Failed to fetch '"+server.URL+"/protected/keystore'. Unexpected status code 401.")
+	})
+
+	t.Run("custom CA", func(t *testing.T) {
+		caFile := filepath.Join(t.TempDir(), "ca.pem")
+		caPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: tlsServer.Certificate().Raw})
+		if err := os.WriteFile(caFile, caPEM, 0o644); err != nil {
+			t.Fatalf("Failed to write CA file: %v", err)
+		}
+
+		blobs, err := FetchURLCertificate(Certificate{Name: "tls", URL: &URLSource{Address: tlsServer.URL + "/bearer.crt", CAFile: caFile, Headers: map[string]string{"X-Tenant": "shop", "Authorization": "Bearer token"}}})
+		assert.NoError(t, err)
+		assert.Len(t, blobs, 1)
+
+		_, err = FetchURLCertificate(Certificate{Name: "tls", URL: &URLSource{Address: tlsServer.URL + "/ca.pem"}})
+		assert.ErrorContains(t, err, "certificate signed by unknown authority")
+	})
+
+	t.Run("invalid settings", func(t *testing.T) {
+		_, err := FetchURLCertificate(Certificate{Name: "none"})
+		assert.EqualError(t, err, "Certificate 'none' has no 'url.address' defined.")
+
+		_, err = FetchURLCertificate(Certificate{Name: "ftp", URL: &URLSource{Address: "ftp://example.com/ca.pem"}})
+		assert.EqualError(t, err, "Invalid URL 'ftp://example.com/ca.pem'.")
+
+		_, err = FetchURLCertificate(Certificate{Name: "timeout", URL: &URLSource{Address: server.URL, Timeout: "soon"}})
+		assert.EqualError(t, err, "Invalid timeout 'soon'.")
+	})
+}
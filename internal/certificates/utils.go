@@ -1,8 +1,11 @@
 package certificates
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
+	"path/filepath"
+	"strings"
 
 	"github.com/rs/zerolog/log"
 )
@@ -110,3 +113,74 @@ func generateCertificateSubject(defaultSubject string, index int) string {
 	}
 	return defaultSubject
 }
+
+// inferCertificateType infers the certificate type of raw certificate data.
+//
+// The type is inferred from the file extension of the given path. If the path has no known
+// extension, the content is checked for PEM encoded certificates or PKCS#7 structures.
+//
+// Parameters:
+//   - path: string
+//     The path of the certificate data.
+//   - data: []byte
+//     The raw certificate data.
+//
+// Returns:
+//   - string
+//     The inferred certificate type or an empty string if it can't be inferred.
+func inferCertificateType(path string, data []byte) string {
+	ext := strings.ToLower(strings.TrimPrefix(filepath.Ext(path), "."))
+	if inferredType, found := FileExtensionsToType[ext]; found {
+		return inferredType
+	}
+
+	switch {
+	case bytes.Contains(data, []byte("-----BEGIN PKCS7-----")):
+		return "p7"
+	case bytes.Contains(data, []byte("-----BEGIN CERTIFICATE-----")):
+		return "pem"
+	}
+
+	return ""
+}
+
+// InferContentType infers the type of inline certificate content. Only PEM encoded certificates and
+// PKCS#7 structures can be recognized, binary types must be defined explicitly.
+//
+// Parameters:
+//   - content: string
+//     The inline content of a certificate.
+//
+// Returns:
+//   - string
+//     The inferred certificate type or an empty string if it can't be inferred.
+func InferContentType(content string) string {
+	return inferCertificateType("", []byte(content))
+}
+
+// looksLikeCertificateType checks if the content of raw certificate data matches the given type.
+//
+// PEM and PKCS#7 data must contain a PEM block, Java KeyStores must start with the JKS magic number
+// or a DER sequence and PKCS#12 files must start with a DER sequence. This is used to discard files
+// which only share a file extension with a certificate type (e.g. TypeScript '.ts' files).
+//
+// Parameters:
+//   - certType: string
+//     The canonical certificate type.
+//   - data: []byte
+//     The raw certificate data.
+//
+// Returns:
+//   - bool
+//     True if the data looks like the given certificate type; otherwise, false.
+func looksLikeCertificateType(certType string, data []byte) bool {
+	switch certType {
+	case "pem", "p7":
+		return bytes.Contains(data, []byte("-----BEGIN "))
+	case "jks":
+		return bytes.HasPrefix(data, []byte{0xfe, 0xed, 0xfe, 0xed}) || bytes.HasPrefix(data, []byte{0x30})
+	case "p12", "truststore":
+		return bytes.HasPrefix(data, []byte{0x30})
+	}
+	return false
+}
@@ -0,0 +1,64 @@
+package certificates
+
+import (
+	"time"
+)
+
+// Validity states of a certificate.
+const (
+	ValidityNotYetValid = "not_yet_valid" // ValidityNotYetValid is the state of a certificate before its NotBefore date.
+	ValidityValid       = "valid"         // ValidityValid is the state of a certificate between its NotBefore and NotAfter date.
+	ValidityExpired     = "expired"       // ValidityExpired is the state of a certificate after its NotAfter date.
+)
+
+// ValidityStates contains all validity states of a certificate.
+var ValidityStates = []string{ValidityNotYetValid, ValidityValid, ValidityExpired}
+
+// now returns the current time. It is a variable so tests can mock it.
+var now = time.Now
+
+// setValidity sets the NotBefore date and the validity state of every extracted certificate.
+//
+// Parameters:
+//   - certInfoList: []CertificateInfo
+//     The extracted certificates. Entries describing a failed extraction are left untouched.
+func setValidity(certInfoList []CertificateInfo) {
+	current := now()
+	for i := range certInfoList {
+		cert := certInfoList[i].certificate
+		if cert == nil {
+			continue
+		}
+
+		certInfoList[i].NotBefore = cert.NotBefore.Unix()
+		certInfoList[i].Validity = validityState(cert.NotBefore, cert.NotAfter, current)
+	}
+}
+
+// validityState returns the validity state of a certificate at the given time.
+//
+// Parameters:
+//   - notBefore: time.Time
+//     The date the certificate is valid from.
+//   - notAfter: time.Time
+//     The date the certificate expires.
+//   - at: time.Time
+//     The time to evaluate the state at.
+//
+// Returns:
+//   - string
+//     One of ValidityStates.
+func validityState(notBefore, notAfter, at time.Time) string {
+	if at.Before(notBefore) {
+		return ValidityNotYetValid
+	}
+	if at.After(notAfter) {
+		return ValidityExpired
+	}
+	return ValidityValid
+}
+
+// NotBeforeAsTime returns the NotBefore date as a time.Time.
+func (ci *CertificateInfo) NotBeforeAsTime() time.Time {
+	return time.Unix(ci.NotBefore, 0)
+}
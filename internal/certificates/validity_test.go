@@ -0,0 +1,54 @@
+package certificates
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidityState(t *testing.T) {
+	notBefore := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	notAfter := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	assert.Equal(t, ValidityNotYetValid, validityState(notBefore, notAfter, notBefore.Add(-time.Second)))
+	assert.Equal(t, ValidityValid, validityState(notBefore, notAfter, notBefore))
+	assert.Equal(t, ValidityValid, validityState(notBefore, notAfter, notAfter))
+	assert.Equal(t, ValidityExpired, validityState(notBefore, notAfter, notAfter.Add(time.Second)))
+}
+
+func TestProcessValidity(t *testing.T) {
+	cert := Certificate{Name: "final", Path: "../../tests/certs/pem/final.crt", Type: "pem"}
+
+	oldNow := now
+	defer func() { now = oldNow }()
+
+	testCases := []struct {
+		Name     string
+		Now      time.Time
+		Expected string
+	}{
+		{Name: "not yet valid", Now: time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC), Expected: ValidityNotYetValid},
+		{Name: "valid", Now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), Expected: ValidityValid},
+		{Name: "expired", Now: time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC), Expected: ValidityExpired},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			now = func() time.Time { return tc.Now }
+
+			result, err := Process([]Certificate{cert}, true)
+			assert.NoError(t, err)
+			assert.Len(t, result, 1)
+			assert.Equal(t, tc.Expected, result[0].Validity)
+			assert.Equal(t, result[0].certificate.NotBefore.Unix(), result[0].NotBefore)
+		})
+	}
+
+	t.Run("failed extraction", func(t *testing.T) {
+		result, err := Process([]Certificate{{Name: "missing", Path: "../../tests/certs/pem/missing.crt", Type: "pem"}}, false)
+		assert.NoError(t, err)
+		assert.Empty(t, result[0].Validity)
+		assert.Zero(t, result[0].NotBefore)
+	})
+}
@@ -0,0 +1,599 @@
+package certificates
+
+import (
+	"bytes"
+	"certalert/internal/resolve"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+func init() {
+	registerSource("vault", FetchVaultCertificates, false)
+}
+
+const (
+	vaultDefaultPKIMount     = "pki"     // Mount of the PKI secrets engine if no mount is configured
+	vaultDefaultKVMount      = "secret"  // Mount of the KV secrets engine if no mount is configured
+	vaultDefaultKVVersion    = 2         // Version of the KV secrets engine if no version is configured
+	vaultDefaultAppRoleMount = "approle" // Mount of the AppRole auth method if no mount is configured
+)
+
+// vaultClient represents an authenticated client of the Vault HTTP API.
+type vaultClient struct {
+	httpClient *http.Client
+	address    string
+	namespace  string
+	token      string
+	tokenKey   *vaultTokenKey // tokenKey is the key of the token in vaultTokens, if the token was obtained with AppRole
+}
+
+// vaultResponse represents the fields of a Vault API response used by the vault source.
+type vaultResponse struct {
+	Data json.RawMessage `json:"data"`
+	Auth *struct {
+		ClientToken   string `json:"client_token"`
+		LeaseDuration int64  `json:"lease_duration"`
+	} `json:"auth"`
+	Errors []string `json:"errors"`
+}
+
+// vaultTokenKey identifies an AppRole login. The role ID is stored as SHA-256 hash, so it is not kept as
+// plain text.
+type vaultTokenKey struct {
+	address    string
+	namespace  string
+	mount      string
+	roleIDHash string
+}
+
+// vaultToken represents a token obtained with AppRole.
+type vaultToken struct {
+	token        string
+	secretIDHash string    // secretIDHash is the SHA-256 hash of the secret ID the token was obtained with
+	renewAt      time.Time // renewAt is the time a new token is requested, zero if the token doesn't expire
+}
+
+// vaultTokens holds the tokens obtained with AppRole by their login, so every scrape reuses the token instead
+// of creating a new one. A login with another secret ID replaces the token. Tokens of logins removed from the
+// config are dropped by PruneCaches.
+var (
+	vaultTokensMutex sync.Mutex
+	vaultTokens      = map[vaultTokenKey]vaultToken{}
+)
+
+// vaultPKICertificate represents a certificate read from a PKI secrets engine. The PEM of a revoked
+// certificate is not kept, as it is never returned.
+type vaultPKICertificate struct {
+	pem      string
+	notAfter time.Time
+	revoked  bool
+}
+
+// vaultMount identifies a secrets engine or auth method of a Vault server.
+type vaultMount struct {
+	address   string
+	namespace string
+	mount     string
+}
+
+// vaultPKICertificates holds the certificates read from PKI secrets engines by the engine and serial number.
+// A certificate never changes and a revoked or expired certificate stays so, so those are not read again.
+// Only the certificates which are neither revoked nor expired are read on every scrape to check if they
+// were revoked in the meantime. The certificates of an engine are replaced on every scrape, so serials
+// removed from Vault are dropped. Engines removed from the config are dropped by PruneCaches.
+var (
+	vaultPKICertificatesMutex sync.Mutex
+	vaultPKICertificates      = map[vaultMount]map[string]vaultPKICertificate{}
+)
+
+// vaultNotFoundError is returned if Vault responds with '404 Not Found'.
+type vaultNotFoundError struct {
+	path string
+}
+
+func (e *vaultNotFoundError) Error() string {
+	return fmt.Sprintf("path '%s' not found", e.path)
+}
+
+// FetchVaultCertificates reads the certificates stored in HashiCorp Vault.
+//
+// With 'pki', all certificates issued by a PKI secrets engine are listed ('<mount>/certs') and read
+// ('<mount>/cert/<serial>'). Revoked certificates are skipped, expired certificates unless 'includeExpired'
+// is set. Each blob is labeled with the mount and the serial number of the certificate.
+//
+// With 'kv', the secrets at the configured paths of a KV v1 or v2 secrets engine are read. Every key with
+// a known certificate file extension is returned as a blob, like the keys of a Kubernetes Secret. Binary
+// keystores (e.g. 'keystore.p12') must be stored base64 encoded. Each blob is labeled with the mount,
+// the path and the key.
+//
+// Parameters:
+//   - cert: Certificate
+//     A Certificate struct with the 'vault' settings.
+//
+// Returns:
+//   - []Blob
+//     A slice containing all certificate files found in Vault.
+//   - error
+//     An error if Vault can't be reached or the login fails.
+func FetchVaultCertificates(cert Certificate) ([]Blob, error) {
+	if cert.Vault == nil || cert.Vault.Address == "" {
+		return nil, fmt.Errorf("Certificate '%s' has no 'vault.address' defined.", cert.Name)
+	}
+	opts := *cert.Vault
+
+	if u, err := url.Parse(opts.Address); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("Invalid Vault address '%s'.", opts.Address)
+	}
+
+	if opts.PKI == nil && opts.KV == nil {
+		return nil, fmt.Errorf("Certificate '%s' has neither 'vault.pki' nor 'vault.kv' defined.", cert.Name)
+	}
+
+	httpClient, err := newHTTPClient(opts.Timeout, opts.CAFile, opts.InsecureSkipVerify)
+	if err != nil {
+		return nil, err
+	}
+
+	client := &vaultClient{
+		httpClient: httpClient,
+		address:    strings.TrimSuffix(opts.Address, "/"),
+		namespace:  opts.Namespace,
+	}
+	if err := client.login(opts.Auth); err != nil {
+		return nil, err
+	}
+
+	var blobs []Blob
+	if opts.PKI != nil {
+		pkiBlobs, err := client.fetchPKICertificates(*opts.PKI)
+		if err != nil {
+			client.forgetToken()
+			return nil, err
+		}
+		blobs = append(blobs, pkiBlobs...)
+	}
+
+	if opts.KV != nil {
+		kvBlobs, err := client.fetchKVCertificates(*opts.KV)
+		if err != nil {
+			client.forgetToken()
+			return nil, err
+		}
+		blobs = append(blobs, kvBlobs...)
+	}
+
+	log.Debug().Msgf("Found %d certificate files in Vault for certificate '%s'", len(blobs), cert.Name)
+
+	return blobs, nil
+}
+
+// login sets the token of the client, either the configured token or one obtained with AppRole.
+//
+// A token obtained with AppRole is reused until 90% of its TTL have passed, so a scrape doesn't create a
+// new token on every run.
+//
+// Parameters:
+//   - auth: VaultAuth
+//     The auth settings. Token, role ID and secret ID are resolved with resolve.ResolveVariable.
+//
+// Returns:
+//   - error
+//     An error if no or both auth methods are defined, a credential can't be resolved or the login fails.
+func (c *vaultClient) login(auth VaultAuth) error {
+	if auth.Token != "" && auth.AppRole != nil {
+		return fmt.Errorf("Both 'auth.token' and 'auth.appRole' are defined.")
+	}
+
+	if auth.AppRole == nil {
+		token, err := resolve.ResolveVariable(auth.Token)
+		if err != nil {
+			return fmt.Errorf("Failed to resolve Vault token. %v", err)
+		}
+		if token == "" {
+			return fmt.Errorf("Neither 'auth.token' nor 'auth.appRole' is defined.")
+		}
+		c.token = token
+		return nil
+	}
+
+	roleID, err := resolve.ResolveVariable(auth.AppRole.RoleID)
+	if err != nil {
+		return fmt.Errorf("Failed to resolve AppRole role ID. %v", err)
+	}
+	secretID, err := resolve.ResolveVariable(auth.AppRole.SecretID)
+	if err != nil {
+		return fmt.Errorf("Failed to resolve AppRole secret ID. %v", err)
+	}
+
+	mount := auth.AppRole.Mount
+	if mount == "" {
+		mount = vaultDefaultAppRoleMount
+	}
+
+	c.tokenKey = &vaultTokenKey{address: c.address, namespace: c.namespace, mount: mount, roleIDHash: sha256Hex(roleID)}
+	secretIDHash := sha256Hex(secretID)
+
+	vaultTokensMutex.Lock()
+	cached, found := vaultTokens[*c.tokenKey]
+	vaultTokensMutex.Unlock()
+	if found && cached.secretIDHash == secretIDHash && (cached.renewAt.IsZero() || time.Now().Before(cached.renewAt)) {
+		c.token = cached.token
+		return nil
+	}
+
+	body := map[string]string{"role_id": roleID, "secret_id": secretID}
+	resp, err := c.request(http.MethodPost, path.Join("auth", mount, "login"), body)
+	if err != nil {
+		return fmt.Errorf("Failed to login to Vault with AppRole. %v", err)
+	}
+	if resp.Auth == nil || resp.Auth.ClientToken == "" {
+		return fmt.Errorf("Failed to login to Vault with AppRole. No token returned.")
+	}
+	c.token = resp.Auth.ClientToken
+
+	token := vaultToken{token: c.token, secretIDHash: secretIDHash}
+	if resp.Auth.LeaseDuration > 0 {
+		token.renewAt = time.Now().Add(time.Duration(resp.Auth.LeaseDuration) * time.Second * 9 / 10)
+	}
+	vaultTokensMutex.Lock()
+	vaultTokens[*c.tokenKey] = token
+	vaultTokensMutex.Unlock()
+
+	return nil
+}
+
+// forgetToken removes the token of the client obtained with AppRole from the cache, e.g. because it was
+// revoked, so the next fetch logs in again.
+func (c *vaultClient) forgetToken() {
+	if c.tokenKey == nil {
+		return
+	}
+	vaultTokensMutex.Lock()
+	delete(vaultTokens, *c.tokenKey)
+	vaultTokensMutex.Unlock()
+}
+
+// sha256Hex returns the hex encoded SHA-256 hash of a credential, so it is not kept as plain text.
+func sha256Hex(value string) string {
+	sum := sha256.Sum256([]byte(value))
+	return hex.EncodeToString(sum[:])
+}
+
+// pruneVaultCaches drops the AppRole tokens and PKI certificates of Vault sources which are not in the
+// certificates anymore, and the tokens which have to be renewed anyway.
+//
+// Parameters:
+//   - certs: []Certificate
+//     The certificates of the current config.
+func pruneVaultCaches(certs []Certificate) {
+	logins := map[vaultMount]bool{}
+	mounts := map[vaultMount]bool{}
+	for _, cert := range certs {
+		if cert.Vault == nil {
+			continue
+		}
+		address := strings.TrimSuffix(cert.Vault.Address, "/")
+		if appRole := cert.Vault.Auth.AppRole; appRole != nil {
+			mount := appRole.Mount
+			if mount == "" {
+				mount = vaultDefaultAppRoleMount
+			}
+			logins[vaultMount{address: address, namespace: cert.Vault.Namespace, mount: mount}] = true
+		}
+		if pki := cert.Vault.PKI; pki != nil {
+			mount := pki.Mount
+			if mount == "" {
+				mount = vaultDefaultPKIMount
+			}
+			mounts[vaultMount{address: address, namespace: cert.Vault.Namespace, mount: mount}] = true
+		}
+	}
+
+	vaultTokensMutex.Lock()
+	for key, token := range vaultTokens {
+		expired := !token.renewAt.IsZero() && time.Now().After(token.renewAt)
+		if expired || !logins[vaultMount{address: key.address, namespace: key.namespace, mount: key.mount}] {
+			delete(vaultTokens, key)
+		}
+	}
+	vaultTokensMutex.Unlock()
+
+	vaultPKICertificatesMutex.Lock()
+	for key := range vaultPKICertificates {
+		if !mounts[key] {
+			delete(vaultPKICertificates, key)
+		}
+	}
+	vaultPKICertificatesMutex.Unlock()
+}
+
+// fetchPKICertificates reads all certificates issued by a PKI secrets engine.
+//
+// Parameters:
+//   - pki: VaultPKI
+//     The settings of the PKI secrets engine.
+//
+// Returns:
+//   - []Blob
+//     The certificates which are not revoked and, unless 'includeExpired' is set, not expired.
+//   - error
+//     An error if the certificates can't be listed or read.
+func (c *vaultClient) fetchPKICertificates(pki VaultPKI) ([]Blob, error) {
+	mount := pki.Mount
+	if mount == "" {
+		mount = vaultDefaultPKIMount
+	}
+
+	var list struct {
+		Keys []string `json:"keys"`
+	}
+	if err := c.read(path.Join(mount, "certs")+"?list=true", &list); err != nil {
+		if _, notFound := err.(*vaultNotFoundError); !notFound {
+			return nil, fmt.Errorf("Failed to list certificates of PKI mount '%s'. %v", mount, err)
+		}
+	}
+
+	key := vaultMount{address: c.address, namespace: c.namespace, mount: mount}
+	vaultPKICertificatesMutex.Lock()
+	cached := vaultPKICertificates[key]
+	vaultPKICertificatesMutex.Unlock()
+
+	var blobs []Blob
+	certs := make(map[string]vaultPKICertificate, len(list.Keys))
+	for _, serial := range list.Keys {
+		cert, err := c.readPKICertificate(mount, serial, cached)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to read certificate '%s' of PKI mount '%s'. %v", serial, mount, err)
+		}
+		certs[serial] = cert
+
+		if cert.revoked {
+			log.Debug().Msgf("Skip revoked certificate '%s' of PKI mount '%s'", serial, mount)
+			continue
+		}
+		if !pki.IncludeExpired && !cert.notAfter.IsZero() && time.Now().After(cert.notAfter) {
+			log.Debug().Msgf("Skip expired certificate '%s' of PKI mount '%s'", serial, mount)
+			continue
+		}
+
+		blobs = append(blobs, Blob{
+			Path:   serial,
+			Type:   "pem",
+			Data:   []byte(cert.pem),
+			Labels: map[string]string{"mount": mount, "serial": serial},
+		})
+	}
+
+	// Only the listed serials are kept, so certificates removed from Vault, e.g. by a tidy, are dropped
+	vaultPKICertificatesMutex.Lock()
+	vaultPKICertificates[key] = certs
+	vaultPKICertificatesMutex.Unlock()
+
+	return blobs, nil
+}
+
+// readPKICertificate reads a certificate of a PKI secrets engine, or takes it from the cached certificates of
+// the engine if it is revoked or expired.
+//
+// Parameters:
+//   - mount: string
+//     The mount of the PKI secrets engine.
+//   - serial: string
+//     The serial number of the certificate.
+//   - cached: map[string]vaultPKICertificate
+//     The certificates of the engine in vaultPKICertificates by serial number. The map is not modified.
+//
+// Returns:
+//   - vaultPKICertificate
+//     The certificate.
+//   - error
+//     An error if the certificate can't be read.
+func (c *vaultClient) readPKICertificate(mount, serial string, cached map[string]vaultPKICertificate) (vaultPKICertificate, error) {
+	if cert, found := cached[serial]; found && (cert.revoked || (!cert.notAfter.IsZero() && time.Now().After(cert.notAfter))) {
+		return cert, nil
+	}
+
+	var resp struct {
+		Certificate    string `json:"certificate"`
+		RevocationTime int64  `json:"revocation_time"`
+	}
+	if err := c.read(path.Join(mount, "cert", serial), &resp); err != nil {
+		return vaultPKICertificate{}, err
+	}
+
+	if resp.RevocationTime > 0 {
+		return vaultPKICertificate{revoked: true}, nil
+	}
+
+	cert := vaultPKICertificate{pem: resp.Certificate}
+	if block, _ := pem.Decode([]byte(resp.Certificate)); block != nil {
+		if parsed, err := x509.ParseCertificate(block.Bytes); err == nil {
+			cert.notAfter = parsed.NotAfter
+		}
+	}
+
+	return cert, nil
+}
+
+// fetchKVCertificates reads the certificate files stored in the secrets of a KV secrets engine.
+//
+// Parameters:
+//   - kv: VaultKV
+//     The settings of the KV secrets engine.
+//
+// Returns:
+//   - []Blob
+//     The certificate files of all secrets.
+//   - error
+//     An error if the version is invalid or a secret can't be read.
+func (c *vaultClient) fetchKVCertificates(kv VaultKV) ([]Blob, error) {
+	mount := kv.Mount
+	if mount == "" {
+		mount = vaultDefaultKVMount
+	}
+	version := kv.Version
+	if version == 0 {
+		version = vaultDefaultKVVersion
+	}
+	if version != 1 && version != 2 {
+		return nil, fmt.Errorf("Invalid KV version '%d'. Must be 1 or 2.", version)
+	}
+
+	var blobs []Blob
+	for _, secretPath := range kv.Paths {
+		secretPath = strings.Trim(secretPath, "/")
+
+		var secret map[string]any
+		if version == 1 {
+			if err := c.read(path.Join(mount, secretPath), &secret); err != nil {
+				return nil, fmt.Errorf("Failed to read secret '%s' of KV mount '%s'. %v", secretPath, mount, err)
+			}
+		} else {
+			var versioned struct {
+				Data map[string]any `json:"data"`
+			}
+			if err := c.read(path.Join(mount, "data", secretPath), &versioned); err != nil {
+				return nil, fmt.Errorf("Failed to read secret '%s' of KV mount '%s'. %v", secretPath, mount, err)
+			}
+			secret = versioned.Data
+		}
+
+		blobs = append(blobs, dataBlobs(kvSecretData(secret), kv.PasswordKey, map[string]string{
+			"mount": mount,
+			"path":  secretPath,
+		})...)
+	}
+
+	return blobs, nil
+}
+
+// kvSecretData converts the values of a KV secret to raw data.
+//
+// Values of keys with a binary certificate type (e.g. 'keystore.p12') are base64 decoded.
+// Values which are not strings are ignored.
+//
+// Parameters:
+//   - secret: map[string]any
+//     The data of the KV secret.
+//
+// Returns:
+//   - map[string][]byte
+//     The raw data of the secret.
+func kvSecretData(secret map[string]any) map[string][]byte {
+	data := make(map[string][]byte, len(secret))
+	for key, value := range secret {
+		s, ok := value.(string)
+		if !ok {
+			continue
+		}
+
+		certType := FileExtensionsToType[strings.TrimPrefix(path.Ext(key), ".")]
+		if certType != "" && certType != "pem" && certType != "p7" {
+			if decoded, err := base64.StdEncoding.DecodeString(s); err == nil {
+				data[key] = decoded
+				continue
+			}
+		}
+		data[key] = []byte(s)
+	}
+	return data
+}
+
+// read sends a GET request to the Vault API and decodes the 'data' of the response into out.
+//
+// Parameters:
+//   - apiPath: string
+//     The path of the request relative to '/v1/'.
+//   - out: any
+//     A pointer to decode the 'data' of the response into.
+//
+// Returns:
+//   - error
+//     An error if the request fails or the response can't be decoded.
+func (c *vaultClient) read(apiPath string, out any) error {
+	resp, err := c.request(http.MethodGet, apiPath, nil)
+	if err != nil {
+		return err
+	}
+	if len(resp.Data) == 0 {
+		return fmt.Errorf("response has no data")
+	}
+	if err := json.Unmarshal(resp.Data, out); err != nil {
+		return fmt.Errorf("failed to decode response. %v", err)
+	}
+	return nil
+}
+
+// request sends a request to the Vault API.
+//
+// Parameters:
+//   - method: string
+//     The HTTP method of the request.
+//   - apiPath: string
+//     The path of the request relative to '/v1/'. It may contain a query.
+//   - body: any
+//     The body of the request, encoded as JSON. If nil, the request has no body.
+//
+// Returns:
+//   - *vaultResponse
+//     The decoded response.
+//   - error
+//     An error if the request fails or Vault responds with an error.
+func (c *vaultClient) request(method, apiPath string, body any) (*vaultResponse, error) {
+	var reader io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		reader = bytes.NewReader(b)
+	}
+
+	req, err := http.NewRequest(method, c.address+"/v1/"+apiPath, reader)
+	if err != nil {
+		return nil, err
+	}
+	if c.token != "" {
+		req.Header.Set("X-Vault-Token", c.token)
+	}
+	if c.namespace != "" {
+		req.Header.Set("X-Vault-Namespace", c.namespace)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var vaultResp vaultResponse
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxURLBodySize)).Decode(&vaultResp); err != nil && err != io.EOF {
+		return nil, fmt.Errorf("failed to decode response with status code %d. %v", resp.StatusCode, err)
+	}
+
+	if resp.StatusCode == http.StatusNotFound && len(vaultResp.Errors) == 0 {
+		return nil, &vaultNotFoundError{path: apiPath}
+	}
+	if resp.StatusCode >= http.StatusBadRequest {
+		return nil, fmt.Errorf("unexpected status code %d. %s", resp.StatusCode, strings.Join(vaultResp.Errors, ", "))
+	}
+
+	return &vaultResp, nil
+}
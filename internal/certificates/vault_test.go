@@ -0,0 +1,254 @@
+package certificates
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// newFakeVault returns a stand-in for the Vault API serving a PKI and a KV v1 and v2 secrets engine,
+// and the number of AppRole logins.
+func newFakeVault(t *testing.T) (*httptest.Server, *int) {
+	final := readTestFile(t, "../../tests/certs/pem/final.crt")
+	root := readTestFile(t, "../../tests/certs/pem/root.crt")
+	p12 := readTestFile(t, "../../tests/certs/p12/with_password.p12")
+	current, _ := createTestCertificate(t, "current", false, true, nil, nil)
+	currentPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: current.Raw})
+	var logins int
+
+	respond := func(w http.ResponseWriter, status int, body any) {
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(body)
+	}
+	data := func(d any) map[string]any { return map[string]any{"data": d} }
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /v1/auth/approle/login", func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]string
+		json.NewDecoder(r.Body).Decode(&body)
+		if body["role_id"] != "role" || body["secret_id"] != "secret" {
+			respond(w, http.StatusBadRequest, map[string]any{"errors": []string{"invalid role or secret ID"}})
+			return
+		}
+		logins++
+		respond(w, http.StatusOK, map[string]any{"auth": map[string]any{"client_token": "approle-token", "lease_duration": 3600}})
+	})
+	mux.HandleFunc("GET /v1/pki/certs", func(w http.ResponseWriter, r *http.Request) {
+		respond(w, http.StatusOK, data(map[string]any{"keys": []string{"01:02", "03:04", "05:06", "07:08"}}))
+	})
+	mux.HandleFunc("GET /v1/pki/cert/01:02", func(w http.ResponseWriter, r *http.Request) {
+		respond(w, http.StatusOK, data(map[string]any{"certificate": string(final), "revocation_time": 0}))
+	})
+	mux.HandleFunc("GET /v1/pki/cert/03:04", func(w http.ResponseWriter, r *http.Request) {
+		respond(w, http.StatusOK, data(map[string]any{"certificate": string(root), "revocation_time": 0}))
+	})
+	mux.HandleFunc("GET /v1/pki/cert/05:06", func(w http.ResponseWriter, r *http.Request) {
+		respond(w, http.StatusOK, data(map[string]any{"certificate": string(final), "revocation_time": 1700000000}))
+	})
+	mux.HandleFunc("GET /v1/pki/cert/07:08", func(w http.ResponseWriter, r *http.Request) {
+		respond(w, http.StatusOK, data(map[string]any{"certificate": string(currentPEM), "revocation_time": 0}))
+	})
+	mux.HandleFunc("GET /v1/empty/certs", func(w http.ResponseWriter, r *http.Request) {
+		respond(w, http.StatusNotFound, map[string]any{"errors": []string{}})
+	})
+	mux.HandleFunc("GET /v1/secret/data/shop/tls", func(w http.ResponseWriter, r *http.Request) {
+		respond(w, http.StatusOK, data(map[string]any{
+			"data": map[string]any{
+				"tls.crt":           string(final),
+				"keystore.p12":      base64.StdEncoding.EncodeToString(p12),
+				"keystore.password": "password",
+				"replicas":          3,
+			},
+			"metadata": map[string]any{"version": 1},
+		}))
+	})
+	mux.HandleFunc("GET /v1/kv/shop/ca", func(w http.ResponseWriter, r *http.Request) {
+		respond(w, http.StatusOK, data(map[string]any{"ca.crt": string(root)}))
+	})
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/auth/approle/login" {
+			token := r.Header.Get("X-Vault-Token")
+			if token != "root-token" && token != "approle-token" {
+				respond(w, http.StatusForbidden, map[string]any{"errors": []string{"permission denied"}})
+				return
+			}
+		}
+		mux.ServeHTTP(w, r)
+	})), &logins
+}
+
+func TestFetchVaultCertificates(t *testing.T) {
+	server, logins := newFakeVault(t)
+	defer server.Close()
+
+	t.Run("lists PKI certificates with token", func(t *testing.T) {
+		t.Setenv("VAULT_TOKEN", "root-token")
+		cert := Certificate{Name: "pki", Vault: &VaultSource{Address: server.URL, Auth: VaultAuth{Token: "env:VAULT_TOKEN"}, PKI: &VaultPKI{}}}
+		blobs, err := FetchVaultCertificates(cert)
+		assert.NoError(t, err)
+		assert.Len(t, blobs, 1)
+		assert.Equal(t, map[string]string{"mount": "pki", "serial": "07:08"}, blobs[0].Labels)
+	})
+
+	t.Run("lists expired PKI certificates if requested", func(t *testing.T) {
+		cert := Certificate{Name: "pki", Vault: &VaultSource{Address: server.URL, Auth: VaultAuth{Token: "root-token"}, PKI: &VaultPKI{IncludeExpired: true}}}
+		blobs, err := FetchVaultCertificates(cert)
+		assert.NoError(t, err)
+		assert.Len(t, blobs, 3)
+		assert.Equal(t, map[string]string{"mount": "pki", "serial": "01:02"}, blobs[0].Labels)
+		assert.Equal(t, map[string]string{"mount": "pki", "serial": "03:04"}, blobs[1].Labels)
+		assert.Equal(t, map[string]string{"mount": "pki", "serial": "07:08"}, blobs[2].Labels)
+	})
+
+	t.Run("empty PKI mount", func(t *testing.T) {
+		cert := Certificate{Name: "pki", Vault: &VaultSource{Address: server.URL, Auth: VaultAuth{Token: "root-token"}, PKI: &VaultPKI{Mount: "empty"}}}
+		blobs, err := FetchVaultCertificates(cert)
+		assert.NoError(t, err)
+		assert.Empty(t, blobs)
+	})
+
+	t.Run("reads KV v2 secrets with AppRole", func(t *testing.T) {
+		t.Setenv("VAULT_SECRET_ID", "secret")
+		cert := Certificate{Name: "kv", Source: "vault", Vault: &VaultSource{
+			Address: server.URL,
+			Auth:    VaultAuth{AppRole: &VaultAppRole{RoleID: "role", SecretID: "env:VAULT_SECRET_ID"}},
+			KV:      &VaultKV{Paths: []string{"/shop/tls"}},
+		}}
+		result, err := Process([]Certificate{cert}, true)
+		assert.NoError(t, err)
+		validateCertificateInfo(t, []CertificateInfo{
+			{Name: "kv", Subject: "CN=with_password", Type: "p12"},
+			{Name: "kv", Subject: "CN=final", Type: "pem"},
+		}, result)
+		assert.Equal(t, map[string]string{"mount": "secret", "path": "shop/tls", "key": "keystore.p12"}, result[0].Labels)
+	})
+
+	t.Run("reuses the AppRole token", func(t *testing.T) {
+		cert := Certificate{Name: "kv", Vault: &VaultSource{
+			Address: server.URL,
+			Auth:    VaultAuth{AppRole: &VaultAppRole{RoleID: "role", SecretID: "secret"}},
+			KV:      &VaultKV{Paths: []string{"shop/tls"}},
+		}}
+		vaultTokensMutex.Lock()
+		clear(vaultTokens)
+		vaultTokensMutex.Unlock()
+
+		before := *logins
+		for range 3 {
+			_, err := FetchVaultCertificates(cert)
+			assert.NoError(t, err)
+		}
+		assert.Equal(t, 1, *logins-before)
+	})
+
+	t.Run("replaces the AppRole token of another secret ID", func(t *testing.T) {
+		cert := Certificate{Name: "kv", Vault: &VaultSource{
+			Address: server.URL,
+			Auth:    VaultAuth{AppRole: &VaultAppRole{RoleID: "role", SecretID: "secret"}},
+			KV:      &VaultKV{Paths: []string{"shop/tls"}},
+		}}
+		key := vaultTokenKey{address: server.URL, mount: "approle", roleIDHash: sha256Hex("role")}
+		vaultTokensMutex.Lock()
+		clear(vaultTokens)
+		vaultTokens[key] = vaultToken{token: "revoked-token", secretIDHash: sha256Hex("rotated")}
+		vaultTokensMutex.Unlock()
+
+		before := *logins
+		_, err := FetchVaultCertificates(cert)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, *logins-before)
+
+		vaultTokensMutex.Lock()
+		defer vaultTokensMutex.Unlock()
+		assert.Len(t, vaultTokens, 1)
+		assert.Equal(t, "approle-token", vaultTokens[key].token)
+	})
+
+	t.Run("drops PKI certificates removed from Vault", func(t *testing.T) {
+		key := vaultMount{address: server.URL, mount: "pki"}
+		vaultPKICertificatesMutex.Lock()
+		vaultPKICertificates[key] = map[string]vaultPKICertificate{"09:10": {revoked: true}}
+		vaultPKICertificatesMutex.Unlock()
+
+		cert := Certificate{Name: "pki", Vault: &VaultSource{Address: server.URL, Auth: VaultAuth{Token: "root-token"}, PKI: &VaultPKI{}}}
+		_, err := FetchVaultCertificates(cert)
+		assert.NoError(t, err)
+
+		vaultPKICertificatesMutex.Lock()
+		defer vaultPKICertificatesMutex.Unlock()
+		assert.ElementsMatch(t, []string{"01:02", "03:04", "05:06", "07:08"}, slices.Collect(maps.Keys(vaultPKICertificates[key])))
+		assert.Equal(t, vaultPKICertificate{revoked: true}, vaultPKICertificates[key]["05:06"])
+	})
+
+	t.Run("prunes sources removed from the config", func(t *testing.T) {
+		kept := Certificate{Name: "kv", Vault: &VaultSource{
+			Address: server.URL,
+			Auth:    VaultAuth{AppRole: &VaultAppRole{RoleID: "role", SecretID: "secret"}},
+			PKI:     &VaultPKI{},
+		}}
+		_, err := FetchVaultCertificates(kept)
+		assert.NoError(t, err)
+		removed := Certificate{Name: "pki", Vault: &VaultSource{Address: server.URL, Auth: VaultAuth{Token: "root-token"}, PKI: &VaultPKI{Mount: "empty"}}}
+		_, err = FetchVaultCertificates(removed)
+		assert.NoError(t, err)
+
+		PruneCaches([]Certificate{kept})
+		vaultTokensMutex.Lock()
+		assert.Len(t, vaultTokens, 1)
+		vaultTokensMutex.Unlock()
+		vaultPKICertificatesMutex.Lock()
+		assert.Len(t, vaultPKICertificates, 1)
+		assert.Contains(t, vaultPKICertificates, vaultMount{address: server.URL, mount: "pki"})
+		vaultPKICertificatesMutex.Unlock()
+
+		PruneCaches(nil)
+		vaultTokensMutex.Lock()
+		assert.Empty(t, vaultTokens)
+		vaultTokensMutex.Unlock()
+		vaultPKICertificatesMutex.Lock()
+		assert.Empty(t, vaultPKICertificates)
+		vaultPKICertificatesMutex.Unlock()
+	})
+
+	t.Run("reads KV v1 secrets", func(t *testing.T) {
+		cert := Certificate{Name: "kv", Vault: &VaultSource{Address: server.URL, Auth: VaultAuth{Token: "root-token"}, KV: &VaultKV{Mount: "kv", Version: 1, Paths: []string{"shop/ca"}}}}
+		blobs, err := FetchVaultCertificates(cert)
+		assert.NoError(t, err)
+		assert.Len(t, blobs, 1)
+		assert.Equal(t, "pem", blobs[0].Type)
+	})
+
+	t.Run("failed AppRole login", func(t *testing.T) {
+		cert := Certificate{Name: "kv", Vault: &VaultSource{Address: server.URL, Auth: VaultAuth{AppRole: &VaultAppRole{RoleID: "role", SecretID: "wrong"}}, KV: &VaultKV{}}}
+		_, err := FetchVaultCertificates(cert)
+		assert.EqualError(t, err, "Failed to login to Vault with AppRole. unexpected status code 400. invalid role or secret ID")
+	})
+
+	t.Run("permission denied", func(t *testing.T) {
+		cert := Certificate{Name: "kv", Vault: &VaultSource{Address: server.URL, Auth: VaultAuth{Token: "wrong"}, KV: &VaultKV{Paths: []string{"shop/tls"}}}}
+		_, err := FetchVaultCertificates(cert)
+		assert.EqualError(t, err, "Failed to read secret 'shop/tls' of KV mount 'secret'. unexpected status code 403. permission denied")
+	})
+
+	t.Run("invalid settings", func(t *testing.T) {
+		_, err := FetchVaultCertificates(Certificate{Name: "vault"})
+		assert.EqualError(t, err, "Certificate 'vault' has no 'vault.address' defined.")
+
+		_, err = FetchVaultCertificates(Certificate{Name: "vault", Vault: &VaultSource{Address: server.URL, Auth: VaultAuth{Token: "root-token"}}})
+		assert.EqualError(t, err, "Certificate 'vault' has neither 'vault.pki' nor 'vault.kv' defined.")
+
+		_, err = FetchVaultCertificates(Certificate{Name: "vault", Vault: &VaultSource{Address: server.URL, PKI: &VaultPKI{}}})
+		assert.EqualError(t, err, "Neither 'auth.token' nor 'auth.appRole' is defined.")
+
+		_, err = FetchVaultCertificates(Certificate{Name: "vault", Vault: &VaultSource{Address: server.URL, Auth: VaultAuth{Token: "root-token"}, KV: &VaultKV{Version: 3}}})
+		assert.EqualError(t, err, "Invalid KV version '3'. Must be 1 or 2.")
+	})
+}
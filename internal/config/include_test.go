@@ -0,0 +1,168 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// writeFiles writes the files with the given content into a temporary directory and returns the directory.
+func writeFiles(t *testing.T, files map[string]string) string {
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("Failed to create dir: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatalf("Failed to write file: %v", err)
+		}
+	}
+	return dir
+}
+
+func TestReadIncludes(t *testing.T) {
+	t.Run("merges includes and config dir", func(t *testing.T) {
+		dir := writeFiles(t, map[string]string{
+			"config.yaml": `
+server:
+  listenAddress: ":8080"
+pushgateway:
+  address: http://pushgateway:9091
+  job: main
+include:
+  - teams/*.yaml
+  - shared.yaml
+certs:
+  - name: main
+    path: main.pem
+`,
+			"teams/a.yaml": `
+certs:
+  - name: team-a
+    path: a.pem
+`,
+			"teams/b.yaml": `
+pushgateway:
+  job: team-b
+certs:
+  - name: team-b
+    path: b.pem
+`,
+			"shared.yaml": `
+certs:
+  - name: shared
+    path: shared.pem
+`,
+			"conf.d/10-ops.yaml": `
+server:
+  listenAddress: ":9090"
+certs:
+  - name: ops
+    path: ops.pem
+`,
+			"conf.d/README.md": "not a config file",
+		})
+
+		cfg := &Config{ConfigDir: filepath.Join(dir, "conf.d")}
+		err := cfg.Read(filepath.Join(dir, "config.yaml"))
+		assert.NoError(t, err)
+
+		var names []string
+		for _, cert := range cfg.Certs {
+			names = append(names, cert.Name)
+		}
+		assert.Equal(t, []string{"main", "team-a", "team-b", "shared", "ops"}, names)
+
+		assert.Equal(t, ":9090", cfg.Server.ListenAddress)
+		assert.Equal(t, "team-b", cfg.Pushgateway.Job)
+		assert.Equal(t, "http://pushgateway:9091", cfg.Pushgateway.Address)
+		assert.Equal(t, []string{
+			filepath.Join(dir, "config.yaml"),
+			filepath.Join(dir, "teams/a.yaml"),
+			filepath.Join(dir, "teams/b.yaml"),
+			filepath.Join(dir, "shared.yaml"),
+			filepath.Join(dir, "conf.d/10-ops.yaml"),
+		}, cfg.Files)
+	})
+
+	t.Run("duplicate names", func(t *testing.T) {
+		dir := writeFiles(t, map[string]string{
+			"config.yaml": `
+include:
+  - other.yaml
+certs:
+  - path: certs/main.pem
+`,
+			"other.yaml": `
+certs:
+  - name: main-pem
+`,
+		})
+
+		cfg := &Config{}
+		assert.NoError(t, cfg.Read(filepath.Join(dir, "config.yaml")))
+		assert.Len(t, cfg.Certs, 2)
+
+		cfg = &Config{FailOnError: true}
+		err := cfg.Read(filepath.Join(dir, "config.yaml"))
+		assert.EqualError(t, err, "Certificate name 'main-pem' of '"+filepath.Join(dir, "other.yaml")+"' is already defined in '"+filepath.Join(dir, "config.yaml")+"'.")
+	})
+
+	t.Run("missing include", func(t *testing.T) {
+		dir := writeFiles(t, map[string]string{"config.yaml": "include:\n  - missing.yaml\n"})
+
+		cfg := &Config{}
+		err := cfg.Read(filepath.Join(dir, "config.yaml"))
+		assert.EqualError(t, err, "Included config file '"+filepath.Join(dir, "missing.yaml")+"' does not exist.")
+	})
+
+	t.Run("glob without matches", func(t *testing.T) {
+		dir := writeFiles(t, map[string]string{"config.yaml": "include:\n  - teams/*.yaml\n"})
+
+		cfg := &Config{}
+		assert.NoError(t, cfg.Read(filepath.Join(dir, "config.yaml")))
+		assert.Equal(t, []string{filepath.Join(dir, "config.yaml")}, cfg.Files)
+	})
+
+	t.Run("missing config dir", func(t *testing.T) {
+		dir := writeFiles(t, map[string]string{"config.yaml": "certs: []\n"})
+
+		cfg := &Config{ConfigDir: filepath.Join(dir, "conf.d")}
+		err := cfg.Read(filepath.Join(dir, "config.yaml"))
+		assert.ErrorContains(t, err, "Failed to read config dir '"+filepath.Join(dir, "conf.d")+"'.")
+	})
+}
+
+func TestIsWatchedFile(t *testing.T) {
+	cfg := Config{
+		Include:   []string{"teams/*.yaml", "/etc/certalert/shared.yaml"},
+		ConfigDir: "/etc/certalert/conf.d",
+		Files:     []string{"/etc/certalert/config.yaml", "/etc/certalert/teams/a.yaml", "/etc/certalert/shared.yaml"},
+	}
+
+	testCases := []struct {
+		Name    string
+		File    string
+		Watched bool
+	}{
+		{Name: "config file is watched by viper", File: "/etc/certalert/config.yaml", Watched: false},
+		{Name: "included file", File: "/etc/certalert/shared.yaml", Watched: true},
+		{Name: "new file matching include pattern", File: "/etc/certalert/teams/b.yaml", Watched: true},
+		{Name: "file in config dir", File: "/etc/certalert/conf.d/10-ops.yaml", Watched: true},
+		{Name: "editor swap file in config dir", File: "/etc/certalert/conf.d/.10-ops.yaml.swp", Watched: false},
+		{Name: "unrelated file", File: "/etc/certalert/teams/notes.txt", Watched: false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			assert.Equal(t, tc.Watched, isWatchedFile(cfg, tc.File))
+		})
+	}
+
+	t.Run("watched dirs", func(t *testing.T) {
+		assert.Equal(t, []string{"/etc/certalert", "/etc/certalert/conf.d", "/etc/certalert/teams"}, watchedDirs(cfg))
+	})
+}
@@ -6,6 +6,7 @@ import (
 	"certalert/internal/utils"
 	"errors"
 	"fmt"
+	"maps"
 	"path/filepath"
 	"slices"
 	"strings"
@@ -14,7 +15,7 @@ import (
 )
 
 // Parse parses the configuration settings from the specified sources.
-// It calls helper methods to parse the Pushgateway and Certificates configurations.
+// It calls helper methods to parse the Pushgateway and Certificates configurations and validates the policy.
 // Additionally, it validates and extracts the hostname and port from the configured listen address.
 //
 // Returns:
@@ -29,6 +30,10 @@ func (c *Config) Parse() (err error) {
 		return err
 	}
 
+	if err := c.Policy.Validate(); err != nil {
+		return fmt.Errorf("Invalid policy. %v", err)
+	}
+
 	_, _, err = utils.ExtractHostAndPort(c.Server.ListenAddress)
 	if err != nil {
 		return fmt.Errorf("Unable to extract hostname and port: %s", err)
@@ -54,36 +59,96 @@ func (c *Config) parseCertificatesConfig() (err error) {
 		return nil
 	}
 
+	// Global labels and thresholds apply to all certificates, so an invalid one is always an error
+	if err := certificates.ValidateLabels(c.Labels); err != nil {
+		return fmt.Errorf("Invalid global labels. %v", err)
+	}
+	if err := c.Thresholds.Validate(); err != nil {
+		return fmt.Errorf("Invalid global thresholds. %v", err)
+	}
+
 	for idx, cert := range c.Certs {
+		// Templates and defaults are applied first, they can define any setting of a certificate
+		expanded, err := c.expandCertificate(cert)
+		if err != nil {
+			if err := handleFailOnError(cert, idx, fmt.Sprintf("Certificate '%s' can't be expanded. %v", cert.Name, err)); err != nil {
+				return err
+			}
+			continue
+		}
+		cert = expanded
+
+		if err := certificates.ValidateLabels(cert.Labels); err != nil {
+			if err := handleFailOnError(cert, idx, fmt.Sprintf("Certificate '%s' has invalid labels. %v", cert.Name, err)); err != nil {
+				return err
+			}
+		}
+		cert.Labels = mergeLabels(c.Labels, cert.Labels)
+
+		cert.Thresholds = cert.Thresholds.Merge(c.Thresholds)
+		if err := cert.Thresholds.Validate(); err != nil {
+			if err := handleFailOnError(cert, idx, fmt.Sprintf("Certificate '%s' has invalid thresholds. %v", cert.Name, err)); err != nil {
+				return err
+			}
+		}
+
 		if cert.Enabled != nil && !*cert.Enabled {
+			// The expanded certificate is stored, as 'enabled' can be set by a template or the defaults
+			c.Certs[idx] = cert
 			log.Debug().Msgf("Skip certificate '%s' because is disabled", cert.Name)
 			continue
 		}
 
-		if cert.Path == "" {
-			if err := handleFailOnError(cert, idx, fmt.Sprintf("Certificate '%s' has no 'path' defined.", cert.Name)); err != nil {
+		source := cert.SourceName()
+		if _, ok := certificates.SourceToFetchFunction[source]; !ok {
+			sources := utils.ExtractMapKeys(certificates.SourceToFetchFunction)
+			slices.Sort(sources)
+			if err := handleFailOnError(cert, idx, fmt.Sprintf("Certificate '%s' has an invalid source '%s'. Must be one of '%s'.", cert.Name, cert.Source, strings.Join(sources, "', '"))); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if cert.Content != "" && source != certificates.DefaultSource {
+			if err := handleFailOnError(cert, idx, fmt.Sprintf("Certificate '%s' has 'content' defined, which is only supported by the '%s' source.", cert.Name, certificates.DefaultSource)); err != nil {
 				return err
 			}
 		}
 
-		if err := utils.CheckFileAccessibility(cert.Path); err != nil {
-			if err := handleFailOnError(cert, idx, fmt.Sprintf("Certificate '%s' is not accessible. %v", cert.Name, err)); err != nil {
+		if cert.Content != "" && cert.Path != "" {
+			if err := handleFailOnError(cert, idx, fmt.Sprintf("Certificate '%s' has both 'path' and 'content' defined.", cert.Name)); err != nil {
 				return err
 			}
 		}
 
-		if cert.Name == "" {
-			file := filepath.Base(cert.Path)
-			// Replace dots, spaces and underscores with dashes
-			cert.Name = strings.Map(func(r rune) rune {
-				if r == '.' || r == ' ' || r == '_' {
-					return '-'
+		// Inline content is not read from the file system
+		if slices.Contains(certificates.SourcesWithLocalPath, source) && cert.Content == "" {
+			if cert.Path == "" {
+				if err := handleFailOnError(cert, idx, fmt.Sprintf("Certificate '%s' has no 'path' defined.", cert.Name)); err != nil {
+					return err
 				}
-				return r
-			}, file)
+			}
+
+			if err := utils.CheckFileAccessibility(cert.Path); err != nil {
+				if err := handleFailOnError(cert, idx, fmt.Sprintf("Certificate '%s' is not accessible. %v", cert.Name, err)); err != nil {
+					return err
+				}
+			}
 		}
 
-		if cert.Type == "" {
+		if cert.Name == "" && cert.Path == "" {
+			if err := handleFailOnError(cert, idx, "Certificate without 'path' has no 'name' defined."); err != nil {
+				return err
+			}
+		}
+
+		if cert.Name == "" && cert.Path != "" {
+			cert.Name = certificateNameFromPath(cert.Path)
+		}
+
+		// Sources other than files can contain multiple certificate files, their type is inferred per file.
+		// The type of inline content is inferred from the content.
+		if cert.Type == "" && source == certificates.DefaultSource && cert.Content == "" {
 			ext := strings.TrimPrefix(filepath.Ext(cert.Path), ".") // extract file extation and remove leading dot
 			if ext == "" {
 				errMsg := fmt.Sprintf("Certificate '%s' has no 'type' defined and is missing a file extension.", cert.Name)
@@ -99,12 +164,40 @@ func (c *Config) parseCertificatesConfig() (err error) {
 		}
 
 		// The Type can be specified in the config file, but it must be one of the supported types
-		if !slices.Contains(certificates.FileExtensionsTypesSorted, cert.Type) {
+		if cert.Type != "" && !slices.Contains(certificates.FileExtensionsTypesSorted, cert.Type) {
 			if err := handleFailOnError(cert, idx, fmt.Sprintf("Certificate '%s' has an invalid type '%s'. Must be one of %s.", cert.Name, cert.Type, certificates.FileExtensionsTypesSorted)); err != nil {
 				return err
 			}
 		}
 
+		if cert.Filters != nil {
+			for _, filter := range slices.Concat(cert.Filters.Include, cert.Filters.Exclude) {
+				if err := filter.Validate(); err != nil {
+					if err := handleFailOnError(cert, idx, fmt.Sprintf("Certificate '%s' has an invalid filter. %v", cert.Name, err)); err != nil {
+						return err
+					}
+				}
+			}
+		}
+
+		if err := cert.ValidateExpectations(); err != nil {
+			if err := handleFailOnError(cert, idx, fmt.Sprintf("Certificate '%s' has an invalid expectation. %v", cert.Name, err)); err != nil {
+				return err
+			}
+		}
+
+		if cert.Pins != nil {
+			if err := resolvePins(cert.Pins); err != nil {
+				if err := handleFailOnError(cert, idx, fmt.Sprintf("Certificate '%s' has a non resolvable pin. %v", cert.Name, err)); err != nil {
+					return err
+				}
+			} else if err := cert.Pins.Validate(); err != nil {
+				if err := handleFailOnError(cert, idx, fmt.Sprintf("Certificate '%s' has an invalid pin. %v", cert.Name, err)); err != nil {
+					return err
+				}
+			}
+		}
+
 		pw, err := resolve.ResolveVariable(cert.Password)
 		if err != nil {
 			if err := handleFailOnError(cert, idx, fmt.Sprintf("Certifacate '%s' has a non resolvable 'password'. %v", cert.Name, err)); err != nil {
@@ -113,12 +206,72 @@ func (c *Config) parseCertificatesConfig() (err error) {
 		}
 		cert.Password = pw
 
+		content, err := resolve.ResolveVariable(cert.Content)
+		if err != nil {
+			if err := handleFailOnError(cert, idx, fmt.Sprintf("Certificate '%s' has a non resolvable 'content'. %v", cert.Name, err)); err != nil {
+				return err
+			}
+		}
+		cert.Content = content
+
+		// Binary content is base64 encoded, so its type can't be inferred when fetching the certificate
+		if cert.Content != "" && cert.Type == "" && certificates.InferContentType(cert.Content) == "" {
+			if err := handleFailOnError(cert, idx, fmt.Sprintf("Certificate '%s' has 'content' which is not PEM encoded and no 'type' defined.", cert.Name)); err != nil {
+				return err
+			}
+		}
+
 		c.Certs[idx] = cert
 	}
 
 	return nil
 }
 
+// mergeLabels merges the global labels with the labels of a certificate. The labels of the certificate win.
+//
+// Parameters:
+//   - global: map[string]string
+//     The global labels of the config.
+//   - labels: map[string]string
+//     The labels of the certificate.
+//
+// Returns:
+//   - map[string]string
+//     The merged labels, nil if there are none.
+func mergeLabels(global, labels map[string]string) map[string]string {
+	if len(global) == 0 && len(labels) == 0 {
+		return nil
+	}
+
+	merged := make(map[string]string, len(global)+len(labels))
+	maps.Copy(merged, global)
+	maps.Copy(merged, labels)
+	return merged
+}
+
+// resolvePins resolves the pinned fingerprints and SPKI pins of a certificate in place.
+//
+// Parameters:
+//   - pins: *certificates.Pins
+//     The pins to resolve.
+//
+// Returns:
+//   - error
+//     An error if a pin can't be resolved.
+func resolvePins(pins *certificates.Pins) error {
+	for _, values := range [][]string{pins.Fingerprints, pins.SPKI} {
+		for i, value := range values {
+			resolved, err := resolve.ResolveVariable(value)
+			if err != nil {
+				return err
+			}
+			values[i] = strings.TrimSpace(resolved)
+		}
+	}
+
+	return nil
+}
+
 // parsePushgatewayConfig parses the Pushgateway configuration settings.
 // It validates and resolves variables in the pushgateway configuration.
 //
@@ -286,6 +286,223 @@ func TestParseCertificatesConfig(t *testing.T) {
 
 		assertError(t, expectedError, err)
 	})
+
+	t.Run("cert content resolved", func(t *testing.T) {
+		config := &Config{
+			Certs: []certificates.Certificate{
+				{
+					Name:    "test_cert",
+					Enabled: utils.BoolPtr(true),
+					Content: "env:INLINE_CERT",
+				},
+			},
+			FailOnError: true,
+		}
+		expectedError := ""
+
+		t.Setenv("INLINE_CERT", "-----BEGIN CERTIFICATE-----")
+		err := config.parseCertificatesConfig()
+
+		assertError(t, expectedError, err)
+		if config.Certs[0].Content != "-----BEGIN CERTIFICATE-----" {
+			t.Errorf("Expected content to be resolved, got '%s'", config.Certs[0].Content)
+		}
+		if config.Certs[0].Type != "" {
+			t.Errorf("Expected type of content not to be inferred, got '%s'", config.Certs[0].Type)
+		}
+	})
+
+	t.Run("cert content without type not PEM encoded", func(t *testing.T) {
+		config := &Config{
+			Certs: []certificates.Certificate{
+				{
+					Name:    "test_cert",
+					Enabled: utils.BoolPtr(true),
+					Content: "MIIKZgIBAzCCCiwGCSqGSIb3DQEHAaCCCh0Egg==",
+				},
+			},
+			FailOnError: true,
+		}
+		expectedError := "Certificate 'test_cert' has 'content' which is not PEM encoded and no 'type' defined."
+
+		err := config.parseCertificatesConfig()
+
+		assertError(t, expectedError, err)
+	})
+
+	t.Run("cert content with type not PEM encoded", func(t *testing.T) {
+		config := &Config{
+			Certs: []certificates.Certificate{
+				{
+					Name:    "test_cert",
+					Enabled: utils.BoolPtr(true),
+					Type:    "p12",
+					Content: "MIIKZgIBAzCCCiwGCSqGSIb3DQEHAaCCCh0Egg==",
+				},
+			},
+			FailOnError: true,
+		}
+
+		err := config.parseCertificatesConfig()
+
+		assertError(t, "", err)
+	})
+
+	t.Run("cert content and path defined", func(t *testing.T) {
+		config := &Config{
+			Certs: []certificates.Certificate{
+				{
+					Name:    "test_cert",
+					Enabled: utils.BoolPtr(true),
+					Path:    "../../tests/certs/pem/final.pem",
+					Content: "-----BEGIN CERTIFICATE-----",
+				},
+			},
+			FailOnError: true,
+		}
+		expectedError := "Certificate 'test_cert' has both 'path' and 'content' defined."
+
+		err := config.parseCertificatesConfig()
+
+		assertError(t, expectedError, err)
+	})
+
+	t.Run("cert content with other source", func(t *testing.T) {
+		config := &Config{
+			Certs: []certificates.Certificate{
+				{
+					Name:    "test_cert",
+					Enabled: utils.BoolPtr(true),
+					Source:  "url",
+					Content: "-----BEGIN CERTIFICATE-----",
+				},
+			},
+			FailOnError: true,
+		}
+		expectedError := "Certificate 'test_cert' has 'content' defined, which is only supported by the 'file' source."
+
+		err := config.parseCertificatesConfig()
+
+		assertError(t, expectedError, err)
+	})
+
+	t.Run("cert content not resolvable", func(t *testing.T) {
+		config := &Config{
+			Certs: []certificates.Certificate{
+				{
+					Name:    "test_cert",
+					Enabled: utils.BoolPtr(true),
+					Content: "file:INVALID_FILE",
+				},
+			},
+			FailOnError: true,
+		}
+		expectedError := "Certificate 'test_cert' has a non resolvable 'content'. Failed to open file 'INVALID_FILE'. open INVALID_FILE: no such file or directory"
+
+		err := config.parseCertificatesConfig()
+
+		assertError(t, expectedError, err)
+	})
+
+	t.Run("cert with invalid filter", func(t *testing.T) {
+		config := &Config{
+			Certs: []certificates.Certificate{
+				{
+					Name:    "test_cert",
+					Enabled: utils.BoolPtr(true),
+					Path:    "../../tests/certs/pem/chain.pem",
+					Filters: &certificates.Filters{
+						Exclude: []certificates.Filter{{Field: "subject"}},
+					},
+				},
+			},
+			FailOnError: true,
+		}
+		expectedError := "Certificate 'test_cert' has an invalid filter. Filter on 'subject' must define either 'value' or 'regex'."
+
+		err := config.parseCertificatesConfig()
+
+		assertError(t, expectedError, err)
+	})
+
+	t.Run("cert with invalid expected IP", func(t *testing.T) {
+		config := &Config{
+			Certs: []certificates.Certificate{
+				{
+					Name:        "test_cert",
+					Enabled:     utils.BoolPtr(true),
+					Path:        "../../tests/certs/pem/chain.pem",
+					ExpectedIPs: []string{"localhost"},
+				},
+			},
+			FailOnError: true,
+		}
+		expectedError := "Certificate 'test_cert' has an invalid expectation. Invalid expected IP 'localhost'."
+
+		err := config.parseCertificatesConfig()
+
+		assertError(t, expectedError, err)
+	})
+
+	t.Run("cert with resolved pins", func(t *testing.T) {
+		t.Setenv("TEST_PIN", "67:AF:9D:53:15:33:8C:B3:73:AB:3E:4B:93:FE:97:6B:FA:AA:F4:15:D0:EF:CA:BE:4F:E3:B5:A2:9A:BD:27:19")
+		config := &Config{
+			Certs: []certificates.Certificate{
+				{
+					Name:    "test_cert",
+					Enabled: utils.BoolPtr(true),
+					Path:    "../../tests/certs/pem/chain.pem",
+					Pins:    &certificates.Pins{Fingerprints: []string{"env:TEST_PIN"}},
+				},
+			},
+			FailOnError: true,
+		}
+
+		err := config.parseCertificatesConfig()
+
+		assertError(t, "", err)
+		if config.Certs[0].Pins.Fingerprints[0] != os.Getenv("TEST_PIN") {
+			t.Errorf("Expected pin to be resolved, got '%s'", config.Certs[0].Pins.Fingerprints[0])
+		}
+	})
+
+	t.Run("cert with invalid pin", func(t *testing.T) {
+		config := &Config{
+			Certs: []certificates.Certificate{
+				{
+					Name:    "test_cert",
+					Enabled: utils.BoolPtr(true),
+					Path:    "../../tests/certs/pem/chain.pem",
+					Pins:    &certificates.Pins{SPKI: []string{"abc"}},
+				},
+			},
+			FailOnError: true,
+		}
+		expectedError := "Certificate 'test_cert' has an invalid pin. Invalid SPKI pin 'abc'. Must be a base64 encoded SHA-256 hash of the public key."
+
+		err := config.parseCertificatesConfig()
+
+		assertError(t, expectedError, err)
+	})
+
+	t.Run("cert with non resolvable pin", func(t *testing.T) {
+		config := &Config{
+			Certs: []certificates.Certificate{
+				{
+					Name:    "test_cert",
+					Enabled: utils.BoolPtr(true),
+					Path:    "../../tests/certs/pem/chain.pem",
+					Pins:    &certificates.Pins{SPKI: []string{"env:MISSING_PIN"}},
+				},
+			},
+			FailOnError: true,
+		}
+		expectedError := "Certificate 'test_cert' has a non resolvable pin. Environment variable 'MISSING_PIN' not found."
+
+		err := config.parseCertificatesConfig()
+
+		assertError(t, expectedError, err)
+	})
 }
 
 func TestParsePushgatewayConfig(t *testing.T) {
@@ -530,6 +747,19 @@ func TestParse(t *testing.T) {
 		assertError(t, err, "Certificate 'test_cert' has no 'path' defined.")
 	})
 
+	t.Run("Policy error", func(t *testing.T) {
+		config := &Config{
+			Policy: certificates.Policy{
+				Rules: map[string]string{"missing-san": "fatal"},
+			},
+			FailOnError: true,
+		}
+
+		err := config.Parse()
+
+		assertError(t, err, "Invalid policy. Invalid severity 'fatal' for policy rule 'missing-san'. Must be one of 'off', 'info', 'warning', 'error'.")
+	})
+
 	t.Run("Simple config (success)", func(t *testing.T) {
 		config := &Config{
 			Certs: []certificates.Certificate{
@@ -557,3 +787,92 @@ func TestParse(t *testing.T) {
 		assertError(t, err, nil)
 	})
 }
+
+func TestParseLabels(t *testing.T) {
+	t.Run("global labels are merged into certificate labels", func(t *testing.T) {
+		c := Config{
+			Labels: map[string]string{"env": "prod", "team": "platform"},
+			Certs: []certificates.Certificate{
+				{Name: "web", Path: "../../tests/certs/pem/final.crt", Labels: map[string]string{"team": "web"}},
+				{Name: "plain", Path: "../../tests/certs/pem/final.crt"},
+			},
+		}
+
+		if err := c.parseCertificatesConfig(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if got := fmt.Sprint(c.Certs[0].Labels); got != "map[env:prod team:web]" {
+			t.Errorf("expected certificate labels to win, got %s", got)
+		}
+		if got := fmt.Sprint(c.Certs[1].Labels); got != "map[env:prod team:platform]" {
+			t.Errorf("expected global labels, got %s", got)
+		}
+	})
+
+	t.Run("invalid global label", func(t *testing.T) {
+		c := Config{Labels: map[string]string{"instance": "a"}}
+
+		err := c.parseCertificatesConfig()
+		if err == nil || err.Error() != "Invalid global labels. Label name 'instance' is reserved. Must not be one of 'fingerprint', 'instance', 'job', 'location', 'mismatches', 'reason', 'role', 'rule', 'severity', 'state', 'subject', 'type'." {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("invalid certificate label", func(t *testing.T) {
+		c := Config{
+			FailOnError: true,
+			Certs: []certificates.Certificate{
+				{Name: "web", Path: "../../tests/certs/pem/final.crt", Labels: map[string]string{"team-name": "web"}},
+			},
+		}
+
+		err := c.parseCertificatesConfig()
+		if err == nil || err.Error() != "Certificate 'web' has invalid labels. Invalid label name 'team-name'. Must match '[a-zA-Z_][a-zA-Z0-9_]*' and must not start with '__'." {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+}
+
+func TestParseThresholds(t *testing.T) {
+	t.Run("global thresholds are merged into certificate thresholds", func(t *testing.T) {
+		c := Config{
+			Thresholds: certificates.Thresholds{Warning: "60d", Critical: "14d"},
+			Certs: []certificates.Certificate{
+				{Name: "web", Path: "../../tests/certs/pem/final.crt", Thresholds: certificates.Thresholds{Critical: "7d"}},
+			},
+		}
+
+		if err := c.parseCertificatesConfig(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		expected := certificates.Thresholds{Warning: "60d", Critical: "7d"}
+		if c.Certs[0].Thresholds != expected {
+			t.Errorf("expected thresholds %v, got %v", expected, c.Certs[0].Thresholds)
+		}
+	})
+
+	t.Run("invalid global thresholds", func(t *testing.T) {
+		c := Config{Thresholds: certificates.Thresholds{Warning: "soon"}}
+
+		err := c.parseCertificatesConfig()
+		if err == nil || err.Error() != "Invalid global thresholds. Invalid warning threshold. Invalid threshold 'soon'. Must be a duration like '30d' or '12h' or a percentage like '33%'." {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("critical threshold longer than warning threshold", func(t *testing.T) {
+		c := Config{
+			FailOnError: true,
+			Certs: []certificates.Certificate{
+				{Name: "web", Path: "../../tests/certs/pem/final.crt", Thresholds: certificates.Thresholds{Warning: "7d", Critical: "14d"}},
+			},
+		}
+
+		err := c.parseCertificatesConfig()
+		if err == nil || err.Error() != "Certificate 'web' has invalid thresholds. The critical threshold '14d' must not be longer than the warning threshold '7d'." {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+}
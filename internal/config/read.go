@@ -1,8 +1,14 @@
 package config
 
 import (
+	"errors"
 	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
 
+	"github.com/rs/zerolog/log"
 	"github.com/spf13/viper"
 )
 
@@ -20,15 +26,201 @@ import (
 //   - error
 //     An error if reading or unmarshaling the configuration fails.
 func (c *Config) Read(configPath string) error {
-	viper.SetConfigFile(configPath)
+	return c.readWith(viper.GetViper(), configPath)
+}
+
+// readWith reads the configuration settings from the specified config file with the given viper instance.
+//
+// The config files listed in 'include' and the files in the config dir are merged into the config file in
+// this order. Certificates are appended, all other settings are overridden by the files read later.
+// Duplicate certificate names are reported as error if 'failOnError' is set, otherwise as warning.
+//
+// Parameters:
+//   - v: *viper.Viper
+//     The viper instance to read the config file with.
+//   - configPath: string
+//     The file path to the configuration file.
+//
+// Returns:
+//   - error
+//     An error if reading or unmarshaling the configuration fails.
+func (c *Config) readWith(v *viper.Viper, configPath string) error {
+	v.SetConfigFile(configPath)
 
-	if err := viper.ReadInConfig(); err != nil {
+	if err := v.ReadInConfig(); err != nil {
 		return fmt.Errorf("Failed to read config file: %v", err)
 	}
 
-	if err := viper.Unmarshal(c); err != nil {
+	fragments, err := fragmentFiles(v.ConfigFileUsed(), v.GetStringSlice("include"), c.ConfigDir)
+	if err != nil {
+		return err
+	}
+
+	merged := viper.New()
+	settings := v.AllSettings()
+	certs := settingsList(settings["certs"])
+	origins := slices.Repeat([]string{v.ConfigFileUsed()}, len(certs)) // origins contains the file of each certificate
+	if err := merged.MergeConfigMap(settings); err != nil {
+		return fmt.Errorf("Failed to merge config file: %v", err)
+	}
+
+	for _, fragment := range fragments {
+		fv := viper.New()
+		fv.SetConfigFile(fragment)
+		if err := fv.ReadInConfig(); err != nil {
+			return fmt.Errorf("Failed to read included config file '%s': %v", fragment, err)
+		}
+
+		// Certificates are appended instead of replaced and includes are not nested
+		settings := fv.AllSettings()
+		fragmentCerts := settingsList(settings["certs"])
+		certs = append(certs, fragmentCerts...)
+		origins = append(origins, slices.Repeat([]string{fragment}, len(fragmentCerts))...)
+		delete(settings, "certs")
+		delete(settings, "include")
+
+		if err := merged.MergeConfigMap(settings); err != nil {
+			return fmt.Errorf("Failed to merge included config file '%s': %v", fragment, err)
+		}
+	}
+
+	if err := merged.MergeConfigMap(map[string]any{"certs": certs}); err != nil {
+		return fmt.Errorf("Failed to merge config file: %v", err)
+	}
+
+	if err := merged.Unmarshal(c); err != nil {
 		return fmt.Errorf("Failed to unmarshal config file: %v", err)
 	}
+	c.Files = append([]string{v.ConfigFileUsed()}, fragments...)
+
+	if err := c.checkDuplicateNames(origins); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// fragmentFiles returns the config files to merge into the config file.
+//
+// The patterns of 'include' are resolved relative to the directory of the config file and can contain globs.
+// A pattern without glob must match an existing file. The files of the config dir with a supported extension
+// follow in lexical order. Every file is returned once and the config file itself is skipped.
+//
+// Parameters:
+//   - configFile: string
+//     The path of the config file.
+//   - include: []string
+//     The patterns of the files to include.
+//   - configDir: string
+//     The directory with config fragments, empty if not set.
+//
+// Returns:
+//   - []string
+//     The paths of the files to merge, in the order they are merged.
+//   - error
+//     An error if an included file or the config dir doesn't exist.
+func fragmentFiles(configFile string, include []string, configDir string) ([]string, error) {
+	var files []string
+	seen := map[string]bool{filepath.Clean(configFile): true}
+	add := func(file string) {
+		if file = filepath.Clean(file); !seen[file] {
+			seen[file] = true
+			files = append(files, file)
+		}
+	}
+
+	for _, pattern := range include {
+		pattern = includePattern(configFile, pattern)
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid include pattern '%s'. %v", pattern, err)
+		}
+		if len(matches) == 0 && !hasGlob(pattern) {
+			return nil, fmt.Errorf("Included config file '%s' does not exist.", pattern)
+		}
+		for _, match := range matches {
+			add(match)
+		}
+	}
+
+	if configDir == "" {
+		return files, nil
+	}
+
+	entries, err := os.ReadDir(configDir)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read config dir '%s'. %v", configDir, err)
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() && isConfigFragment(entry.Name()) {
+			add(filepath.Join(configDir, entry.Name()))
+		}
+	}
+
+	return files, nil
+}
+
+// includePattern resolves an include pattern relative to the directory of the config file.
+func includePattern(configFile, pattern string) string {
+	if filepath.IsAbs(pattern) {
+		return pattern
+	}
+	return filepath.Join(filepath.Dir(configFile), pattern)
+}
+
+// hasGlob reports if the pattern contains a glob.
+func hasGlob(pattern string) bool {
+	return strings.ContainsAny(pattern, "*?[")
+}
+
+// isConfigFragment reports if the file in the config dir is a config file, based on its extension.
+func isConfigFragment(name string) bool {
+	ext := strings.TrimPrefix(filepath.Ext(name), ".")
+	return !strings.HasPrefix(name, ".") && slices.Contains(configFragmentExtensions, ext)
+}
+
+// configFragmentExtensions contains the extensions of the files read from the config dir.
+var configFragmentExtensions = []string{"json", "toml", "yaml", "yml"}
+
+// settingsList returns the items of a list setting, nil if the setting is not a list.
+func settingsList(setting any) []any {
+	list, _ := setting.([]any)
+	return list
+}
+
+// checkDuplicateNames reports certificates with the same name.
+//
+// Parameters:
+//   - origins: []string
+//     The file each certificate was read from.
+//
+// Returns:
+//   - error
+//     An error if a name is used more than once and 'failOnError' is set.
+func (c *Config) checkDuplicateNames(origins []string) error {
+	firstOrigin := make(map[string]string) // name -> file of the first certificate with this name
+	for idx, cert := range c.Certs {
+		name := cert.Name
+		if name == "" && cert.Path != "" {
+			name = certificateNameFromPath(cert.Path)
+		}
+		if name == "" {
+			continue
+		}
+
+		origin := origins[idx]
+		first, found := firstOrigin[name]
+		if !found {
+			firstOrigin[name] = origin
+			continue
+		}
+
+		errMsg := fmt.Sprintf("Certificate name '%s' of '%s' is already defined in '%s'.", name, origin, first)
+		if c.FailOnError {
+			return errors.New(errMsg)
+		}
+		log.Warn().Msg(errMsg)
+	}
 
 	return nil
 }
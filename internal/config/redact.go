@@ -1,17 +1,21 @@
 package config
 
 import (
-	"certalert/internal/utils"
-	"strings"
+	"certalert/internal/resolve"
+	"reflect"
 )
 
+// SecretTag is the struct tag marking a config field as sensitive. Fields tagged with `secret:"true"` are
+// redacted by RedactConfig; all values below such a field, like the values of a map, are redacted as well.
+const SecretTag = "secret"
+
 // RedactConfig redacts sensitive data from a configuration object.
 //
-// This function redacts sensitive information in the provided Config object, such as:
-// - Pushgateway.Auth.Basic.Username
-// - Pushgateway.Auth.Basic.Password
-// - Pushgateway.Auth.Bearer.Token
-// - Certs.Password
+// This function walks the provided Config object recursively, including nested structs, pointers, slices and
+// maps like Certs, Defaults and Templates, and redacts every string of a field tagged with `secret:"true"`,
+// such as the Pushgateway auth, the certificate passwords and contents, the URL headers and the credentials
+// of the URL, Vault and S3 sources. New config fields are redacted by tagging them, without changing this
+// function.
 //
 // Parameters:
 //   - config: *Config
@@ -21,31 +25,59 @@ import (
 //   - error
 //     An error if redacting the sensitive information fails.
 func RedactConfig(config *Config) error {
-	if utils.HasStructField(config, "Pushgateway.Auth.Basic.Username") {
-		config.Pushgateway.Auth.Basic.Username = redactVariable(config.Pushgateway.Auth.Basic.Username)
-	}
-
-	if utils.HasStructField(config, "Pushgateway.Auth.Basic.Password") {
-		config.Pushgateway.Auth.Basic.Password = redactVariable(config.Pushgateway.Auth.Basic.Password)
-	}
-
-	if utils.HasStructField(config, "Pushgateway.Auth.Bearer.Token") {
-		config.Pushgateway.Auth.Bearer.Token = redactVariable(config.Pushgateway.Auth.Bearer.Token)
-	}
+	redactValue(reflect.ValueOf(config).Elem(), false)
+	return nil
+}
 
-	for i, cert := range config.Certs {
-		if utils.HasStructField(cert, "Password") {
-			config.Certs[i].Password = redactVariable(cert.Password)
+// redactValue redacts the strings of a value in place.
+//
+// Structs are walked field by field, a field is sensitive if it is tagged with `secret:"true"` or if the
+// value containing it is sensitive. Pointers, slices, arrays and maps are walked element by element. Map
+// values are not addressable, so they are copied, redacted and stored again.
+//
+// Parameters:
+//   - v: reflect.Value
+//     The settable value to be redacted.
+//   - secret: bool
+//     Whether the value is sensitive.
+func redactValue(v reflect.Value, secret bool) {
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if !v.IsNil() {
+			redactValue(v.Elem(), secret)
+		}
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			field := v.Type().Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			redactValue(v.Field(i), secret || field.Tag.Get(SecretTag) == "true")
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			redactValue(v.Index(i), secret)
+		}
+	case reflect.Map:
+		iter := v.MapRange()
+		for iter.Next() {
+			value := reflect.New(v.Type().Elem()).Elem()
+			value.Set(iter.Value())
+			redactValue(value, secret)
+			v.SetMapIndex(iter.Key(), value)
+		}
+	case reflect.String:
+		if secret && v.CanSet() {
+			v.SetString(redactVariable(v.String()))
 		}
 	}
-
-	return nil
 }
 
-// redactVariable redacts sensitive data from a string if it is not prefixed with "env:" or "file:".
+// redactVariable redacts sensitive data from a string if it is not a reference to a secret.
 //
 // This function is used to redact sensitive information from a string, such as passwords, unless the string
-// is explicitly marked with the "env:" or "file:" prefix. If the input string is empty or already prefixed,
+// is a reference of a registered scheme like "env:" or "file:", which doesn't reveal the secret. References
+// containing the secret itself, like "base64:", are redacted. If the input string is empty or a reference,
 // it remains unchanged; otherwise, it is redacted.
 //
 // Parameters:
@@ -56,7 +88,7 @@ func RedactConfig(config *Config) error {
 //   - string
 //     The redacted or unchanged string.
 func redactVariable(s string) string {
-	if strings.HasPrefix(s, "env:") || strings.HasPrefix(s, "file:") || s == "" {
+	if s == "" || (resolve.IsReference(s) && !resolve.IsSensitive(s)) {
 		return s
 	}
 	return "<REDACTED>"
@@ -2,6 +2,7 @@ package config
 
 import (
 	"certalert/internal/certificates"
+	"certalert/internal/test_helpers"
 	"testing"
 )
 
@@ -24,6 +25,24 @@ func TestRedactVariable(t *testing.T) {
 		}
 	})
 
+	t.Run("does not redact other registered references", func(t *testing.T) {
+		for _, input := range []string{"exec:pass show certalert/jks", "secretKeyRef:keystore/password", "enc:YWdlLWVuY3J5cHRpb24ub3JnL3Yx"} {
+			actual := redactVariable(input)
+			if actual != input {
+				t.Errorf("Expected %s, got %s", input, actual)
+			}
+		}
+	})
+
+	t.Run("redacts references containing the secret", func(t *testing.T) {
+		input := "base64:c2VjcmV0"
+		expected := "<REDACTED>"
+		actual := redactVariable(input)
+		if actual != expected {
+			t.Errorf("Expected %s, got %s", expected, actual)
+		}
+	})
+
 	t.Run("redacts non-prefixed strings", func(t *testing.T) {
 		input := "mysecret"
 		expected := "<REDACTED>"
@@ -81,6 +100,36 @@ func TestRedactConfig(t *testing.T) {
 		Name:     "TestCert",
 		Password: "password",
 	})
+	config.Certs = append(config.Certs, certificates.Certificate{
+		Name:     "TestURLCert",
+		Password: "password",
+		Content:  "-----BEGIN CERTIFICATE-----",
+		URL: &certificates.URLSource{
+			Address: "https://example.com/ca.pem",
+			Headers: map[string]string{"X-Api-Key": "key", "X-Tenant": "env:TENANT"},
+			Auth: &certificates.URLAuth{
+				Basic:  &certificates.URLBasicAuth{Username: "username", Password: "password"},
+				Bearer: &certificates.URLBearerAuth{Token: "token"},
+			},
+		},
+	})
+
+	config.Certs = append(config.Certs, certificates.Certificate{
+		Name: "TestVaultCert",
+		Vault: &certificates.VaultSource{
+			Address: "https://vault.example.com",
+			Auth: certificates.VaultAuth{
+				Token:   "token",
+				AppRole: &certificates.VaultAppRole{RoleID: "role", SecretID: "secret"},
+			},
+		},
+		S3: &certificates.S3Source{
+			Bucket:          "certs",
+			AccessKeyID:     "AKID",
+			SecretAccessKey: "secret",
+			SessionToken:    "env:AWS_SESSION_TOKEN",
+		},
+	})
 
 	err := RedactConfig(config)
 	if err != nil {
@@ -91,6 +140,14 @@ func TestRedactConfig(t *testing.T) {
 		if config.Pushgateway.Address != "http://example.com" {
 			t.Errorf("Address not http://example.com")
 		}
+
+		if config.Certs[1].URL.Address != "https://example.com/ca.pem" {
+			t.Errorf("URL Address not https://example.com/ca.pem")
+		}
+
+		if config.Certs[1].URL.Headers["X-Tenant"] != "env:TENANT" {
+			t.Errorf("URL Header X-Tenant not env:TENANT")
+		}
 	})
 
 	t.Run("redacts sensitive fields", func(t *testing.T) {
@@ -107,10 +164,102 @@ func TestRedactConfig(t *testing.T) {
 			t.Errorf("Bearer Token not <REDACTED>")
 		}
 
-		for _, cert := range config.Certs {
+		for _, cert := range config.Certs[:2] {
 			if cert.Password != "<REDACTED>" {
 				t.Errorf("Cert Password not <REDACTED>")
 			}
 		}
+
+		if config.Certs[1].Content != "<REDACTED>" {
+			t.Errorf("Cert Content not <REDACTED>")
+		}
+
+		url := config.Certs[1].URL
+		if url.Headers["X-Api-Key"] != "<REDACTED>" {
+			t.Errorf("URL Header X-Api-Key not <REDACTED>")
+		}
+
+		if url.Auth.Basic.Username != "<REDACTED>" || url.Auth.Basic.Password != "<REDACTED>" {
+			t.Errorf("URL Basic Auth not <REDACTED>")
+		}
+
+		if url.Auth.Bearer.Token != "<REDACTED>" {
+			t.Errorf("URL Bearer Token not <REDACTED>")
+		}
+
+		vault := config.Certs[2].Vault
+		if vault.Auth.Token != "<REDACTED>" {
+			t.Errorf("Vault Token not <REDACTED>")
+		}
+
+		if vault.Auth.AppRole.SecretID != "<REDACTED>" {
+			t.Errorf("Vault AppRole SecretID not <REDACTED>")
+		}
+
+		if vault.Auth.AppRole.RoleID != "role" {
+			t.Errorf("Vault AppRole RoleID not role")
+		}
+
+		s3 := config.Certs[2].S3
+		if s3.SecretAccessKey != "<REDACTED>" {
+			t.Errorf("S3 SecretAccessKey not <REDACTED>")
+		}
+
+		if s3.SessionToken != "env:AWS_SESSION_TOKEN" {
+			t.Errorf("S3 SessionToken not env:AWS_SESSION_TOKEN")
+		}
+	})
+}
+
+func TestRedactConfigNested(t *testing.T) {
+	config := &Config{
+		Defaults: certificates.Certificate{
+			Password: "password",
+			Kubernetes: &certificates.KubernetesSource{
+				PasswordKey: "keystore.password",
+			},
+		},
+		Templates: map[string]certificates.Certificate{
+			"api": {
+				Password: "password",
+				URL: &certificates.URLSource{
+					Address: "https://example.com/ca.pem",
+					Headers: map[string]string{"Authorization": "Bearer token"},
+				},
+			},
+		},
+	}
+
+	if err := RedactConfig(config); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	t.Run("redacts the defaults", func(t *testing.T) {
+		if config.Defaults.Password != "<REDACTED>" {
+			t.Errorf("Defaults Password not <REDACTED>")
+		}
+
+		if config.Defaults.Kubernetes.PasswordKey != "keystore.password" {
+			t.Errorf("Defaults Kubernetes PasswordKey not keystore.password")
+		}
+	})
+
+	t.Run("redacts the templates", func(t *testing.T) {
+		template := config.Templates["api"]
+		if template.Password != "<REDACTED>" {
+			t.Errorf("Template Password not <REDACTED>")
+		}
+
+		if template.URL.Headers["Authorization"] != "<REDACTED>" {
+			t.Errorf("Template URL Header Authorization not <REDACTED>")
+		}
+
+		if template.URL.Address != "https://example.com/ca.pem" {
+			t.Errorf("Template URL Address not https://example.com/ca.pem")
+		}
 	})
 }
+
+func TestConfigSecretTags(t *testing.T) {
+	test_helpers.AssertSecretTags(t, Config{})
+}
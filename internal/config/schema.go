@@ -0,0 +1,141 @@
+package config
+
+import (
+	"certalert/internal/certificates"
+	"maps"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// SchemaDraft is the JSON Schema dialect of the generated schema.
+const SchemaDraft = "https://json-schema.org/draft/2020-12/schema"
+
+// Schema represents the subset of JSON Schema used to describe the config file.
+type Schema struct {
+	Draft                string             `json:"$schema,omitempty"`
+	Title                string             `json:"title,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Enum                 []string           `json:"enum,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	PropertyNames        *Schema            `json:"propertyNames,omitempty"`
+	AdditionalProperties any                `json:"additionalProperties,omitempty"` // false or a *Schema
+	Items                *Schema            `json:"items,omitempty"`
+}
+
+// schemaEnums maps the path of a setting to the function returning its allowed values.
+// Items of lists are addressed with '[]', values of maps with '{}' and keys of maps with '{key}'.
+var schemaEnums = map[string]func() []string{
+	"certs[].type":                    func() []string { return slices.Sorted(slices.Values(certificates.FileExtensionsTypesSorted)) },
+	"certs[].source":                  func() []string { return slices.Sorted(maps.Keys(certificates.SourceToFetchFunction)) },
+	"certs[].filters.include[].field": func() []string { return certificates.FilterFields },
+	"certs[].filters.exclude[].field": func() []string { return certificates.FilterFields },
+	"policy.rules{key}":               func() []string { return certificates.PolicyRules },
+	"policy.rules{}":                  func() []string { return certificates.Severities },
+}
+
+// GenerateSchema generates the JSON Schema of the config file from the Config struct.
+//
+// The settings are named after the 'mapstructure' tags of the struct fields. Structs are closed, so
+// unknown settings are reported. The allowed values of settings like the certificate type are taken
+// from the registered types, sources, filter fields, policy rules and severities.
+//
+// Returns:
+//   - *Schema
+//     The JSON Schema of the config file.
+func GenerateSchema() *Schema {
+	schema := schemaFor(reflect.TypeOf(Config{}), "")
+	schema.Draft = SchemaDraft
+	schema.Title = "certalert config"
+	return schema
+}
+
+// schemaFor generates the schema of a Go type.
+//
+// Parameters:
+//   - t: reflect.Type
+//     The type to generate the schema for.
+//   - path: string
+//     The path of the setting, used to look up its allowed values in schemaEnums.
+//
+// Returns:
+//   - *Schema
+//     The schema of the type.
+func schemaFor(t reflect.Type, path string) *Schema {
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	// Certificates, their defaults and templates share the allowed values of their settings
+	if t == reflect.TypeOf(certificates.Certificate{}) {
+		path = "certs[]"
+	}
+
+	var schema *Schema
+	switch t.Kind() {
+	case reflect.Struct:
+		schema = &Schema{Type: "object", Properties: map[string]*Schema{}, AdditionalProperties: false}
+		for i := range t.NumField() {
+			field := t.Field(i)
+			name := strings.Split(field.Tag.Get("mapstructure"), ",")[0]
+			if !field.IsExported() || name == "-" {
+				continue
+			}
+			if name == "" {
+				name = field.Name
+			}
+			schema.Properties[name] = schemaFor(field.Type, joinSchemaPath(path, name))
+		}
+	case reflect.Slice:
+		schema = &Schema{Type: "array", Items: schemaFor(t.Elem(), path+"[]")}
+	case reflect.Map:
+		schema = &Schema{Type: "object", AdditionalProperties: schemaFor(t.Elem(), path+"{}")}
+		if enum, ok := schemaEnums[path+"{key}"]; ok {
+			schema.PropertyNames = &Schema{Enum: enum()}
+		}
+	case reflect.Bool:
+		schema = &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		schema = &Schema{Type: "integer"}
+	default:
+		schema = &Schema{Type: "string"}
+	}
+
+	if enum, ok := schemaEnums[path]; ok {
+		schema.Enum = enum()
+	}
+
+	return schema
+}
+
+// joinSchemaPath appends the name of a setting to the path of its parent.
+func joinSchemaPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+// property returns the schema of the named property, matched case-insensitively like the config file is read.
+//
+// Parameters:
+//   - name: string
+//     The name of the property.
+//
+// Returns:
+//   - string
+//     The name of the property as defined in the schema.
+//   - *Schema
+//     The schema of the property, nil if the property is not allowed.
+func (s *Schema) property(name string) (string, *Schema) {
+	for key, property := range s.Properties {
+		if strings.EqualFold(key, name) {
+			return key, property
+		}
+	}
+
+	if additional, ok := s.AdditionalProperties.(*Schema); ok {
+		return name, additional
+	}
+
+	return name, nil
+}
@@ -0,0 +1,49 @@
+package config
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenerateSchema(t *testing.T) {
+	schema := GenerateSchema()
+
+	assert.Equal(t, SchemaDraft, schema.Draft)
+	assert.Equal(t, "object", schema.Type)
+	assert.Equal(t, false, schema.AdditionalProperties)
+
+	t.Run("names properties after the mapstructure tags", func(t *testing.T) {
+		assert.Contains(t, schema.Properties, "autoReloadConfig")
+		assert.Equal(t, "string", schema.Properties["server"].Properties["listenAddress"].Type)
+		assert.Equal(t, "boolean", schema.Properties["certs"].Items.Properties["enabled"].Type)
+		assert.Equal(t, "integer", schema.Properties["policy"].Properties["minRSAKeyBits"].Type)
+	})
+
+	t.Run("lists allowed values", func(t *testing.T) {
+		cert := schema.Properties["certs"].Items
+		assert.Contains(t, cert.Properties["type"].Enum, "pem")
+		assert.Contains(t, cert.Properties["source"].Enum, "file")
+		assert.Equal(t, []string{"alias", "fingerprint", "issuer", "san", "subject"}, cert.Properties["filters"].Properties["include"].Items.Properties["field"].Enum)
+
+		rules := schema.Properties["policy"].Properties["rules"]
+		assert.Contains(t, rules.PropertyNames.Enum, "rsa-key-size")
+		assert.Equal(t, []string{"off", "info", "warning", "error"}, rules.AdditionalProperties.(*Schema).Enum)
+	})
+
+	t.Run("published schema is up to date", func(t *testing.T) {
+		published, err := os.ReadFile("../../certalert.schema.json")
+		if err != nil {
+			t.Fatalf("Failed to read published schema: %v", err)
+		}
+
+		generated, err := json.MarshalIndent(schema, "", "  ")
+		if err != nil {
+			t.Fatalf("Failed to marshal schema: %v", err)
+		}
+
+		assert.JSONEq(t, string(generated), string(published), "run 'certalert config schema > certalert.schema.json'")
+	})
+}
@@ -0,0 +1,171 @@
+package config
+
+import (
+	"certalert/internal/certificates"
+	"certalert/internal/metrics"
+	"certalert/internal/utils"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/viper"
+)
+
+// Snapshot represents a parsed configuration together with its redacted copy.
+//
+// A snapshot is never modified after it is created, so it can be read concurrently without locking.
+// A reload creates a new snapshot and swaps it in atomically.
+type Snapshot struct {
+	Config   Config    // Config is the parsed config with resolved variables.
+	Redacted Config    // Redacted is the config with sensitive data redacted, as exposed by the /config endpoint.
+	LoadedAt time.Time // LoadedAt is the time the snapshot was created.
+}
+
+var (
+	current   atomic.Pointer[Snapshot] // current is the active snapshot
+	reloadMu  sync.Mutex               // reloadMu serializes reloads, so an older file can't overwrite a newer one
+	flagsBase Config                   // flagsBase contains the settings of the command line flags, before the config file is read
+)
+
+// SetFlagSettings records the settings of the command line flags, before the config file is read into them.
+// A reload reads the config file into these settings, so it behaves like a fresh start: a setting removed
+// from the config file falls back to its flag or the default of the flag.
+//
+// Parameters:
+//   - c: Config
+//     The config holding the values of the command line flags.
+func SetFlagSettings(c Config) {
+	reloadMu.Lock()
+	defer reloadMu.Unlock()
+
+	flagsBase = flagSettings(c)
+}
+
+// Current returns the active config snapshot.
+//
+// Returns:
+//   - *Snapshot
+//     The active snapshot, nil if no snapshot was activated yet.
+func Current() *Snapshot {
+	return current.Load()
+}
+
+// Activate makes the snapshot the active config snapshot and records a successful load in the reload metrics.
+// The caches of sources which are not in the snapshot anymore are dropped.
+//
+// Parameters:
+//   - snapshot: *Snapshot
+//     The snapshot to activate.
+func Activate(snapshot *Snapshot) {
+	current.Store(snapshot)
+	certificates.PruneCaches(snapshot.Config.Certs)
+	metrics.ConfigLastReloadSuccessful.Set(1)
+	metrics.ConfigLastReloadSuccessTimestamp.Set(float64(snapshot.LoadedAt.Unix()))
+}
+
+// NewSnapshot parses and redacts a copy of the given config. The given config is not modified.
+//
+// Parameters:
+//   - raw: Config
+//     The config as read from the config file, with unresolved variables.
+//
+// Returns:
+//   - *Snapshot
+//     The new snapshot.
+//   - error
+//     An error if the config is invalid.
+func NewSnapshot(raw Config) (*Snapshot, error) {
+	var parsed Config
+	if err := utils.DeepCopy(raw, &parsed); err != nil {
+		return nil, fmt.Errorf("Unable to copy config: %s", err)
+	}
+	if err := parsed.Parse(); err != nil {
+		return nil, fmt.Errorf("Unable to parse config: %s", err)
+	}
+
+	var redacted Config
+	if err := utils.DeepCopy(raw, &redacted); err != nil {
+		return nil, fmt.Errorf("Unable to copy config: %s", err)
+	}
+	// The /config endpoint shows the certificates with their templates and defaults applied
+	redacted.expandCertificates()
+	if err := RedactConfig(&redacted); err != nil {
+		return nil, fmt.Errorf("Unable to redact config: %s", err)
+	}
+
+	return &Snapshot{Config: parsed, Redacted: redacted, LoadedAt: time.Now()}, nil
+}
+
+// Reload reads, parses and validates the config file and activates it on success.
+//
+// The new config is built next to the active one. If any step fails, the active snapshot keeps
+// serving and the error is returned. The config file is read into the settings of the command line
+// flags recorded by SetFlagSettings, like on startup, so settings removed from the config file fall
+// back to their flags instead of keeping the value of the active snapshot. The outcome is recorded
+// in the reload metrics.
+//
+// Parameters:
+//   - configPath: string
+//     The file path to the configuration file.
+//
+// Returns:
+//   - error
+//     An error if the config file can't be read or is invalid.
+func Reload(configPath string) error {
+	reloadMu.Lock()
+	defer reloadMu.Unlock()
+
+	raw := flagsBase
+	if active := Current(); active != nil {
+		// The version is set by the binary, not by a flag or the config file
+		raw.Version = active.Config.Version
+	}
+
+	snapshot, err := loadSnapshot(configPath, raw)
+	if err != nil {
+		metrics.ConfigLastReloadSuccessful.Set(0)
+		metrics.ConfigLastReloadFailureTimestamp.SetToCurrentTime()
+		return err
+	}
+
+	Activate(snapshot)
+	log.Info().Msgf("Configuration reloaded from '%s'", configPath)
+
+	return nil
+}
+
+// loadSnapshot reads the config file with a dedicated viper instance and creates a snapshot of it.
+//
+// Parameters:
+//   - configPath: string
+//     The file path to the configuration file.
+//   - raw: Config
+//     The settings the config file is read into.
+//
+// Returns:
+//   - *Snapshot
+//     The new snapshot.
+//   - error
+//     An error if the config file can't be read or is invalid.
+func loadSnapshot(configPath string, raw Config) (*Snapshot, error) {
+	v := viper.New()
+	v.AutomaticEnv()
+	if err := raw.readWith(v, configPath); err != nil {
+		return nil, err
+	}
+
+	return NewSnapshot(raw)
+}
+
+// flagSettings returns a config with the settings of the given config which can also be set by command line flags.
+func flagSettings(c Config) Config {
+	return Config{
+		Version:          c.Version,
+		AutoReloadConfig: c.AutoReloadConfig,
+		FailOnError:      c.FailOnError,
+		ConfigDir:        c.ConfigDir,
+		Server:           c.Server,
+	}
+}
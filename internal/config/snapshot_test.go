@@ -0,0 +1,202 @@
+package config
+
+import (
+	"certalert/internal/certificates"
+	"certalert/internal/metrics"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+const validSnapshotConfig = `
+certs:
+  - name: final
+    path: ../../tests/certs/pem/final.crt
+    type: pem
+    password: secret
+`
+
+// writeConfigFile writes the content to a config file in a temporary directory and returns its path.
+func writeConfigFile(t *testing.T, content string) string {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("Failed to write config file: %v", err)
+	}
+	return path
+}
+
+// activateConfigFile reads the config file, activates it as snapshot and resets the active snapshot after the test.
+func activateConfigFile(t *testing.T, path string) *Snapshot {
+	previous, previousFlags := Current(), flagsBase
+	t.Cleanup(func() {
+		current.Store(previous)
+		flagsBase = previousFlags
+	})
+
+	raw := Config{Server: Server{ListenAddress: ":9999"}}
+	SetFlagSettings(raw)
+	if err := raw.readWith(viper.New(), path); err != nil {
+		t.Fatalf("Failed to read config file: %v", err)
+	}
+
+	snapshot, err := NewSnapshot(raw)
+	if err != nil {
+		t.Fatalf("Failed to create snapshot: %v", err)
+	}
+	Activate(snapshot)
+
+	return snapshot
+}
+
+func TestNewSnapshot(t *testing.T) {
+	raw := Config{}
+	raw.Server.ListenAddress = ":9999"
+	raw.Certs = append(raw.Certs, certificates.Certificate{
+		Name:     "final",
+		Path:     "../../tests/certs/pem/final.crt",
+		Type:     "pem",
+		Password: "secret",
+	})
+
+	snapshot, err := NewSnapshot(raw)
+	assert.NoError(t, err)
+
+	assert.Equal(t, "secret", snapshot.Config.Certs[0].Password)
+	assert.Equal(t, "<REDACTED>", snapshot.Redacted.Certs[0].Password)
+
+	t.Run("does not modify the raw config", func(t *testing.T) {
+		assert.Equal(t, "secret", raw.Certs[0].Password)
+		assert.Equal(t, "final", raw.Certs[0].Name)
+	})
+
+	t.Run("invalid config", func(t *testing.T) {
+		invalid := Config{}
+		invalid.FailOnError = true
+		invalid.Server.ListenAddress = ":9999"
+		invalid.Certs = append(invalid.Certs, certificates.Certificate{
+			Name: "final",
+			Path: "../../tests/certs/pem/final.crt",
+			Type: "unknown",
+		})
+
+		_, err := NewSnapshot(invalid)
+		assert.ErrorContains(t, err, "Unable to parse config: ")
+	})
+}
+
+func TestReload(t *testing.T) {
+	t.Run("activates a valid config", func(t *testing.T) {
+		path := writeConfigFile(t, validSnapshotConfig)
+		initial := activateConfigFile(t, path)
+
+		updated := validSnapshotConfig + `
+  - name: chain
+    path: ../../tests/certs/pem/chain.pem
+    type: pem
+`
+		if err := os.WriteFile(path, []byte(updated), 0o644); err != nil {
+			t.Fatalf("Failed to write config file: %v", err)
+		}
+
+		err := Reload(path)
+		assert.NoError(t, err)
+
+		snapshot := Current()
+		assert.NotSame(t, initial, snapshot)
+		assert.Len(t, snapshot.Config.Certs, 2)
+		assert.Equal(t, "<REDACTED>", snapshot.Redacted.Certs[0].Password)
+		assert.Equal(t, ":9999", snapshot.Config.Server.ListenAddress, "flag settings must be carried over")
+		assert.Equal(t, float64(1), testutil.ToFloat64(metrics.ConfigLastReloadSuccessful))
+	})
+
+	t.Run("settings removed from the config file fall back to their flags", func(t *testing.T) {
+		path := writeConfigFile(t, "failOnError: true\nserver:\n  listenAddress: \":7777\"\n"+validSnapshotConfig)
+		initial := activateConfigFile(t, path)
+		assert.True(t, initial.Config.FailOnError)
+		assert.Equal(t, ":7777", initial.Config.Server.ListenAddress)
+
+		if err := os.WriteFile(path, []byte(validSnapshotConfig), 0o644); err != nil {
+			t.Fatalf("Failed to write config file: %v", err)
+		}
+
+		err := Reload(path)
+		assert.NoError(t, err)
+		assert.False(t, Current().Config.FailOnError)
+		assert.Equal(t, ":9999", Current().Config.Server.ListenAddress)
+	})
+
+	testCases := []struct {
+		Name    string
+		Content string
+		Error   string
+	}{
+		{
+			Name:    "invalid YAML",
+			Content: "certs: [",
+			Error:   "Failed to read config file: ",
+		},
+		{
+			Name: "invalid certificate",
+			Content: `
+failOnError: true
+certs:
+  - name: final
+    path: ../../tests/certs/pem/final.crt
+    type: unknown
+`,
+			Error: "Unable to parse config: ",
+		},
+		{
+			Name: "invalid policy",
+			Content: validSnapshotConfig + `
+policy:
+  rules:
+    rsa-key-size: fatal
+`,
+			Error: "Unable to parse config: Invalid policy. ",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run("keeps the previous config on "+tc.Name, func(t *testing.T) {
+			path := writeConfigFile(t, validSnapshotConfig)
+			initial := activateConfigFile(t, path)
+
+			if err := os.WriteFile(path, []byte(tc.Content), 0o644); err != nil {
+				t.Fatalf("Failed to write config file: %v", err)
+			}
+
+			err := Reload(path)
+			assert.ErrorContains(t, err, tc.Error)
+			assert.Same(t, initial, Current())
+			assert.Equal(t, float64(0), testutil.ToFloat64(metrics.ConfigLastReloadSuccessful))
+			assert.NotZero(t, testutil.ToFloat64(metrics.ConfigLastReloadFailureTimestamp))
+		})
+	}
+
+	t.Run("concurrent reads while reloading", func(t *testing.T) {
+		path := writeConfigFile(t, validSnapshotConfig)
+		activateConfigFile(t, path)
+
+		var wg sync.WaitGroup
+		for i := 0; i < 4; i++ {
+			wg.Add(2)
+			go func() {
+				defer wg.Done()
+				assert.NoError(t, Reload(path))
+			}()
+			go func() {
+				defer wg.Done()
+				snapshot := Current()
+				assert.Len(t, snapshot.Config.Certs, 1)
+				assert.Equal(t, "<REDACTED>", snapshot.Redacted.Certs[0].Password)
+			}()
+		}
+		wg.Wait()
+	})
+}
@@ -5,20 +5,29 @@ import (
 	"fmt"
 )
 
-// Config represents the config file
+// App represents the config file and the command line flags. It is used by the commands, the server
+// reads the active Snapshot instead.
 var App Config
 
-// AppCopy represents the config file with sensitive data redacted
-var AppCopy Config
-
 // Config represents the config file
 type Config struct {
-	Version          string                     `mapstructure:"version"`
-	AutoReloadConfig bool                       `mapstructure:"autoReloadConfig,omitempty" yaml:"autoReloadConfig,omitempty"`
-	FailOnError      bool                       `mapstructure:"failOnError,omitempty" yaml:"failOnError,omitempty"`
-	Server           Server                     `mapstructure:"server,omitempty" yaml:"server,omitempty"`
-	Pushgateway      Pushgateway                `mapstructure:"pushgateway,omitempty" yaml:"pushgateway,omitempty"`
-	Certs            []certificates.Certificate `mapstructure:"certs"`
+	Version          string                              `mapstructure:"version"`
+	AutoReloadConfig bool                                `mapstructure:"autoReloadConfig,omitempty" yaml:"autoReloadConfig,omitempty"`
+	FailOnError      bool                                `mapstructure:"failOnError,omitempty" yaml:"failOnError,omitempty"`
+	Dedup            bool                                `mapstructure:"dedup,omitempty" yaml:"dedup,omitempty"`
+	Server           Server                              `mapstructure:"server,omitempty" yaml:"server,omitempty"`
+	Healthz          Healthz                             `mapstructure:"healthz,omitempty" yaml:"healthz,omitempty"`
+	Pushgateway      Pushgateway                         `mapstructure:"pushgateway,omitempty" yaml:"pushgateway,omitempty"`
+	Policy           certificates.Policy                 `mapstructure:"policy,omitempty" yaml:"policy,omitempty"`
+	Defaults         certificates.Certificate            `mapstructure:"defaults,omitempty" yaml:"defaults,omitempty"`
+	Templates        map[string]certificates.Certificate `mapstructure:"templates,omitempty" yaml:"templates,omitempty"`
+	Certs            []certificates.Certificate          `mapstructure:"certs"`
+	Include          []string                            `mapstructure:"include,omitempty" yaml:"include,omitempty"`
+	Labels           map[string]string                   `mapstructure:"labels,omitempty" yaml:"labels,omitempty"`
+	Thresholds       certificates.Thresholds             `mapstructure:"thresholds,omitempty" yaml:"thresholds,omitempty"`
+
+	ConfigDir string   `mapstructure:"-" yaml:"-"` // ConfigDir is the directory with config fragments, set by the --config-dir flag
+	Files     []string `mapstructure:"-" yaml:"-"` // Files are the config file and the fragments merged into it, in this order
 }
 
 // Server represents the server config
@@ -26,6 +35,11 @@ type Server struct {
 	ListenAddress string `mapstructure:"listenAddress,omitempty" yaml:"listenAddress,omitempty"`
 }
 
+// Healthz represents the config of the /healthz endpoint
+type Healthz struct {
+	FailOnNotYetValid bool `mapstructure:"failOnNotYetValid,omitempty" yaml:"failOnNotYetValid,omitempty"`
+}
+
 // Pushgateway represents the pushgateway config
 type Pushgateway struct {
 	Address            string `mapstructure:"address,omitempty" yaml:"address,omitempty"`
@@ -51,11 +65,11 @@ func (a *Auth) Validate() error {
 
 // Basic represents the pushgateway basic auth config
 type Basic struct {
-	Password string `mapstructure:"password,omitempty" yaml:"password,omitempty"`
-	Username string `mapstructure:"username,omitempty" yaml:"username,omitempty"`
+	Password string `mapstructure:"password,omitempty" yaml:"password,omitempty" secret:"true"`
+	Username string `mapstructure:"username,omitempty" yaml:"username,omitempty" secret:"true"`
 }
 
 // Bearer represents the pushgateway bearer auth config
 type Bearer struct {
-	Token string `mapstructure:"token,omitempty" yaml:"token,omitempty"`
+	Token string `mapstructure:"token,omitempty" yaml:"token,omitempty" secret:"true"`
 }
@@ -0,0 +1,116 @@
+package config
+
+import (
+	"certalert/internal/certificates"
+	"certalert/internal/utils"
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// notInherited contains the fields of a certificate config which are not taken from templates and defaults.
+var notInherited = []string{"Name", "Extends"}
+
+// expandCertificate applies the template the certificate extends and the defaults to the certificate.
+//
+// Settings of the certificate take precedence over the settings of its template, which take precedence
+// over the defaults. A template can extend another template. The settings are merged per field, nested
+// blocks like 'url' or 'filters' are taken as a whole. The name is never inherited.
+//
+// Parameters:
+//   - cert: certificates.Certificate
+//     The certificate config to expand.
+//
+// Returns:
+//   - certificates.Certificate
+//     The expanded certificate config.
+//   - error
+//     An error if a template doesn't exist or the templates extend each other in a cycle.
+func (c *Config) expandCertificate(cert certificates.Certificate) (certificates.Certificate, error) {
+	visited := make(map[string]bool)
+	for name := cert.Extends; name != ""; {
+		key, template, found := c.template(name)
+		if !found {
+			return cert, fmt.Errorf("Unknown template '%s'. Must be one of '%s'.", name, strings.Join(slices.Sorted(maps.Keys(c.Templates)), "', '"))
+		}
+		if visited[key] {
+			return cert, fmt.Errorf("Template '%s' extends itself.", name)
+		}
+		visited[key] = true
+
+		if err := inherit(&cert, template); err != nil {
+			return cert, err
+		}
+		name = template.Extends
+	}
+
+	if err := inherit(&cert, c.Defaults); err != nil {
+		return cert, err
+	}
+
+	return cert, nil
+}
+
+// expandCertificates expands all certificate configs in place. Certificates which can't be expanded are kept as is,
+// Parse reports them.
+func (c *Config) expandCertificates() {
+	for idx, cert := range c.Certs {
+		if expanded, err := c.expandCertificate(cert); err == nil {
+			c.Certs[idx] = expanded
+		}
+	}
+}
+
+// template returns the template with the given name. The name is matched case-insensitively, as the keys of the
+// templates are lowercased when the config file is read.
+//
+// Returns:
+//   - string
+//     The key of the template.
+//   - certificates.Certificate
+//     The template.
+//   - bool
+//     True if the template exists.
+func (c *Config) template(name string) (string, certificates.Certificate, bool) {
+	for key, template := range c.Templates {
+		if strings.EqualFold(key, name) {
+			return key, template, true
+		}
+	}
+	return "", certificates.Certificate{}, false
+}
+
+// inherit sets the unset fields of the certificate config to a copy of the fields of the parent.
+//
+// Parameters:
+//   - cert: *certificates.Certificate
+//     The certificate config to complete.
+//   - parent: certificates.Certificate
+//     The template or the defaults.
+//
+// Returns:
+//   - error
+//     An error if the parent can't be copied.
+func inherit(cert *certificates.Certificate, parent certificates.Certificate) error {
+	// Copy the parent, so certificates don't share the nested blocks of a template
+	var copied certificates.Certificate
+	if err := utils.DeepCopy(parent, &copied); err != nil {
+		return fmt.Errorf("Unable to copy template: %v", err)
+	}
+
+	dst := reflect.ValueOf(cert).Elem()
+	src := reflect.ValueOf(copied)
+	for i := range dst.NumField() {
+		field := dst.Type().Field(i)
+		if !field.IsExported() || slices.Contains(notInherited, field.Name) {
+			continue
+		}
+		if dst.Field(i).IsZero() {
+			dst.Field(i).Set(src.Field(i))
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,174 @@
+package config
+
+import (
+	"certalert/internal/certificates"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExpandCertificate(t *testing.T) {
+	disabled := false
+	cfg := Config{
+		Defaults: certificates.Certificate{
+			Password: "env:DEFAULT_PASSWORD",
+			Type:     "pem",
+			Enabled:  &disabled,
+		},
+		Templates: map[string]certificates.Certificate{
+			"internalca": {
+				Password: "env:INTERNAL_CA_PASSWORD",
+				Filters:  &certificates.Filters{OnlyLeaf: true},
+			},
+			"jks": {
+				Extends: "internalCA",
+				Type:    "jks",
+			},
+			"loop-a": {Extends: "loop-b"},
+			"loop-b": {Extends: "loop-a"},
+		},
+	}
+
+	testCases := []struct {
+		Name          string
+		Cert          certificates.Certificate
+		Expected      certificates.Certificate
+		ExpectedError string
+	}{
+		{
+			Name:     "defaults",
+			Cert:     certificates.Certificate{Name: "plain", Path: "plain.pem"},
+			Expected: certificates.Certificate{Name: "plain", Path: "plain.pem", Password: "env:DEFAULT_PASSWORD", Type: "pem", Enabled: &disabled},
+		},
+		{
+			Name: "template chain before defaults",
+			Cert: certificates.Certificate{Name: "store", Path: "store.jks", Extends: "jks"},
+			Expected: certificates.Certificate{
+				Name:     "store",
+				Path:     "store.jks",
+				Extends:  "jks",
+				Password: "env:INTERNAL_CA_PASSWORD",
+				Type:     "jks",
+				Enabled:  &disabled,
+				Filters:  &certificates.Filters{OnlyLeaf: true},
+			},
+		},
+		{
+			Name: "certificate settings take precedence",
+			Cert: certificates.Certificate{Name: "own", Path: "own.p12", Extends: "JKS", Type: "p12", Password: "secret"},
+			Expected: certificates.Certificate{
+				Name:     "own",
+				Path:     "own.p12",
+				Extends:  "JKS",
+				Password: "secret",
+				Type:     "p12",
+				Enabled:  &disabled,
+				Filters:  &certificates.Filters{OnlyLeaf: true},
+			},
+		},
+		{
+			Name:          "unknown template",
+			Cert:          certificates.Certificate{Name: "unknown", Extends: "missing"},
+			ExpectedError: "Unknown template 'missing'. Must be one of 'internalca', 'jks', 'loop-a', 'loop-b'.",
+		},
+		{
+			Name:          "cyclic templates",
+			Cert:          certificates.Certificate{Name: "loop", Extends: "loop-a"},
+			ExpectedError: "Template 'loop-a' extends itself.",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			expanded, err := cfg.expandCertificate(tc.Cert)
+			if tc.ExpectedError != "" {
+				assert.EqualError(t, err, tc.ExpectedError)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.Expected, expanded)
+		})
+	}
+
+	t.Run("does not share nested blocks", func(t *testing.T) {
+		first, _ := cfg.expandCertificate(certificates.Certificate{Name: "first", Extends: "internalca"})
+		first.Filters.OnlyLeaf = false
+
+		assert.True(t, cfg.Templates["internalca"].Filters.OnlyLeaf)
+	})
+}
+
+func TestParseTemplates(t *testing.T) {
+	t.Setenv("TEMPLATE_PASSWORD", "password")
+
+	cfg := Config{
+		FailOnError: true,
+		Server:      Server{ListenAddress: ":8080"},
+		Defaults:    certificates.Certificate{Password: "env:TEMPLATE_PASSWORD"},
+		Templates: map[string]certificates.Certificate{
+			"jks": {Type: "jks"},
+		},
+		Certs: []certificates.Certificate{
+			{Name: "regular", Path: "../../tests/certs/jks/regular.jks", Extends: "jks"},
+			{Path: "../../tests/certs/pem/final.crt"},
+		},
+	}
+
+	snapshot, err := NewSnapshot(cfg)
+	assert.NoError(t, err)
+
+	t.Run("applies templates before type inference and password resolution", func(t *testing.T) {
+		assert.Equal(t, "jks", snapshot.Config.Certs[0].Type)
+		assert.Equal(t, "password", snapshot.Config.Certs[0].Password)
+		assert.Equal(t, "pem", snapshot.Config.Certs[1].Type)
+		assert.Equal(t, "password", snapshot.Config.Certs[1].Password)
+	})
+
+	t.Run("shows the expanded certificates redacted", func(t *testing.T) {
+		assert.Equal(t, "jks", snapshot.Redacted.Certs[0].Type)
+		assert.Equal(t, "env:TEMPLATE_PASSWORD", snapshot.Redacted.Certs[0].Password)
+		assert.Equal(t, "env:TEMPLATE_PASSWORD", snapshot.Redacted.Certs[1].Password)
+	})
+
+	t.Run("disables certificates by template", func(t *testing.T) {
+		disabled := false
+		cfg := Config{
+			FailOnError: true,
+			Templates: map[string]certificates.Certificate{
+				"off": {Enabled: &disabled},
+			},
+			Certs: []certificates.Certificate{
+				{Name: "final", Path: "../../tests/certs/pem/final.crt"},
+				{Name: "missing", Path: "../../tests/certs/pem/missing.pem", Extends: "off"},
+			},
+		}
+
+		assert.NoError(t, cfg.parseCertificatesConfig())
+		assert.Equal(t, &disabled, cfg.Certs[1].Enabled)
+
+		certInfos, err := certificates.Process(cfg.Certs, true)
+		assert.NoError(t, err)
+		assert.Len(t, certInfos, 1)
+		assert.Equal(t, "final", certInfos[0].Name)
+	})
+
+	t.Run("unknown template", func(t *testing.T) {
+		cfg.Certs = []certificates.Certificate{{Name: "regular", Path: "../../tests/certs/jks/regular.jks", Extends: "p12"}}
+
+		err := cfg.Parse()
+		assert.EqualError(t, err, "Certificate 'regular' can't be expanded. Unknown template 'p12'. Must be one of 'jks'.")
+	})
+}
+
+func TestRedactTemplates(t *testing.T) {
+	cfg := &Config{
+		Defaults: certificates.Certificate{Password: "secret"},
+		Templates: map[string]certificates.Certificate{
+			"vault": {Vault: &certificates.VaultSource{Auth: certificates.VaultAuth{Token: "token"}}},
+		},
+	}
+
+	assert.NoError(t, RedactConfig(cfg))
+	assert.Equal(t, "<REDACTED>", cfg.Defaults.Password)
+	assert.Equal(t, "<REDACTED>", cfg.Templates["vault"].Vault.Auth.Token)
+}
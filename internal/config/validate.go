@@ -0,0 +1,630 @@
+package config
+
+import (
+	"bytes"
+	"certalert/internal/certificates"
+	"certalert/internal/resolve"
+	"certalert/internal/utils"
+	"cmp"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/viper"
+	"gopkg.in/yaml.v3"
+)
+
+// ValidationFinding represents a problem found in a config file.
+type ValidationFinding struct {
+	File    string // File is the config file containing the problem, the config file or one of its fragments.
+	Line    int    // Line is the line of the setting in the config file, 0 if unknown.
+	Path    string // Path is the path of the setting, e.g. 'certs[0].type'.
+	Message string // Message describes the problem.
+}
+
+// String returns the finding as 'line 12: certs[0].type: message'. The line and the path are omitted if unknown.
+func (f ValidationFinding) String() string {
+	var b strings.Builder
+	if f.Line > 0 {
+		fmt.Fprintf(&b, "line %d: ", f.Line)
+	}
+	if f.Path != "" {
+		fmt.Fprintf(&b, "%s: ", f.Path)
+	}
+	b.WriteString(f.Message)
+	return b.String()
+}
+
+// ValidateOptions represents the options of the validation of a config file.
+type ValidateOptions struct {
+	ConfigDir   string // ConfigDir is the directory with config fragments, empty if not set.
+	RunCommands bool   // RunCommands runs the commands of 'exec:' references instead of only checking that they exist.
+}
+
+// configDocument is a config file read for the validation.
+type configDocument struct {
+	file string     // file is the path of the config file
+	root *yaml.Node // root is the root node of the config file, nil if the file is empty
+}
+
+// certificateName is the location of the first certificate with a name.
+type certificateName struct {
+	file string // file is the config file of the certificate
+	line int    // line is the line of the certificate
+}
+
+// validator collects the findings while validating a config file and its fragments.
+type validator struct {
+	options   ValidateOptions
+	file      string // file is the config file being validated
+	findings  []ValidationFinding
+	names     map[string]certificateName // names contains the certificate names of all config files
+	defaults  []*yaml.Node               // defaults are the nodes of the certificate defaults, in the order the files are merged
+	templates []*yaml.Node               // templates are the nodes of the certificate templates, in the order the files are merged
+}
+
+// add adds a finding for the given node of the config file being validated.
+func (v *validator) add(node *yaml.Node, path, format string, args ...any) {
+	v.findings = append(v.findings, ValidationFinding{File: v.file, Line: node.Line, Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+// Validate checks a config file without activating it.
+//
+// The file and its fragments, the files of 'include' and of the config dir, are checked against the
+// JSON Schema returned by GenerateSchema. Unknown settings, values of
+// the wrong type and values not allowed by an enum are reported. Afterwards the semantic checks run:
+// duplicate certificate names, certificate types which can't be inferred, unresolvable references like
+// 'env:' or 'file:', missing certificate files and missing included files. Certificate names must be
+// unique across all files and the templates and defaults of all files apply, as the files are merged
+// when the config is read. Unlike Parse, Validate doesn't stop at the first
+// problem and has no side effects. The commands of 'exec:' references are only checked for an existing
+// executable, unless RunCommands is set.
+//
+// YAML and JSON files are validated with line numbers. Other formats like TOML are read with viper
+// and validated without line numbers.
+//
+// Parameters:
+//   - configPath: string
+//     The file path to the configuration file.
+//   - options: ValidateOptions
+//     The options of the validation.
+//
+// Returns:
+//   - []ValidationFinding
+//     All problems found, sorted by line. Empty if the config file is valid.
+//   - error
+//     An error if the config file can't be read.
+func Validate(configPath string, options ValidateOptions) ([]ValidationFinding, error) {
+	root, err := readNode(configPath)
+	v := &validator{options: options, file: configPath, names: make(map[string]certificateName)}
+	if syntaxErr, ok := err.(*syntaxError); ok {
+		v.findings = append(v.findings, ValidationFinding{File: configPath, Line: syntaxErr.line, Message: syntaxErr.message})
+		return v.findings, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	documents, files := v.readFragments(configPath, root)
+	documents = append([]configDocument{{file: configPath, root: root}}, documents...)
+
+	for _, doc := range documents {
+		v.defaults = append(v.defaults, mappingValue(doc.root, "defaults"))
+		v.templates = append(v.templates, mappingValue(doc.root, "templates"))
+	}
+
+	for _, doc := range documents {
+		if doc.root == nil {
+			continue
+		}
+		v.file = doc.file
+		v.validateNode(GenerateSchema(), doc.root, "")
+		v.validateCertificates(doc.root)
+	}
+
+	// Findings are sorted by the order the files are merged, then by line
+	order := map[string]int{configPath: -1}
+	for idx, file := range files {
+		order[file] = idx
+	}
+	slices.SortStableFunc(v.findings, func(a, b ValidationFinding) int {
+		return cmp.Or(cmp.Compare(order[a.File], order[b.File]), cmp.Compare(a.Line, b.Line))
+	})
+
+	return v.findings, nil
+}
+
+// readFragments reads the fragments of the config file, the files of 'include' and of the config dir.
+// Missing included files, an unreadable config dir and fragments which can't be read are reported as findings.
+//
+// Parameters:
+//   - configPath: string
+//     The file path to the configuration file.
+//   - root: *yaml.Node
+//     The root node of the config file, may be nil.
+//
+// Returns:
+//   - []configDocument
+//     The fragments which could be read, in the order they are merged.
+//   - []string
+//     The paths of all fragments, in the order they are merged.
+func (v *validator) readFragments(configPath string, root *yaml.Node) ([]configDocument, []string) {
+	var files []string
+	seen := make(map[string]bool)
+	add := func(matches []string) {
+		for _, file := range matches {
+			if !seen[file] {
+				seen[file] = true
+				files = append(files, file)
+			}
+		}
+	}
+
+	if include := mappingValue(root, "include"); include != nil && include.Kind == yaml.SequenceNode {
+		for idx, patternNode := range include.Content {
+			patternNode = resolveAlias(patternNode)
+			if patternNode.Kind != yaml.ScalarNode {
+				continue
+			}
+			matches, err := fragmentFiles(configPath, []string{patternNode.Value}, "")
+			if err != nil {
+				v.add(patternNode, fmt.Sprintf("include[%d]", idx), "%v", err)
+				continue
+			}
+			add(matches)
+		}
+	}
+
+	if v.options.ConfigDir != "" {
+		matches, err := fragmentFiles(configPath, nil, v.options.ConfigDir)
+		if err != nil {
+			v.findings = append(v.findings, ValidationFinding{File: configPath, Message: err.Error()})
+		}
+		add(matches)
+	}
+
+	var documents []configDocument
+	for _, file := range files {
+		root, err := readNode(file)
+		if syntaxErr, ok := err.(*syntaxError); ok {
+			v.findings = append(v.findings, ValidationFinding{File: file, Line: syntaxErr.line, Message: syntaxErr.message})
+			continue
+		}
+		if err != nil {
+			v.findings = append(v.findings, ValidationFinding{File: file, Message: err.Error()})
+			continue
+		}
+		documents = append(documents, configDocument{file: file, root: root})
+	}
+
+	return documents, files
+}
+
+// readNode reads the config file into a YAML node tree.
+//
+// Parameters:
+//   - configPath: string
+//     The file path to the configuration file.
+//
+// Returns:
+//   - *yaml.Node
+//     The root node of the config file, nil if the file is empty.
+//   - error
+//     An error if the file can't be read. A syntax error is returned as *syntaxError.
+func readNode(configPath string) (*yaml.Node, error) {
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read config file: %v", err)
+	}
+
+	var doc yaml.Node
+	switch strings.ToLower(strings.TrimPrefix(filepath.Ext(configPath), ".")) {
+	case "yaml", "yml", "json":
+		if err := yaml.NewDecoder(bytes.NewReader(data)).Decode(&doc); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil, nil
+			}
+			return nil, newSyntaxError(err)
+		}
+	default:
+		v := viper.New()
+		v.SetConfigFile(configPath)
+		if err := v.ReadInConfig(); err != nil {
+			return nil, fmt.Errorf("Failed to read config file: %v", err)
+		}
+		if err := doc.Encode(v.AllSettings()); err != nil {
+			return nil, fmt.Errorf("Failed to parse config file: %v", err)
+		}
+		return &doc, nil
+	}
+
+	if doc.Kind == yaml.DocumentNode && len(doc.Content) > 0 {
+		return resolveAlias(doc.Content[0]), nil
+	}
+	return &doc, nil
+}
+
+// syntaxError represents a syntax error in a YAML or JSON config file.
+type syntaxError struct {
+	line    int    // line is the line of the error, 0 if unknown
+	message string // message describes the error
+}
+
+func (e *syntaxError) Error() string {
+	return e.message
+}
+
+// syntaxErrorLine matches the line prefix of the errors of the YAML parser, e.g. 'yaml: line 3: did not find expected key'.
+var syntaxErrorLine = regexp.MustCompile(`^yaml: line (\d+): (.*)$`)
+
+// newSyntaxError converts an error of the YAML parser into a syntaxError.
+func newSyntaxError(err error) *syntaxError {
+	match := syntaxErrorLine.FindStringSubmatch(err.Error())
+	if match == nil {
+		return &syntaxError{message: fmt.Sprintf("Invalid syntax. %v", err)}
+	}
+	line, _ := strconv.Atoi(match[1])
+	return &syntaxError{line: line, message: fmt.Sprintf("Invalid syntax. %s", match[2])}
+}
+
+// resolveAlias returns the node an alias points to, or the node itself if it isn't an alias.
+func resolveAlias(node *yaml.Node) *yaml.Node {
+	for node.Kind == yaml.AliasNode && node.Alias != nil {
+		node = node.Alias
+	}
+	return node
+}
+
+// isNull reports if the node is an empty value, which leaves the setting at its default.
+func isNull(node *yaml.Node) bool {
+	return node.Kind == yaml.ScalarNode && node.Tag == "!!null"
+}
+
+// validateNode checks a node against its schema.
+//
+// Parameters:
+//   - schema: *Schema
+//     The schema of the setting.
+//   - node: *yaml.Node
+//     The node of the setting.
+//   - path: string
+//     The path of the setting.
+func (v *validator) validateNode(schema *Schema, node *yaml.Node, path string) {
+	node = resolveAlias(node)
+	if isNull(node) {
+		return
+	}
+
+	switch schema.Type {
+	case "object":
+		if node.Kind != yaml.MappingNode {
+			v.add(node, path, "Must be a map.")
+			return
+		}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			keyNode, valueNode := node.Content[i], node.Content[i+1]
+			if keyNode.Tag == "!!merge" {
+				v.validateNode(schema, valueNode, path)
+				continue
+			}
+
+			name, property := schema.property(keyNode.Value)
+			if property == nil {
+				v.add(keyNode, path, "Unknown setting '%s'.", keyNode.Value)
+				continue
+			}
+			if schema.PropertyNames != nil {
+				v.validateEnum(schema.PropertyNames, keyNode, joinSchemaPath(path, keyNode.Value))
+			}
+			v.validateNode(property, valueNode, joinSchemaPath(path, name))
+		}
+	case "array":
+		if node.Kind != yaml.SequenceNode {
+			v.add(node, path, "Must be a list.")
+			return
+		}
+		for i, item := range node.Content {
+			v.validateNode(schema.Items, item, fmt.Sprintf("%s[%d]", path, i))
+		}
+	default:
+		if node.Kind != yaml.ScalarNode {
+			v.add(node, path, "Must be a %s.", schema.Type)
+			return
+		}
+		if !v.validateScalar(schema, node, path) {
+			return
+		}
+		v.validateEnum(schema, node, path)
+	}
+}
+
+// validateScalar checks if a scalar node can be decoded into the type of the schema.
+// Like viper, strings are accepted for booleans and integers as long as they can be converted.
+//
+// Returns:
+//   - bool
+//     True if the value is valid.
+func (v *validator) validateScalar(schema *Schema, node *yaml.Node, path string) bool {
+	switch schema.Type {
+	case "boolean":
+		if _, err := strconv.ParseBool(node.Value); err != nil {
+			v.add(node, path, "Invalid boolean '%s'.", node.Value)
+			return false
+		}
+	case "integer":
+		if _, err := strconv.Atoi(node.Value); err != nil {
+			v.add(node, path, "Invalid integer '%s'.", node.Value)
+			return false
+		}
+	}
+	return true
+}
+
+// validateEnum checks if the value of a scalar node is one of the allowed values of the schema.
+func (v *validator) validateEnum(schema *Schema, node *yaml.Node, path string) {
+	if len(schema.Enum) == 0 || slices.Contains(schema.Enum, node.Value) {
+		return
+	}
+	v.add(node, path, "Invalid value '%s'. Must be one of '%s'.", node.Value, strings.Join(schema.Enum, "', '"))
+}
+
+// validateCertificates runs the semantic checks of the certificates.
+//
+// Parameters:
+//   - root: *yaml.Node
+//     The root node of the config file.
+func (v *validator) validateCertificates(root *yaml.Node) {
+	v.validateReferences(root, "")
+
+	v.validateLabels(mappingValue(root, "labels"), "labels")
+	v.validateThresholds(mappingValue(root, "thresholds"), "thresholds")
+
+	certs := mappingValue(root, "certs")
+	if certs == nil || certs.Kind != yaml.SequenceNode {
+		return
+	}
+
+	for idx, certNode := range certs.Content {
+		certNode = resolveAlias(certNode)
+		if certNode.Kind != yaml.MappingNode {
+			continue
+		}
+		path := fmt.Sprintf("certs[%d]", idx)
+
+		name := scalarValue(certNode, "name")
+		v.validateExtends(certNode, path)
+		v.validateLabels(mappingValue(certNode, "labels"), path+".labels")
+		v.validateThresholds(mappingValue(certNode, "thresholds"), path+".thresholds")
+
+		certPath, pathNode := v.certificateValue(certNode, "path")
+		if name == "" && certPath != "" {
+			name = certificateNameFromPath(certPath)
+		}
+		if name != "" {
+			if first, found := v.names[name]; !found {
+				v.names[name] = certificateName{file: v.file, line: certNode.Line}
+			} else if first.file == v.file {
+				v.add(certNode, path, "Certificate name '%s' is already used by the certificate on line %d.", name, first.line)
+			} else {
+				v.add(certNode, path, "Certificate name '%s' is already used by the certificate in '%s' on line %d.", name, first.file, first.line)
+			}
+		}
+
+		if enabled, _ := v.certificateValue(certNode, "enabled"); enabled != "" {
+			if enabled, err := strconv.ParseBool(enabled); err == nil && !enabled {
+				continue
+			}
+		}
+
+		source, _ := v.certificateValue(certNode, "source")
+		if source == "" {
+			source = certificates.DefaultSource
+		}
+		if content, _ := v.certificateValue(certNode, "content"); !slices.Contains(certificates.SourcesWithLocalPath, source) || content != "" {
+			continue
+		}
+
+		if certPath == "" {
+			v.add(certNode, path, "Certificate '%s' has no 'path' defined.", name)
+			continue
+		}
+		if err := utils.CheckFileAccessibility(certPath); err != nil {
+			v.add(pathNode, path+".path", "Certificate '%s' is not accessible. %v", name, err)
+		}
+
+		if certType, _ := v.certificateValue(certNode, "type"); certType == "" && source == certificates.DefaultSource {
+			ext := strings.TrimPrefix(filepath.Ext(certPath), ".")
+			if _, ok := certificates.FileExtensionsToType[ext]; !ok {
+				v.add(pathNode, path+".type", "Certificate '%s' has no 'type' defined and it can't be inferred from the file extension '.%s'.", name, ext)
+			}
+		}
+	}
+}
+
+// validateLabels checks the names of custom labels against the Prometheus naming rules.
+//
+// Parameters:
+//   - node: *yaml.Node
+//     The node of the labels, may be nil.
+//   - path: string
+//     The path of the labels.
+func (v *validator) validateLabels(node *yaml.Node, path string) {
+	if node == nil {
+		return
+	}
+	node = resolveAlias(node)
+	if node.Kind != yaml.MappingNode {
+		return
+	}
+
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		keyNode := node.Content[i]
+		if err := certificates.ValidateLabels(map[string]string{keyNode.Value: ""}); err != nil {
+			v.add(keyNode, joinSchemaPath(path, keyNode.Value), "%v", err)
+		}
+	}
+}
+
+// validateThresholds checks if the warning and critical thresholds can be parsed.
+//
+// Parameters:
+//   - node: *yaml.Node
+//     The node of the thresholds, may be nil.
+//   - path: string
+//     The path of the thresholds.
+func (v *validator) validateThresholds(node *yaml.Node, path string) {
+	for _, key := range []string{"warning", "critical"} {
+		valueNode := mappingValue(node, key)
+		if valueNode == nil || valueNode.Kind != yaml.ScalarNode {
+			continue
+		}
+		if _, err := certificates.ParseThreshold(valueNode.Value); err != nil {
+			v.add(valueNode, joinSchemaPath(path, key), "%v", err)
+		}
+	}
+}
+
+// certificateValue returns the effective value of a scalar setting of a certificate. Like Parse, the value is
+// taken from the certificate, the templates it extends or the defaults, in this order. The defaults of the
+// file merged last win.
+//
+// Parameters:
+//   - certNode: *yaml.Node
+//     The node of the certificate.
+//   - key: string
+//     The name of the setting.
+//
+// Returns:
+//   - string
+//     The effective value, empty if the setting is not set.
+//   - *yaml.Node
+//     The node defining the value, the certificate node if the setting is not set.
+func (v *validator) certificateValue(certNode *yaml.Node, key string) (string, *yaml.Node) {
+	visited := make(map[*yaml.Node]bool)
+	for node := certNode; node != nil && !visited[node]; node = v.template(scalarValue(node, "extends")) {
+		visited[node] = true
+		if value := scalarValue(node, key); value != "" {
+			return value, mappingValue(node, key)
+		}
+	}
+
+	for _, defaults := range slices.Backward(v.defaults) {
+		if value := scalarValue(defaults, key); value != "" {
+			return value, mappingValue(defaults, key)
+		}
+	}
+	return "", certNode
+}
+
+// template returns the node of a certificate template, nil if it is not defined. Like viper merges the
+// files, the template of the file merged last wins.
+func (v *validator) template(name string) *yaml.Node {
+	if name == "" {
+		return nil
+	}
+	for _, templates := range slices.Backward(v.templates) {
+		if template := mappingValue(templates, name); template != nil {
+			return template
+		}
+	}
+	return nil
+}
+
+// validateExtends checks if the templates a certificate extends exist and don't extend each other in a cycle.
+//
+// Parameters:
+//   - certNode: *yaml.Node
+//     The node of the certificate.
+//   - path: string
+//     The path of the certificate.
+func (v *validator) validateExtends(certNode *yaml.Node, path string) {
+	visited := make(map[*yaml.Node]bool)
+	for node := certNode; !visited[node]; {
+		visited[node] = true
+
+		extends := scalarValue(node, "extends")
+		if extends == "" {
+			return
+		}
+
+		template := v.template(extends)
+		switch {
+		case template == nil:
+			v.add(mappingValue(node, "extends"), path+".extends", "Unknown template '%s'.", extends)
+			return
+		case visited[template]:
+			v.add(mappingValue(node, "extends"), path+".extends", "Template '%s' extends itself.", extends)
+			return
+		}
+
+		node, path = template, "templates."+extends
+	}
+}
+
+// validateReferences checks if all references of the node and its children, like 'env:' or 'file:', can be resolved.
+// The commands of 'exec:' references are only run if RunCommands is set, otherwise their executable must exist.
+func (v *validator) validateReferences(node *yaml.Node, path string) {
+	node = resolveAlias(node)
+	switch node.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			v.validateReferences(node.Content[i+1], joinSchemaPath(path, node.Content[i].Value))
+		}
+	case yaml.SequenceNode:
+		for i, item := range node.Content {
+			v.validateReferences(item, fmt.Sprintf("%s[%d]", path, i))
+		}
+	case yaml.ScalarNode:
+		if !resolve.IsReference(node.Value) {
+			return
+		}
+		if resolve.IsCommand(node.Value) && !v.options.RunCommands {
+			if err := resolve.CheckCommand(node.Value); err != nil {
+				v.add(node, path, "Unable to resolve '%s'. %v", node.Value, err)
+			}
+			return
+		}
+		if _, err := resolve.ResolveVariable(node.Value); err != nil {
+			v.add(node, path, "Unable to resolve '%s'. %v", node.Value, err)
+		}
+	}
+}
+
+// mappingValue returns the value node of a key in a mapping node, matched case-insensitively.
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if strings.EqualFold(node.Content[i].Value, key) {
+			return resolveAlias(node.Content[i+1])
+		}
+	}
+	return nil
+}
+
+// scalarValue returns the value of a scalar setting in a mapping node, empty if it is not set.
+func scalarValue(node *yaml.Node, key string) string {
+	value := mappingValue(node, key)
+	if value == nil || value.Kind != yaml.ScalarNode || isNull(value) {
+		return ""
+	}
+	return value.Value
+}
+
+// certificateNameFromPath returns the name of a certificate without a name, derived from the file name of its path.
+// Dots, spaces and underscores are replaced with dashes.
+func certificateNameFromPath(path string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '.' || r == ' ' || r == '_' {
+			return '-'
+		}
+		return r
+	}, filepath.Base(path))
+}
@@ -0,0 +1,282 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidate(t *testing.T) {
+	testCases := []struct {
+		Name     string
+		File     string
+		Content  string
+		Options  ValidateOptions
+		Findings []string
+	}{
+		{
+			Name: "valid config",
+			Content: `
+server:
+  listenAddress: ":8080"
+policy:
+  rules:
+    rsa-key-size: warning
+certs:
+  - name: final
+    path: ../../tests/certs/pem/final.crt
+    enabled: "true"
+  - path: ../../tests/certs/pem/chain.pem
+`,
+		},
+		{
+			Name:    "empty config",
+			Content: "",
+		},
+		{
+			Name: "unknown settings and invalid values",
+			Content: `failOnErr: true
+autoReloadConfig: sometimes
+server: ":8080"
+policy:
+  rules:
+    rsa-key-size: fatal
+    key-size: error
+certs:
+  - name: final
+    path: ../../tests/certs/pem/final.crt
+    type: pam
+    filters:
+      include:
+        - field: serial
+          value: "1"
+`,
+			Findings: []string{
+				"line 1: Unknown setting 'failOnErr'.",
+				"line 2: autoReloadConfig: Invalid boolean 'sometimes'.",
+				"line 3: server: Must be a map.",
+				"line 6: policy.rules.rsa-key-size: Invalid value 'fatal'. Must be one of 'off', 'info', 'warning', 'error'.",
+				"line 7: policy.rules.key-size: Invalid value 'key-size'. Must be one of 'dsa-key', 'missing-san', 'overlong-validity', 'rsa-key-size', 'unencrypted-private-key', 'weak-signature-algorithm'.",
+				"line 11: certs[0].type: Invalid value 'pam'. Must be one of 'crt', 'jks', 'p12', 'p7', 'p7b', 'p7c', 'pem', 'pfx', 'pkcs12', 'truststore', 'ts'.",
+				"line 14: certs[0].filters.include[0].field: Invalid value 'serial'. Must be one of 'alias', 'fingerprint', 'issuer', 'san', 'subject'.",
+			},
+		},
+		{
+			Name: "semantic problems",
+			Content: `certs:
+  - name: final
+    path: ../../tests/certs/pem/final.crt
+    password: env:CERTALERT_VALIDATE_MISSING
+  - name: final
+    path: ../../tests/certs/pem/missing.pem
+  - path: ../../tests/certs/pem/final.crt.unknown
+  - name: disabled
+    enabled: false
+  - name: no-path
+`,
+			Findings: []string{
+				"line 4: certs[0].password: Unable to resolve 'env:CERTALERT_VALIDATE_MISSING'. Environment variable 'CERTALERT_VALIDATE_MISSING' not found.",
+				"line 5: certs[1]: Certificate name 'final' is already used by the certificate on line 2.",
+				"line 6: certs[1].path: Certificate 'final' is not accessible. File does not exist: ../../tests/certs/pem/missing.pem",
+				"line 7: certs[2].path: Certificate 'final-crt-unknown' is not accessible. File does not exist: ../../tests/certs/pem/final.crt.unknown",
+				"line 7: certs[2].type: Certificate 'final-crt-unknown' has no 'type' defined and it can't be inferred from the file extension '.unknown'.",
+				"line 10: certs[4]: Certificate 'no-path' has no 'path' defined.",
+			},
+		},
+		{
+			Name: "templates and defaults",
+			Content: `defaults:
+  type: pem
+templates:
+  chain:
+    path: ../../tests/certs/pem/chain.pem
+  loop:
+    extends: loop
+certs:
+  - name: inherited-path
+    extends: chain
+  - name: unknown-template
+    path: ../../tests/certs/pem/final.crt
+    extends: missing
+  - name: cyclic
+    extends: loop
+  - path: ../../tests/certs/pem/final.unknown-ext
+`,
+			Findings: []string{
+				"line 7: templates.loop.extends: Template 'loop' extends itself.",
+				"line 13: certs[1].extends: Unknown template 'missing'.",
+				"line 14: certs[2]: Certificate 'cyclic' has no 'path' defined.",
+				"line 16: certs[3].path: Certificate 'final-unknown-ext' is not accessible. File does not exist: ../../tests/certs/pem/final.unknown-ext",
+			},
+		},
+		{
+			Name: "labels",
+			Content: `labels:
+  env: prod
+  job: certalert
+certs:
+  - name: final
+    path: ../../tests/certs/pem/final.crt
+    labels:
+      team-name: web
+`,
+			Findings: []string{
+				"line 3: labels.job: Label name 'job' is reserved. Must not be one of 'fingerprint', 'instance', 'job', 'location', 'mismatches', 'reason', 'role', 'rule', 'severity', 'state', 'subject', 'type'.",
+				"line 8: certs[0].labels.team-name: Invalid label name 'team-name'. Must match '[a-zA-Z_][a-zA-Z0-9_]*' and must not start with '__'.",
+			},
+		},
+		{
+			Name: "thresholds",
+			Content: `thresholds:
+  warning: 30 days
+certs:
+  - name: final
+    path: ../../tests/certs/pem/final.crt
+    thresholds:
+      warning: 14d
+      critical: -1d
+`,
+			Findings: []string{
+				"line 2: thresholds.warning: Invalid threshold '30 days'. Must be a duration like '30d' or '12h' or a percentage like '33%'.",
+				"line 8: certs[0].thresholds.critical: Invalid threshold '-1d'. Must not be negative.",
+			},
+		},
+		{
+			Name: "commands are not run",
+			Content: `certs:
+  - name: final
+    path: ../../tests/certs/pem/final.crt
+    password: exec:false
+  - name: chain
+    path: ../../tests/certs/pem/chain.pem
+    password: exec:certalert-missing-command --get password
+`,
+			Findings: []string{
+				"line 7: certs[1].password: Unable to resolve 'exec:certalert-missing-command --get password'. Command 'certalert-missing-command' not found. exec: \"certalert-missing-command\": executable file not found in $PATH",
+			},
+		},
+		{
+			Name: "commands are run",
+			Content: `certs:
+  - name: final
+    path: ../../tests/certs/pem/final.crt
+    password: exec:false
+`,
+			Options: ValidateOptions{RunCommands: true},
+			Findings: []string{
+				"line 4: certs[0].password: Unable to resolve 'exec:false'. Command 'false' failed. exit status 1: ",
+			},
+		},
+		{
+			Name:     "invalid syntax",
+			Content:  "certs:\n  - name: [\n",
+			Findings: []string{"line 2: Invalid syntax. did not find expected node content"},
+		},
+		{
+			Name:     "json",
+			File:     "config.json",
+			Content:  "{\n  \"server\": {\n    \"port\": 8080\n  }\n}\n",
+			Findings: []string{"line 3: server: Unknown setting 'port'."},
+		},
+		{
+			Name:     "toml without line numbers",
+			File:     "config.toml",
+			Content:  "[server]\nlistenAddress = \":8080\"\nport = 8080\n",
+			Findings: []string{"server: Unknown setting 'port'."},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			file := tc.File
+			if file == "" {
+				file = "config.yaml"
+			}
+			path := filepath.Join(t.TempDir(), file)
+			if err := os.WriteFile(path, []byte(tc.Content), 0o644); err != nil {
+				t.Fatalf("Failed to write config file: %v", err)
+			}
+
+			findings, err := Validate(path, tc.Options)
+			assert.NoError(t, err)
+
+			var messages []string
+			for _, finding := range findings {
+				messages = append(messages, finding.String())
+			}
+			assert.Equal(t, tc.Findings, messages)
+		})
+	}
+
+	t.Run("missing file", func(t *testing.T) {
+		_, err := Validate("path/to/non_existent.yaml", ValidateOptions{})
+		assert.ErrorContains(t, err, "Failed to read config file: ")
+	})
+}
+
+func TestValidateFragments(t *testing.T) {
+	dir := t.TempDir()
+	configDir := filepath.Join(dir, "conf.d")
+	files := map[string]string{
+		"config.yaml": `include:
+  - fragment.yaml
+  - missing.yaml
+templates:
+  chain:
+    path: ../../tests/certs/pem/chain.pem
+certs:
+  - name: on
+    path: ../../tests/certs/pem/final.crt
+`,
+		"fragment.yaml": `certs:
+  - name: on
+    path: ../../tests/certs/pem/final.crt
+  - name: inherited
+    extends: chain
+  - name: missing
+    path: ../../tests/certs/pem/missing.pem
+`,
+		"conf.d/team.yaml": `certs:
+  - name: inherited
+    path: ../../tests/certs/pem/final.crt
+    tpye: pem
+`,
+	}
+	if err := os.MkdirAll(configDir, 0o755); err != nil {
+		t.Fatalf("Failed to create config dir: %v", err)
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatalf("Failed to write config file: %v", err)
+		}
+	}
+	configPath := filepath.Join(dir, "config.yaml")
+	fragmentPath := filepath.Join(dir, "fragment.yaml")
+	teamPath := filepath.Join(configDir, "team.yaml")
+
+	t.Run("include and config dir", func(t *testing.T) {
+		findings, err := Validate(configPath, ValidateOptions{ConfigDir: configDir})
+		assert.NoError(t, err)
+
+		var messages []string
+		for _, finding := range findings {
+			messages = append(messages, finding.File+": "+finding.String())
+		}
+		assert.Equal(t, []string{
+			configPath + ": line 3: include[1]: Included config file '" + filepath.Join(dir, "missing.yaml") + "' does not exist.",
+			fragmentPath + ": line 2: certs[0]: Certificate name 'on' is already used by the certificate in '" + configPath + "' on line 8.",
+			fragmentPath + ": line 7: certs[2].path: Certificate 'missing' is not accessible. File does not exist: ../../tests/certs/pem/missing.pem",
+			teamPath + ": line 2: certs[0]: Certificate name 'inherited' is already used by the certificate in '" + fragmentPath + "' on line 4.",
+			teamPath + ": line 4: certs[0]: Unknown setting 'tpye'.",
+		}, messages)
+	})
+
+	t.Run("missing config dir", func(t *testing.T) {
+		findings, err := Validate(fragmentPath, ValidateOptions{ConfigDir: filepath.Join(dir, "missing.d")})
+		assert.NoError(t, err)
+		assert.NotEmpty(t, findings)
+		assert.Equal(t, ValidationFinding{File: fragmentPath, Message: "Failed to read config dir '" + filepath.Join(dir, "missing.d") + "'. open " + filepath.Join(dir, "missing.d") + ": no such file or directory"}, findings[0])
+	})
+}
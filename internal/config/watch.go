@@ -0,0 +1,134 @@
+package config
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/rs/zerolog/log"
+)
+
+// WatchIncludes watches the included config files and the config dir of the active snapshot and calls
+// onChange whenever one of them is written, created, removed or renamed.
+//
+// The config file itself is watched by viper.WatchConfig. The directories of the files are watched, so
+// files matching an include pattern or added to the config dir later are picked up as well. The watched
+// directories are updated after each change, as a reload can add includes. A snapshot must be active.
+//
+// Parameters:
+//   - onChange: func(fsnotify.Event)
+//     The function called on a change, e.g. to reload the config.
+//
+// Returns:
+//   - error
+//     An error if the watcher can't be created.
+func WatchIncludes(onChange func(fsnotify.Event)) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("Failed to create config watcher: %v", err)
+	}
+
+	watched := make(map[string]bool)
+	addDirs := func() {
+		for _, dir := range watchedDirs(Current().Config) {
+			if watched[dir] {
+				continue
+			}
+			if err := watcher.Add(dir); err != nil {
+				log.Warn().Msgf("Unable to watch config dir '%s'. %v", dir, err)
+				continue
+			}
+			watched[dir] = true
+		}
+	}
+	addDirs()
+
+	go func() {
+		for {
+			select {
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				if !event.Has(fsnotify.Write|fsnotify.Create|fsnotify.Remove|fsnotify.Rename) || !isWatchedFile(Current().Config, event.Name) {
+					continue
+				}
+				onChange(event)
+				addDirs()
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				log.Error().Msgf("Error watching included config files. %v", err)
+			}
+		}
+	}()
+
+	return nil
+}
+
+// watchedDirs returns the directories containing the included config files and the config dir.
+//
+// Parameters:
+//   - c: Config
+//     The config with the files it was read from.
+//
+// Returns:
+//   - []string
+//     The sorted directories to watch.
+func watchedDirs(c Config) []string {
+	if len(c.Files) == 0 {
+		return nil
+	}
+
+	var dirs []string
+	for _, file := range c.Files[1:] {
+		dirs = append(dirs, filepath.Dir(file))
+	}
+	for _, pattern := range c.Include {
+		if dir := filepath.Dir(includePattern(c.Files[0], pattern)); !hasGlob(dir) {
+			dirs = append(dirs, dir)
+		}
+	}
+	if c.ConfigDir != "" {
+		dirs = append(dirs, filepath.Clean(c.ConfigDir))
+	}
+
+	slices.Sort(dirs)
+	return slices.Compact(dirs)
+}
+
+// isWatchedFile reports if a changed file is an included config file, matches an include pattern or is
+// a config fragment in the config dir. The config file itself is not reported, it is watched by viper.
+//
+// Parameters:
+//   - c: Config
+//     The config with the files it was read from.
+//   - name: string
+//     The path of the changed file.
+//
+// Returns:
+//   - bool
+//     True if the change must trigger a reload.
+func isWatchedFile(c Config, name string) bool {
+	if len(c.Files) == 0 {
+		return false
+	}
+
+	name = filepath.Clean(name)
+	if name == filepath.Clean(c.Files[0]) {
+		return false
+	}
+	if slices.Contains(c.Files[1:], name) {
+		return true
+	}
+
+	for _, pattern := range c.Include {
+		if matched, _ := filepath.Match(includePattern(c.Files[0], pattern), name); matched {
+			return true
+		}
+	}
+
+	return c.ConfigDir != "" && filepath.Dir(name) == filepath.Clean(c.ConfigDir) && isConfigFragment(filepath.Base(name))
+}
@@ -25,16 +25,19 @@ func init() {
 func Certificates(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "text/html")
 
-	certificatesInfo, err := certificates.Process(config.App.Certs, config.App.FailOnError)
+	// Read the active config once, so the whole request sees the same config
+	snapshot := config.Current()
+	certificatesInfo, err := certificates.Process(snapshot.Config.Certs, snapshot.Config.FailOnError)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
 	tplData := TemplateData{
-		CertInfos: certificatesInfo,
-		CSS:       CSS,
-		JS:        JS,
+		CertInfos:  certificatesInfo,
+		LabelNames: certificates.CustomLabelNames(certificatesInfo),
+		CSS:        CSS,
+		JS:         JS,
 	}
 	tpl, err := renderTemplate(tplBase, tplCertificates, tplData)
 	if err != nil {
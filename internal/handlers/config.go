@@ -31,7 +31,7 @@ func Config(w http.ResponseWriter, r *http.Request) {
 	defer yamlEncoder.Close()
 	yamlEncoder.SetIndent(2)
 
-	if err := yamlEncoder.Encode(&config.AppCopy); err != nil {
+	if err := yamlEncoder.Encode(&config.Current().Redacted); err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
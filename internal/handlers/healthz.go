@@ -4,7 +4,10 @@ import (
 	"certalert/internal/certificates"
 	"certalert/internal/config"
 	"certalert/internal/server"
+	"fmt"
 	"net/http"
+	"strings"
+	"time"
 )
 
 func init() {
@@ -15,9 +18,10 @@ func init() {
 //
 // This handler returns the status of the application. It checks the health
 // of the application by attempting to process the configured certificates. If
-// the certificate processing encounters an error, it returns an HTTP 500
-// Internal Server Error response with the error message. If the application is
-// healthy, it returns an HTTP 200 OK response with the "ok" message.
+// the certificate processing encounters an error, a certificate doesn't match its
+// pins or, if enabled, a certificate is not yet valid, it returns an HTTP 500
+// Internal Server Error response with the reasons.
+// If the application is healthy, it returns an HTTP 200 OK response with the "ok" message.
 //
 // Parameters:
 //   - w: http.ResponseWriter
@@ -25,12 +29,45 @@ func init() {
 //   - r: *http.Request
 //     The HTTP request.
 func Healthz(w http.ResponseWriter, r *http.Request) {
-	if _, err := certificates.Process(config.App.Certs, true); err != nil {
+	// Read the active config once, so the whole request sees the same config
+	snapshot := config.Current()
+	certificatesInfo, err := certificates.Process(snapshot.Config.Certs, true)
+	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
+	if reasons := healthFailures(certificatesInfo, snapshot.Config.Healthz); len(reasons) > 0 {
+		http.Error(w, strings.Join(reasons, "\n"), http.StatusInternalServerError)
+		return
+	}
+
 	w.Header().Set("Content-Type", "text/plain")
 	w.WriteHeader(http.StatusOK)
 	w.Write([]byte("ok"))
 }
+
+// healthFailures returns the reasons why the processed certificates make the application unhealthy.
+//
+// Parameters:
+//   - certificatesInfo: []certificates.CertificateInfo
+//     The processed certificates.
+//   - opts: config.Healthz
+//     The settings of the /healthz endpoint.
+//
+// Returns:
+//   - []string
+//     A reason per certificate which doesn't match its pins or, if enabled, is not yet valid.
+//     Empty if all certificates are healthy.
+func healthFailures(certificatesInfo []certificates.CertificateInfo, opts config.Healthz) []string {
+	var reasons []string
+	for _, ci := range certificatesInfo {
+		if ci.Pinning == certificates.PinningMismatch {
+			reasons = append(reasons, fmt.Sprintf("Certificate '%s' (%s) doesn't match any of its pins.", ci.Name, ci.Subject))
+		}
+		if opts.FailOnNotYetValid && ci.Validity == certificates.ValidityNotYetValid {
+			reasons = append(reasons, fmt.Sprintf("Certificate '%s' (%s) is not valid before %s.", ci.Name, ci.Subject, ci.NotBeforeAsTime().UTC().Format(time.RFC3339)))
+		}
+	}
+	return reasons
+}
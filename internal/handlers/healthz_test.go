@@ -0,0 +1,27 @@
+package handlers
+
+import (
+	"certalert/internal/certificates"
+	"certalert/internal/config"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHealthFailures(t *testing.T) {
+	assert.Empty(t, healthFailures([]certificates.CertificateInfo{
+		{Name: "service", Subject: "CN=www.example.com", Pinning: certificates.PinningMatch},
+		{Name: "ca", Subject: "CN=Example CA"},
+		{Name: "future", Subject: "CN=future.example.com", Validity: certificates.ValidityNotYetValid},
+	}, config.Healthz{}))
+
+	assert.Equal(t, []string{"Certificate 'partner' (CN=partner.example.com) doesn't match any of its pins."}, healthFailures([]certificates.CertificateInfo{
+		{Name: "service", Subject: "CN=www.example.com", Pinning: certificates.PinningMatch},
+		{Name: "partner", Subject: "CN=partner.example.com", Pinning: certificates.PinningMismatch},
+	}, config.Healthz{}))
+
+	assert.Equal(t, []string{"Certificate 'future' (CN=future.example.com) is not valid before 2030-01-01T00:00:00Z."}, healthFailures([]certificates.CertificateInfo{
+		{Name: "service", Subject: "CN=www.example.com", Validity: certificates.ValidityValid},
+		{Name: "future", Subject: "CN=future.example.com", NotBefore: 1893456000, Validity: certificates.ValidityNotYetValid},
+	}, config.Healthz{FailOnNotYetValid: true}))
+}
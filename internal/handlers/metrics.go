@@ -6,6 +6,8 @@ import (
 	"certalert/internal/metrics"
 	"certalert/internal/server"
 	"net/http"
+	"strings"
+	"sync"
 
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
@@ -15,10 +17,33 @@ func init() {
 	server.Register("/metrics", "Delivers metrics for Prometheus to scrape", Metrics, "GET", "POST")
 }
 
+// scrapeMutex serializes the scrapes, so a scrape never serves the metrics another scrape is resetting.
+var scrapeMutex sync.Mutex
+
+// certificateMetrics are the metrics of the certificates, which carry the labels of their source.
+// They are reset on every scrape.
+var certificateMetrics = []*metrics.LabeledGaugeVec{
+	metrics.CertificateEpoch,
+	metrics.CertificateExtractionStatus,
+	metrics.CertificateNotBefore,
+	metrics.CertificateValidityState,
+	metrics.CertificateExpectationMismatch,
+	metrics.CertificatePinMismatch,
+	metrics.CertificatePolicyViolation,
+	metrics.CertificateStatus,
+	metrics.CertificateLifetimeRemainingRatio,
+	metrics.CertificateWarningThreshold,
+	metrics.CertificateCriticalThreshold,
+}
+
 // setMetricsForCertificateInfo sets metrics for a given certificate info.
 //
 // It takes a CertificateInfo object and sets metrics in Prometheus for the
-// certificate extraction status, epoch, and error reason (if any).
+// certificate extraction status, epoch, validity, status, thresholds, role and error reason (if any).
+// The labels of the source of the certificate, e.g. the namespace and name of a Kubernetes Secret, are
+// added to every metric, so certificates with the same subject from different sources get a series each.
+// If the certificate was checked against expected names, issuer or pins, the results of the checks are set too.
+// Every violated policy rule is set as a policy violation.
 //
 // Parameters:
 //   - ci: certificates.CertificateInfo
@@ -28,25 +53,179 @@ func setMetricsForCertificateInfo(ci certificates.CertificateInfo) {
 		"instance": ci.Name,
 		"subject":  ci.Subject,
 		"type":     ci.Type,
+		"role":     ci.Role,
 		"reason":   "none", // default value
 	}
 
 	if ci.Error != "" {
 		// Add the reason only for CertificateExtractionStatus when there's an error
 		labels["reason"] = ci.Error
-		metrics.CertificateExtractionStatus.With(labels).Set(1)
+		metrics.CertificateExtractionStatus.WithSource(labels, ci.Labels).Set(1)
 	} else {
 		// Set without reason label
-		metrics.CertificateExtractionStatus.With(labels).Set(0)
-		metrics.CertificateEpoch.With(labels).Set(float64(ci.Epoch))
+		metrics.CertificateExtractionStatus.WithSource(labels, ci.Labels).Set(0)
+		metrics.CertificateEpoch.WithSource(labels, ci.Labels).Set(float64(ci.Epoch))
+	}
+
+	if ci.Validity != "" {
+		setValidityMetrics(ci)
+	}
+
+	if ci.Status != "" {
+		setStatusMetrics(ci)
+	}
+
+	if ci.Expectation != "" {
+		setExpectationMetric(ci)
+	}
+
+	if ci.Pinning != "" {
+		status := 0.0
+		if ci.Pinning == certificates.PinningMismatch {
+			status = 1
+		}
+		metrics.CertificatePinMismatch.WithSource(prometheus.Labels{"instance": ci.Name, "subject": ci.Subject}, ci.Labels).Set(status)
+	}
+
+	for _, finding := range ci.Findings {
+		metrics.CertificatePolicyViolation.WithSource(prometheus.Labels{
+			"instance": ci.Name,
+			"subject":  ci.Subject,
+			"rule":     finding.Rule,
+			"severity": finding.Severity,
+		}, ci.Labels).Set(1)
+	}
+}
+
+// setValidityMetrics sets the NotBefore date and the validity state of a certificate.
+//
+// Parameters:
+//   - ci: certificates.CertificateInfo
+//     The CertificateInfo object with the validity state.
+func setValidityMetrics(ci certificates.CertificateInfo) {
+	metrics.CertificateNotBefore.WithSource(prometheus.Labels{
+		"instance": ci.Name,
+		"subject":  ci.Subject,
+		"type":     ci.Type,
+		"role":     ci.Role,
+	}, ci.Labels).Set(float64(ci.NotBefore))
+
+	for _, state := range certificates.ValidityStates {
+		value := 0.0
+		if state == ci.Validity {
+			value = 1
+		}
+		metrics.CertificateValidityState.WithSource(prometheus.Labels{
+			"instance": ci.Name,
+			"subject":  ci.Subject,
+			"state":    state,
+		}, ci.Labels).Set(value)
+	}
+}
+
+// setStatusMetrics sets the status, the remaining ratio of the lifetime and the thresholds of a certificate.
+//
+// Parameters:
+//   - ci: certificates.CertificateInfo
+//     The CertificateInfo object with the status.
+func setStatusMetrics(ci certificates.CertificateInfo) {
+	certLabels := prometheus.Labels{
+		"instance": ci.Name,
+		"subject":  ci.Subject,
+		"type":     ci.Type,
+		"role":     ci.Role,
+	}
+	metrics.CertificateStatus.WithSource(certLabels, ci.Labels).Set(float64(certificates.StatusValue(ci.Status)))
+	metrics.CertificateLifetimeRemainingRatio.WithSource(certLabels, ci.Labels).Set(ci.LifetimeRemainingRatio)
+
+	labels := prometheus.Labels{"instance": ci.Name, "subject": ci.Subject}
+	metrics.CertificateWarningThreshold.WithSource(labels, ci.Labels).Set(float64(ci.WarningThreshold))
+	metrics.CertificateCriticalThreshold.WithSource(labels, ci.Labels).Set(float64(ci.CriticalThreshold))
+}
+
+// setExpectationMetric sets the metric of the expected names and issuer check of a certificate.
+//
+// Parameters:
+//   - ci: certificates.CertificateInfo
+//     The CertificateInfo object with the result of the check.
+func setExpectationMetric(ci certificates.CertificateInfo) {
+	mismatches := "none"
+	status := 0.0
+	if ci.Expectation == certificates.ExpectationMismatch {
+		mismatches = strings.Join(ci.Mismatches, ",")
+		status = 1
+	}
+
+	metrics.CertificateExpectationMismatch.WithSource(prometheus.Labels{
+		"instance":   ci.Name,
+		"subject":    ci.Subject,
+		"mismatches": mismatches,
+	}, ci.Labels).Set(status)
+}
+
+// setMetricsForDeduplicatedCertificateInfo sets the metrics of a distinct certificate and its locations.
+//
+// Parameters:
+//   - dci: certificates.DeduplicatedCertificateInfo
+//     The DeduplicatedCertificateInfo object for which metrics should be set.
+func setMetricsForDeduplicatedCertificateInfo(dci certificates.DeduplicatedCertificateInfo) {
+	labels := prometheus.Labels{
+		"fingerprint": dci.Fingerprint,
+		"subject":     dci.Subject,
+	}
+	metrics.DeduplicatedCertificateEpoch.With(labels).Set(float64(dci.Epoch))
+	metrics.DeduplicatedCertificateLocations.With(labels).Set(float64(len(dci.Locations)))
+
+	for _, location := range dci.Locations {
+		metrics.DeduplicatedCertificateLocationInfo.With(prometheus.Labels{
+			"fingerprint": dci.Fingerprint,
+			"instance":    location.Name,
+			"location":    location.LabelsString(),
+		}).Set(1)
+	}
+}
+
+// setMetrics sets the metrics of all certificates of a scrape.
+//
+// The metrics of the certificates are reset first, so series of a previous scrape don't linger once
+// they are resolved, e.g. the mismatches of the expected names or the violated policy rules, or once
+// their source is gone, e.g. a deleted Kubernetes Secret, S3 object or file of a Git repository.
+// The metrics of the distinct certificates are reset too, so a certificate removed from a location or
+// replaced by a renewed one, or all of them once deduplication is disabled, are not reported anymore.
+//
+// Parameters:
+//   - certificateInfos: []certificates.CertificateInfo
+//     The processed and linted certificates.
+//   - dedup: bool
+//     Whether the metrics of the distinct certificates are set in addition.
+func setMetrics(certificateInfos []certificates.CertificateInfo, dedup bool) {
+	for _, vec := range certificateMetrics {
+		vec.Reset()
+	}
+
+	metrics.SetCustomLabels(certificates.CustomLabelsByName(certificateInfos))
+	for _, ci := range certificateInfos {
+		setMetricsForCertificateInfo(ci)
+	}
+
+	metrics.DeduplicatedCertificateEpoch.Reset()
+	metrics.DeduplicatedCertificateLocations.Reset()
+	metrics.DeduplicatedCertificateLocationInfo.Reset()
+	if dedup {
+		deduplicated, _ := certificates.Deduplicate(certificateInfos)
+		for _, dci := range deduplicated {
+			setMetricsForDeduplicatedCertificateInfo(dci)
+		}
 	}
 }
 
 // Metrics is an HTTP handler for the /metrics route.
 //
 // This handler returns the metrics for Prometheus to scrape. It processes the
-// configured certificates and sets metrics based on the extraction status, epoch,
-// and error reason (if any).
+// configured certificates, lints them with the configured policy and sets metrics based
+// on the extraction status, epoch, and error reason (if any). The custom labels of the certificates
+// are added to their metrics. If deduplication is enabled, the metrics of the distinct
+// certificates are set in addition. Scrapes are serialized, as some metrics are reset on every scrape.
 //
 // Parameters:
 //   - w: http.ResponseWriter
@@ -54,15 +233,19 @@ func setMetricsForCertificateInfo(ci certificates.CertificateInfo) {
 //   - r: *http.Request
 //     The HTTP request.
 func Metrics(w http.ResponseWriter, r *http.Request) {
-	certificateInfos, err := certificates.Process(config.App.Certs, config.App.FailOnError)
+	// Read the active config once, so the whole request sees the same config
+	snapshot := config.Current()
+	certificateInfos, err := certificates.Process(snapshot.Config.Certs, snapshot.Config.FailOnError)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	for _, ci := range certificateInfos {
-		setMetricsForCertificateInfo(ci)
-	}
+	certificates.Lint(certificateInfos, snapshot.Config.Policy)
+
+	scrapeMutex.Lock()
+	defer scrapeMutex.Unlock()
+	setMetrics(certificateInfos, snapshot.Config.Dedup)
 
 	// Serve metrics
 	promhttp.HandlerFor(metrics.PromMetrics.Registry, promhttp.HandlerOpts{}).ServeHTTP(w, r)
@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"certalert/internal/certificates"
+	"certalert/internal/metrics"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetMetricsResetsExpectationMismatches(t *testing.T) {
+	ci := certificates.CertificateInfo{
+		Name:        "web",
+		Subject:     "CN=www.example.com",
+		Expectation: certificates.ExpectationMismatch,
+		Mismatches:  []string{"dns:api.example.com"},
+	}
+	setMetrics([]certificates.CertificateInfo{ci}, false)
+	assert.Equal(t, 1, testutil.CollectAndCount(metrics.CertificateExpectationMismatch))
+
+	// The resolved mismatch must not leave its series behind
+	ci.Expectation = certificates.ExpectationMatch
+	ci.Mismatches = nil
+	setMetrics([]certificates.CertificateInfo{ci}, false)
+	assert.Equal(t, 1, testutil.CollectAndCount(metrics.CertificateExpectationMismatch))
+	assert.Equal(t, 0.0, testutil.ToFloat64(metrics.CertificateExpectationMismatch))
+}
+
+func TestSetMetricsResetsPolicyViolations(t *testing.T) {
+	ci := certificates.CertificateInfo{
+		Name:     "web",
+		Subject:  "CN=www.example.com",
+		Findings: []certificates.Finding{{Rule: "min-key-size", Severity: "critical"}},
+	}
+	setMetrics([]certificates.CertificateInfo{ci}, false)
+	assert.Equal(t, 1, testutil.CollectAndCount(metrics.CertificatePolicyViolation))
+
+	// A fixed certificate has no violations left
+	ci.Findings = nil
+	setMetrics([]certificates.CertificateInfo{ci}, false)
+	assert.Equal(t, 0, testutil.CollectAndCount(metrics.CertificatePolicyViolation))
+}
+
+func TestSetMetricsResetsRemovedSources(t *testing.T) {
+	secret := func(name string) certificates.CertificateInfo {
+		return certificates.CertificateInfo{
+			Name:     "secrets",
+			Subject:  "CN=" + name,
+			Type:     "pem",
+			Epoch:    1700000000,
+			Validity: certificates.ValidityStates[0],
+			Status:   "valid",
+			Pinning:  certificates.PinningMismatch,
+			Labels:   map[string]string{"namespace": "shop", "secret": name},
+		}
+	}
+	setMetrics([]certificates.CertificateInfo{secret("web"), secret("api")}, false)
+	assert.Equal(t, 2, testutil.CollectAndCount(metrics.CertificateEpoch))
+	assert.Equal(t, 2, testutil.CollectAndCount(metrics.CertificateStatus))
+	assert.Equal(t, 2, testutil.CollectAndCount(metrics.CertificatePinMismatch))
+
+	// The series of a deleted Secret must not be exported anymore
+	setMetrics([]certificates.CertificateInfo{secret("web")}, false)
+	assert.Equal(t, 1, testutil.CollectAndCount(metrics.CertificateEpoch))
+	assert.Equal(t, 1, testutil.CollectAndCount(metrics.CertificateExtractionStatus))
+	assert.Equal(t, 1, testutil.CollectAndCount(metrics.CertificateNotBefore))
+	assert.Equal(t, len(certificates.ValidityStates), testutil.CollectAndCount(metrics.CertificateValidityState))
+	assert.Equal(t, 1, testutil.CollectAndCount(metrics.CertificateStatus))
+	assert.Equal(t, 1, testutil.CollectAndCount(metrics.CertificateCriticalThreshold))
+	assert.Equal(t, 1, testutil.CollectAndCount(metrics.CertificatePinMismatch))
+}
+
+func TestSetMetricsResetsDeduplicatedLocations(t *testing.T) {
+	certificateInfos, err := certificates.Process([]certificates.Certificate{
+		{Name: "web", Path: "../../tests/certs/pem/final.crt"},
+		{Name: "api", Path: "../../tests/certs/pem/final.crt"},
+	}, true)
+	assert.NoError(t, err)
+
+	setMetrics(certificateInfos, true)
+	assert.Equal(t, 2, testutil.CollectAndCount(metrics.DeduplicatedCertificateLocationInfo))
+
+	// The location of a removed config entry must not be reported anymore
+	setMetrics(certificateInfos[:1], true)
+	assert.Equal(t, 1, testutil.CollectAndCount(metrics.DeduplicatedCertificateLocationInfo))
+	assert.Equal(t, 1.0, testutil.ToFloat64(metrics.DeduplicatedCertificateLocations))
+
+	setMetrics(certificateInfos, false)
+	assert.Equal(t, 0, testutil.CollectAndCount(metrics.DeduplicatedCertificateLocationInfo))
+	assert.Equal(t, 0, testutil.CollectAndCount(metrics.DeduplicatedCertificateEpoch))
+	assert.Equal(t, 0, testutil.CollectAndCount(metrics.DeduplicatedCertificateLocations))
+}
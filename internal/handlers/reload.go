@@ -3,7 +3,6 @@ package handlers
 import (
 	"certalert/internal/config"
 	"certalert/internal/server"
-	"certalert/internal/utils"
 	"net/http"
 
 	"github.com/rs/zerolog/log"
@@ -16,9 +15,10 @@ func init() {
 
 // Reload is an HTTP handler for the /reload route.
 //
-// This handler reloads the configuration file. It reads, parses, and redacts the
-// configuration. It also updates the copy of the configuration used for exposing
-// the current configuration via the /config route.
+// This handler reloads the configuration file. The file is read, parsed and redacted
+// next to the active configuration and only activated if all steps succeed. If the new
+// configuration is invalid, the previous configuration keeps serving and an HTTP 500
+// Internal Server Error response with the reason is returned.
 //
 // Parameters:
 //   - w: http.ResponseWriter
@@ -28,26 +28,8 @@ func init() {
 func Reload(w http.ResponseWriter, r *http.Request) {
 	log.Debug().Msgf("Force reloading configuration")
 
-	if err := config.App.Read(viper.ConfigFileUsed()); err != nil {
-		log.Fatal().Msgf("Unable to read config: %s", err)
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
-	}
-
-	if err := config.App.Parse(); err != nil {
-		log.Fatal().Msgf("Unable to parse config: %s", err)
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
-	}
-
-	if err := utils.DeepCopy(config.App, &config.AppCopy); err != nil {
-		log.Fatal().Msgf("Unable to copy config: %s", err)
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
-	}
-
-	if err := config.RedactConfig(&config.AppCopy); err != nil {
-		log.Fatal().Msgf("Unable to redact config: %s", err)
+	if err := config.Reload(viper.ConfigFileUsed()); err != nil {
+		log.Error().Msgf("Failed to reload configuration, keeping the previous configuration. %s", err)
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
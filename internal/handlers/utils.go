@@ -31,48 +31,36 @@ var remainingDuration = func(epoch int64) time.Duration {
 	return time.Until(time.Unix(epoch, 0))
 }
 
-// getRowColor returns the color code for a row based on the expiry date.
+// getRowColor returns the color code for a row based on the status of the certificate.
 //
 // Parameters:
-//   - epoch: int64
-//     The epoch time representing the expiry date.
+//   - status: string
+//     The status of the certificate derived from its thresholds. Empty if the extraction failed.
+//   - validity: string
+//     The validity state of the certificate, e.g. 'not-yet-valid'. Empty if the extraction failed.
 //
 // Returns:
 //   - string
-//     The color code for the row.
-//     - "red-row" for expired certificates.
-//     - "orange-row" for certificates expiring in the next 30 days.
-//     - "yellow-row" for certificates expiring in the next 60 days.
-//     - An empty string for certificates with more than 60 days until expiration.
-
-func getRowColor(epoch int64) string {
-	if epoch == 0 {
+//     The color code for the row: "purple-row" for certificates which are not yet valid, "red-row" for
+//     expired certificates and certificates within their critical threshold, "orange-row" for certificates
+//     within their warning threshold and an empty string for all other certificates.
+func getRowColor(status, validity string) string {
+	if status == "" {
 		return ""
 	}
 
-	d := remainingDuration(epoch)
-
-	// expired
-	if d <= 0 {
-		return "red-row"
+	if validity == certificates.ValidityNotYetValid {
+		return "purple-row"
 	}
 
-	// expires in the next 3 days
-	if d <= 3*24*time.Hour {
+	switch status {
+	case certificates.StatusExpired, certificates.StatusCritical:
 		return "red-row"
-	}
-
-	// expires in the next 30 days
-	if d <= 30*24*time.Hour {
+	case certificates.StatusWarning:
 		return "orange-row"
+	default:
+		return ""
 	}
-
-	// expires in the next 60 days
-	if d <= 60*24*time.Hour {
-		return "yellow-row"
-	}
-
-	return ""
 }
 
 // epochToHumanReadable converts the epoch time to a human-readable duration string.
@@ -168,6 +156,9 @@ func renderTemplate(baseTplStr string, tplStr string, data interface{}) (string,
 		"formatTime":    formatTime,
 		"humanReadable": epochToHumanReadable,
 		"getRowColor":   getRowColor,
+		"join":          strings.Join,
+		"add":           func(a, b int) int { return a + b },
+		"percent":       func(ratio float64) string { return fmt.Sprintf("%.0f%%", ratio*100) },
 	}
 
 	// Create a new template and parse the base template into it.
@@ -198,6 +189,8 @@ type TemplateData struct {
 	JS        string
 	Endpoints []server.Handler
 	CertInfos []certificates.CertificateInfo
+	// LabelNames are the names of the custom labels of the certificates, rendered as additional columns
+	LabelNames []string
 }
 
 // CSS is the CSS that is used in the template
@@ -235,18 +228,18 @@ thead th {
 	opacity: 0.7;
 }
 
-.row-yellow {
-	background-color: #FFD700;
-}
-
-.row-orange {
+.orange-row {
 	background-color: #FFA500;
 }
 
-.row-red {
+.red-row {
 	background-color: #FF4500;
 }
 
+.purple-row {
+	background-color: #DA70D6;
+}
+
 .sortable:hover {
   cursor: pointer;
   text-decoration: underline;
@@ -327,15 +320,25 @@ const tplCertificates string = `
 					<tr class="table-header">
 							<th scope="col"></th>
 							<th class="sortable" onclick="sortTable(1)">Name</th>
-							<th class="sortable" onclick="sortTable(2)">Subject</th>
-							<th class="sortable" onclick="sortTable(3)">Type</th>
-							<th class="sortable" onclick="sortTable(4)">Expiry Date</th>
-							<th class="sortable" onclick="sortTable(5)">Expiration</th>
+							<th class="sortable" onclick="sortTable(2)">Source</th>
+							<th class="sortable" onclick="sortTable(3)">Subject</th>
+							<th class="sortable" onclick="sortTable(4)">Type</th>
+							<th class="sortable" onclick="sortTable(5)">Role</th>
+							<th class="sortable" onclick="sortTable(6)">Expectation</th>
+							<th class="sortable" onclick="sortTable(7)">Valid From</th>
+							<th class="sortable" onclick="sortTable(8)">Expiry Date</th>
+							<th class="sortable" onclick="sortTable(9)">Expiration</th>
+							<th class="sortable" onclick="sortTable(10)">Validity</th>
+							<th class="sortable" onclick="sortTable(11)">Status</th>
+							<th class="sortable" onclick="sortTable(12)">Lifetime Remaining</th>
+							{{- range $i, $name := .LabelNames }}
+							<th class="sortable" onclick="sortTable({{ add $i 13 }})">{{ $name }}</th>
+							{{- end }}
 					</tr>
 			</thead>
 			<tbody>
 					{{range .CertInfos}}
-					<tr class="{{ getRowColor .Epoch }}">
+					<tr class="{{ getRowColor .Status .Validity }}">
 							<td>
 									{{if .Error}}
 											<span class="error-symbol" title="{{.Error}}" style="color: red;">✖</span>
@@ -344,10 +347,29 @@ const tplCertificates string = `
 									{{end}}
 							</td>
 							<td>{{.Name}}</td>
+							<td>{{ if .Labels }}{{ .LabelsString }}{{ else }}-{{ end }}</td>
 							<td>{{.Subject}}</td>
 							<td>{{.Type}}</td>
+							<td>{{.Role}}</td>
+							<td>
+									{{if eq .Expectation "mismatch"}}
+											<span class="error-symbol" title="{{ join .Mismatches ", " }}" style="color: red;">✖ mismatch</span>
+									{{else if eq .Expectation "match"}}
+											<span style="color: green;">✔ match</span>
+									{{else}}
+											-
+									{{end}}
+							</td>
+							<td>{{ formatTime .NotBeforeAsTime "2006-01-02" }}</td>
 							<td>{{ formatTime .ExpiryAsTime "2006-01-02" }}</td>
 							<td>{{ humanReadable .Epoch }}</td>
+							<td>{{ if .Validity }}{{ .Validity }}{{ else }}-{{ end }}</td>
+							<td>{{ if .Status }}{{ .Status }}{{ else }}-{{ end }}</td>
+							<td>{{ if .Status }}{{ percent .LifetimeRemainingRatio }}{{ else }}-{{ end }}</td>
+							{{- $labels := .CustomLabels }}
+							{{- range $.LabelNames }}
+							<td>{{ index $labels . }}</td>
+							{{- end }}
 					</tr>
 					{{end}}
 			</tbody>
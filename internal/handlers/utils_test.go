@@ -1,6 +1,8 @@
 package handlers
 
 import (
+	"certalert/internal/certificates"
+	"strings"
 	"testing"
 	"time"
 )
@@ -41,39 +43,37 @@ func TestRemainingDuration(t *testing.T) {
 }
 
 func TestGetRowColor(t *testing.T) {
-	// Mock remainingDuration for testing
-	oldRemainingDuration := remainingDuration
-	remainingDuration = func(epoch int64) time.Duration {
-		return time.Unix(epoch, 0).Sub(time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC))
+	tests := []struct {
+		name     string
+		status   string
+		expected string
+	}{
+		{"failed extraction", "", ""},
+		{"expired", certificates.StatusExpired, "red-row"},
+		{"critical", certificates.StatusCritical, "red-row"},
+		{"warning", certificates.StatusWarning, "orange-row"},
+		{"ok", certificates.StatusOK, ""},
 	}
-	defer func() {
-		remainingDuration = oldRemainingDuration
-	}()
 
-	currentTime := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC).Unix()
+	for _, tt := range tests {
+		actual := getRowColor(tt.status, "")
+		if actual != tt.expected {
+			t.Errorf("%s: expected %s, got %s", tt.name, tt.expected, actual)
+		}
+	}
 
-	tests := []struct {
+	validityTests := []struct {
 		name     string
-		epoch    int64
+		validity string
 		expected string
 	}{
-		{"zero", 0, ""},
-		{"expired", currentTime - 10, "red-row"},
-		{"1 day", currentTime + 1*24*60*60, "red-row"},       // Converted 1 day to seconds
-		{"2 days", currentTime + 2*24*60*60, "red-row"},      // Converted 2 days to seconds
-		{"3 days", currentTime + 3*24*60*60, "red-row"},      // Converted 3 days to seconds
-		{"4 days", currentTime + 4*24*60*60, "orange-row"},   // Converted 4 days to seconds
-		{"5 days", currentTime + 5*24*60*60, "orange-row"},   // Converted 5 days to seconds
-		{"29 days", currentTime + 29*24*60*60, "orange-row"}, // Converted 29 days to seconds
-		{"30 days", currentTime + 30*24*60*60, "orange-row"}, // Converted 30 days to seconds
-		{"31 days", currentTime + 31*24*60*60, "yellow-row"}, // Converted 31 days to seconds
-		{"59 days", currentTime + 59*24*60*60, "yellow-row"}, // Converted 59 days to seconds
-		{"60 days", currentTime + 60*24*60*60, "yellow-row"}, // Converted 60 days to seconds
-		{"61 days", currentTime + 61*24*60*60, ""},           // Converted 61 days to seconds
+		{"valid", certificates.ValidityValid, ""},
+		{"not yet valid", certificates.ValidityNotYetValid, "purple-row"},
+		{"expired", certificates.ValidityExpired, ""},
 	}
 
-	for _, tt := range tests {
-		actual := getRowColor(tt.epoch)
+	for _, tt := range validityTests {
+		actual := getRowColor(certificates.StatusOK, tt.validity)
 		if actual != tt.expected {
 			t.Errorf("%s: expected %s, got %s", tt.name, tt.expected, actual)
 		}
@@ -166,12 +166,12 @@ func TestRenderTemplate(t *testing.T) {
 	}{
 		{
 			Name:       "Valid_Case",
-			baseTplStr: "{{formatTime .Time \"2006-01-02\"}} {{humanReadable .Epoch}} {{getRowColor .Status}}",
+			baseTplStr: "{{formatTime .Time \"2006-01-02\"}} {{humanReadable .Epoch}} {{getRowColor .Status \"\"}}",
 			tplStr:     "",
 			data: map[string]interface{}{
 				"Time":   time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC),
 				"Epoch":  int64(1630454400),
-				"Status": int64(1630454400),
+				"Status": certificates.StatusCritical,
 			},
 			expectedStr: "2022-01-01 now red-row",
 			expectedErr: nil,
@@ -222,3 +222,64 @@ func TestRenderTemplate(t *testing.T) {
 		})
 	}
 }
+
+func TestRenderCertificatesTemplate(t *testing.T) {
+	data := TemplateData{
+		CertInfos: []certificates.CertificateInfo{
+			{Name: "service", Subject: "CN=www.example.com", Type: "pem", Role: "leaf", Expectation: "mismatch", Mismatches: []string{"dns:api.example.com", "ip:10.0.0.2"}},
+			{Name: "service", Subject: "CN=Example CA", Type: "pem", Role: "root"},
+		},
+	}
+
+	result, err := renderTemplate(tplBase, tplCertificates, data)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, expected := range []string{"<td>leaf</td>", "<td>root</td>", `title="dns:api.example.com, ip:10.0.0.2"`, "✖ mismatch"} {
+		if !strings.Contains(result, expected) {
+			t.Errorf("expected output to contain %q", expected)
+		}
+	}
+}
+
+func TestRenderCertificatesTemplateLabels(t *testing.T) {
+	data := TemplateData{
+		CertInfos: []certificates.CertificateInfo{
+			{Name: "web", Subject: "CN=www.example.com", Status: "warning", LifetimeRemainingRatio: 0.3333, CustomLabels: map[string]string{"team": "web", "env": "prod"}},
+			{Name: "db", Subject: "CN=db.example.com"},
+		},
+		LabelNames: []string{"env", "team"},
+	}
+
+	result, err := renderTemplate(tplBase, tplCertificates, data)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, expected := range []string{`onclick="sortTable(13)">env</th>`, `onclick="sortTable(14)">team</th>`, "<td>prod</td>", "<td>web</td>", "<td>33%</td>"} {
+		if !strings.Contains(result, expected) {
+			t.Errorf("expected output to contain %q", expected)
+		}
+	}
+}
+
+func TestRenderCertificatesTemplateSourceLabels(t *testing.T) {
+	data := TemplateData{
+		CertInfos: []certificates.CertificateInfo{
+			{Name: "cluster", Subject: "CN=www.example.com", Labels: map[string]string{"namespace": "shop", "secret": "web-tls", "key": "tls.crt"}},
+			{Name: "file", Subject: "CN=db.example.com"},
+		},
+	}
+
+	result, err := renderTemplate(tplBase, tplCertificates, data)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, expected := range []string{`onclick="sortTable(2)">Source</th>`, "<td>key=tls.crt,namespace=shop,secret=web-tls</td>", "<td>-</td>"} {
+		if !strings.Contains(result, expected) {
+			t.Errorf("expected output to contain %q", expected)
+		}
+	}
+}
@@ -0,0 +1,197 @@
+package metrics
+
+import (
+	"encoding/json"
+	"slices"
+	"sync/atomic"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// customLabels holds the custom labels of the certificates, keyed by the instance label.
+var customLabels atomic.Pointer[map[string]map[string]string]
+
+// SetCustomLabels sets the custom labels added to the metrics of the certificates.
+//
+// Parameters:
+//   - labels: map[string]map[string]string
+//     The custom labels keyed by the name of the certificate, which is the value of the 'instance' label.
+func SetCustomLabels(labels map[string]map[string]string) {
+	customLabels.Store(&labels)
+}
+
+// SourceLabel is the internal label of a LabeledGaugeVec carrying the labels of the source a certificate was
+// read from, e.g. the namespace and name of a Kubernetes Secret. It is expanded into a label per source label
+// when the metrics are collected and never exported itself.
+const SourceLabel = "source"
+
+// LabeledGaugeVec is a GaugeVec which adds the source labels and the custom labels of the certificates to
+// its metrics.
+//
+// The names of the source labels depend on the sources of the certificates and the names of the custom
+// labels are only known after the config is read, and both change when the config is reloaded, so the
+// collector is unchecked: it describes no metrics and builds the descriptor of its metrics on every collect.
+// Metrics of a certificate without a source or custom label get an empty value, which Prometheus treats like
+// a missing label. A source label with the same name as a label set by CertAlert is dropped, a source label with
+// the same name as a custom label wins over it.
+type LabeledGaugeVec struct {
+	*prometheus.GaugeVec
+	name       string
+	help       string
+	labelNames []string
+}
+
+// NewLabeledGaugeVec creates a new LabeledGaugeVec. The label names must contain 'instance', which is
+// used to look up the custom labels of a metric.
+//
+// Parameters:
+//   - opts: prometheus.GaugeOpts
+//     The options of the gauge.
+//   - labelNames: []string
+//     The names of the labels set by CertAlert.
+//
+// Returns:
+//   - *LabeledGaugeVec
+//     The new LabeledGaugeVec.
+func NewLabeledGaugeVec(opts prometheus.GaugeOpts, labelNames []string) *LabeledGaugeVec {
+	return &LabeledGaugeVec{
+		GaugeVec:   prometheus.NewGaugeVec(opts, append(slices.Clone(labelNames), SourceLabel)),
+		name:       prometheus.BuildFQName(opts.Namespace, opts.Subsystem, opts.Name),
+		help:       opts.Help,
+		labelNames: labelNames,
+	}
+}
+
+// With returns the gauge for the given labels without source labels. See WithSource.
+func (v *LabeledGaugeVec) With(labels prometheus.Labels) prometheus.Gauge {
+	return v.WithSource(labels, nil)
+}
+
+// WithSource returns the gauge for the given labels and the labels of the source of the certificate.
+// Certificates with the same labels but different source labels, e.g. the same certificate stored in two
+// Kubernetes Secrets, get a gauge each.
+//
+// Parameters:
+//   - labels: prometheus.Labels
+//     The labels set by CertAlert.
+//   - source: map[string]string
+//     The labels of the source of the certificate.
+//
+// Returns:
+//   - prometheus.Gauge
+//     The gauge of the labels.
+func (v *LabeledGaugeVec) WithSource(labels prometheus.Labels, source map[string]string) prometheus.Gauge {
+	withSource := make(prometheus.Labels, len(labels)+1)
+	for name, value := range labels {
+		withSource[name] = value
+	}
+	withSource[SourceLabel] = encodeSourceLabels(source)
+	return v.GaugeVec.With(withSource)
+}
+
+// Describe implements prometheus.Collector. It sends no descriptor, which makes the collector unchecked.
+func (v *LabeledGaugeVec) Describe(ch chan<- *prometheus.Desc) {}
+
+// Collect implements prometheus.Collector. It collects the metrics of the GaugeVec, expands the source labels
+// and adds the custom labels.
+func (v *LabeledGaugeVec) Collect(ch chan<- prometheus.Metric) {
+	var labels map[string]map[string]string
+	if stored := customLabels.Load(); stored != nil {
+		labels = *stored
+	}
+
+	collected := make(chan prometheus.Metric)
+	go func() {
+		v.GaugeVec.Collect(collected)
+		close(collected)
+	}()
+
+	var written []*dto.Metric
+	var sources []map[string]string
+	for metric := range collected {
+		var m dto.Metric
+		if err := metric.Write(&m); err != nil {
+			ch <- prometheus.NewInvalidMetric(prometheus.NewDesc(v.name, v.help, nil, nil), err)
+			continue
+		}
+		written = append(written, &m)
+		sources = append(sources, decodeSourceLabels(labelValue(&m, SourceLabel)))
+	}
+
+	// The source labels win over custom labels with the same name, as they tell the series apart
+	names := customLabelNames(labels)
+	for _, source := range sources {
+		for name := range source {
+			if !slices.Contains(v.labelNames, name) && !slices.Contains(names, name) {
+				names = append(names, name)
+			}
+		}
+	}
+	slices.Sort(names)
+
+	desc := prometheus.NewDesc(v.name, v.help, slices.Concat(v.labelNames, names), nil)
+	for i, m := range written {
+		labelValues := make([]string, 0, len(v.labelNames)+len(names))
+		for _, name := range v.labelNames {
+			labelValues = append(labelValues, labelValue(m, name))
+		}
+		instance := labelValue(m, "instance")
+		for _, name := range names {
+			value, found := sources[i][name]
+			if !found {
+				value = labels[instance][name]
+			}
+			labelValues = append(labelValues, value)
+		}
+
+		labeled, err := prometheus.NewConstMetric(desc, prometheus.GaugeValue, m.GetGauge().GetValue(), labelValues...)
+		if err != nil {
+			labeled = prometheus.NewInvalidMetric(desc, err)
+		}
+		ch <- labeled
+	}
+}
+
+// labelValue returns the value of a label of a metric, or an empty string if the metric has no such label.
+func labelValue(m *dto.Metric, name string) string {
+	for _, pair := range m.GetLabel() {
+		if pair.GetName() == name {
+			return pair.GetValue()
+		}
+	}
+	return ""
+}
+
+// encodeSourceLabels encodes the source labels into the value of the SourceLabel. The keys of the JSON
+// object are sorted, so equal labels result in the same value.
+func encodeSourceLabels(source map[string]string) string {
+	if len(source) == 0 {
+		return ""
+	}
+	encoded, _ := json.Marshal(source) // a map of strings can always be encoded
+	return string(encoded)
+}
+
+// decodeSourceLabels decodes the value of the SourceLabel into the source labels.
+func decodeSourceLabels(value string) map[string]string {
+	var source map[string]string
+	if value != "" {
+		_ = json.Unmarshal([]byte(value), &source) // the value is always set by encodeSourceLabels
+	}
+	return source
+}
+
+// customLabelNames returns the sorted names of the custom labels of all certificates.
+func customLabelNames(labels map[string]map[string]string) []string {
+	var names []string
+	for _, certLabels := range labels {
+		for name := range certLabels {
+			if !slices.Contains(names, name) {
+				names = append(names, name)
+			}
+		}
+	}
+	slices.Sort(names)
+	return names
+}
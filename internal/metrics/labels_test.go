@@ -0,0 +1,81 @@
+package metrics
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLabeledGaugeVec(t *testing.T) {
+	t.Cleanup(func() { SetCustomLabels(nil) })
+
+	vec := NewLabeledGaugeVec(prometheus.GaugeOpts{Name: "test_epoch_seconds", Help: "Test metric"}, []string{"instance", "subject"})
+	vec.With(prometheus.Labels{"instance": "web", "subject": "CN=web"}).Set(1)
+	vec.With(prometheus.Labels{"instance": "db", "subject": "CN=db"}).Set(2)
+
+	t.Run("without custom labels", func(t *testing.T) {
+		SetCustomLabels(nil)
+		expected := `
+# HELP test_epoch_seconds Test metric
+# TYPE test_epoch_seconds gauge
+test_epoch_seconds{instance="db",subject="CN=db"} 2
+test_epoch_seconds{instance="web",subject="CN=web"} 1
+`
+		assert.NoError(t, testutil.CollectAndCompare(vec, strings.NewReader(expected)))
+	})
+
+	t.Run("with custom labels", func(t *testing.T) {
+		SetCustomLabels(map[string]map[string]string{"web": {"team": "web", "env": "prod"}})
+		expected := `
+# HELP test_epoch_seconds Test metric
+# TYPE test_epoch_seconds gauge
+test_epoch_seconds{env="",instance="db",subject="CN=db",team=""} 2
+test_epoch_seconds{env="prod",instance="web",subject="CN=web",team="web"} 1
+`
+		assert.NoError(t, testutil.CollectAndCompare(vec, strings.NewReader(expected)))
+	})
+
+	t.Run("registers as unchecked collector", func(t *testing.T) {
+		reg := prometheus.NewRegistry()
+		assert.NoError(t, reg.Register(vec))
+		_, err := reg.Gather()
+		assert.NoError(t, err)
+	})
+}
+
+func TestLabeledGaugeVecSourceLabels(t *testing.T) {
+	t.Cleanup(func() { SetCustomLabels(nil) })
+
+	vec := NewLabeledGaugeVec(prometheus.GaugeOpts{Name: "test_epoch_seconds", Help: "Test metric"}, []string{"instance", "subject"})
+	labels := prometheus.Labels{"instance": "cluster", "subject": "CN=web"}
+	vec.WithSource(labels, map[string]string{"namespace": "shop", "secret": "web-tls", "key": "tls.crt"}).Set(1)
+	vec.WithSource(labels, map[string]string{"namespace": "blog", "secret": "web-tls", "key": "tls.crt"}).Set(2)
+	vec.With(prometheus.Labels{"instance": "file", "subject": "CN=file"}).Set(3)
+
+	t.Run("expands the source labels into a series each", func(t *testing.T) {
+		SetCustomLabels(nil)
+		expected := `
+# HELP test_epoch_seconds Test metric
+# TYPE test_epoch_seconds gauge
+test_epoch_seconds{instance="cluster",key="tls.crt",namespace="blog",secret="web-tls",subject="CN=web"} 2
+test_epoch_seconds{instance="cluster",key="tls.crt",namespace="shop",secret="web-tls",subject="CN=web"} 1
+test_epoch_seconds{instance="file",key="",namespace="",secret="",subject="CN=file"} 3
+`
+		assert.NoError(t, testutil.CollectAndCompare(vec, strings.NewReader(expected)))
+	})
+
+	t.Run("source labels win over custom labels", func(t *testing.T) {
+		SetCustomLabels(map[string]map[string]string{"cluster": {"namespace": "platform", "team": "web"}, "file": {"namespace": "platform"}})
+		expected := `
+# HELP test_epoch_seconds Test metric
+# TYPE test_epoch_seconds gauge
+test_epoch_seconds{instance="cluster",key="tls.crt",namespace="blog",secret="web-tls",subject="CN=web",team="web"} 2
+test_epoch_seconds{instance="cluster",key="tls.crt",namespace="shop",secret="web-tls",subject="CN=web",team="web"} 1
+test_epoch_seconds{instance="file",key="",namespace="platform",secret="",subject="CN=file",team=""} 3
+`
+		assert.NoError(t, testutil.CollectAndCompare(vec, strings.NewReader(expected)))
+	})
+}
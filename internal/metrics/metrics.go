@@ -8,21 +8,153 @@ var PromMetrics Metrics
 
 var (
 	// New metric to track certificate expiration date as epoch
-	CertificateEpoch = prometheus.NewGaugeVec(
+	CertificateEpoch = NewLabeledGaugeVec(
 		prometheus.GaugeOpts{
 			Name: "certalert_certificate_epoch_seconds",
 			Help: "The expiration date of the certificate as a epoch",
 		},
-		[]string{"instance", "subject", "type", "reason"},
+		[]string{"instance", "subject", "type", "role", "reason"},
 	)
 
 	// New metric to track failed certificate extractions
-	CertificateExtractionStatus = prometheus.NewGaugeVec(
+	CertificateExtractionStatus = NewLabeledGaugeVec(
 		prometheus.GaugeOpts{
 			Name: "certalert_certificate_extraction_status",
 			Help: "Status of certificate extraction (0=success, 1=failure)",
 		},
-		[]string{"instance", "subject", "type", "reason"},
+		[]string{"instance", "subject", "type", "role", "reason"},
+	)
+
+	// Metric to track the date a certificate is valid from as epoch
+	CertificateNotBefore = NewLabeledGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "certalert_certificate_not_before_seconds",
+			Help: "The date the certificate is valid from as a epoch",
+		},
+		[]string{"instance", "subject", "type", "role"},
+	)
+
+	// Metric to track the validity state of a certificate
+	CertificateValidityState = NewLabeledGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "certalert_certificate_validity_state",
+			Help: "The validity state of the certificate (1 for the current state, 0 for the other states)",
+		},
+		[]string{"instance", "subject", "state"},
+	)
+
+	// Metric to track if a certificate matches its expected names and issuer
+	CertificateExpectationMismatch = NewLabeledGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "certalert_certificate_expectation_mismatch",
+			Help: "Status of the expected names and issuer check (0=match, 1=mismatch)",
+		},
+		[]string{"instance", "subject", "mismatches"},
+	)
+
+	// Metric to track if a certificate matches one of its pins
+	CertificatePinMismatch = NewLabeledGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "certalert_certificate_pin_mismatch",
+			Help: "Status of the fingerprint and public key pin check (0=match, 1=mismatch)",
+		},
+		[]string{"instance", "subject"},
+	)
+
+	// Metric to track the violations of the policy rules
+	CertificatePolicyViolation = NewLabeledGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "certalert_certificate_policy_violation",
+			Help: "A violation of a policy rule by a certificate (always 1)",
+		},
+		[]string{"instance", "subject", "rule", "severity"},
+	)
+
+	// Metric to track the status of a certificate derived from its thresholds
+	CertificateStatus = NewLabeledGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "certalert_certificate_status",
+			Help: "The status of the certificate derived from its thresholds (0=ok, 1=warning, 2=critical, 3=expired)",
+		},
+		[]string{"instance", "subject", "type", "role"},
+	)
+
+	// Metric to track the remaining ratio of the lifetime of a certificate
+	CertificateLifetimeRemainingRatio = NewLabeledGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "certalert_certificate_lifetime_remaining_ratio",
+			Help: "The remaining ratio of the lifetime between the NotBefore and NotAfter date of the certificate (0 to 1)",
+		},
+		[]string{"instance", "subject", "type", "role"},
+	)
+
+	// Metric to track the warning threshold of a certificate in seconds
+	CertificateWarningThreshold = NewLabeledGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "certalert_certificate_warning_threshold_seconds",
+			Help: "The remaining validity at which the certificate changes to the warning status in seconds",
+		},
+		[]string{"instance", "subject"},
+	)
+
+	// Metric to track the critical threshold of a certificate in seconds
+	CertificateCriticalThreshold = NewLabeledGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "certalert_certificate_critical_threshold_seconds",
+			Help: "The remaining validity at which the certificate changes to the critical status in seconds",
+		},
+		[]string{"instance", "subject"},
+	)
+
+	// Metric to track if the last reload of the config was successful
+	ConfigLastReloadSuccessful = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "certalert_config_last_reload_successful",
+			Help: "Whether the last reload of the config was successful (1=success, 0=failure)",
+		},
+	)
+
+	// Metric to track the time of the last successful reload of the config as epoch
+	ConfigLastReloadSuccessTimestamp = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "certalert_config_last_reload_success_timestamp_seconds",
+			Help: "The time of the last successful reload of the config as a epoch",
+		},
+	)
+
+	// Metric to track the time of the last failed reload of the config as epoch
+	ConfigLastReloadFailureTimestamp = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "certalert_config_last_reload_failure_timestamp_seconds",
+			Help: "The time of the last failed reload of the config as a epoch",
+		},
+	)
+
+	// Metric to track the expiration date of each distinct certificate as epoch, if deduplication is enabled
+	DeduplicatedCertificateEpoch = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "certalert_deduplicated_certificate_epoch_seconds",
+			Help: "The expiration date of a distinct certificate, identified by its SHA-256 fingerprint, as a epoch",
+		},
+		[]string{"fingerprint", "subject"},
+	)
+
+	// Metric to track how many locations contain a distinct certificate, if deduplication is enabled
+	DeduplicatedCertificateLocations = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "certalert_deduplicated_certificate_locations",
+			Help: "The number of locations containing a distinct certificate",
+		},
+		[]string{"fingerprint", "subject"},
+	)
+
+	// Metric to list the locations containing a distinct certificate, if deduplication is enabled
+	DeduplicatedCertificateLocationInfo = NewLabeledGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "certalert_deduplicated_certificate_location_info",
+			Help: "A location containing a distinct certificate (always 1)",
+		},
+		[]string{"fingerprint", "instance", "location"},
 	)
 )
 
@@ -46,6 +178,21 @@ func NewMetrics() *Metrics {
 	reg := prometheus.NewRegistry()
 	reg.Register(CertificateEpoch)            // Register the global metric
 	reg.Register(CertificateExtractionStatus) // Register the new metric
+	reg.Register(CertificateNotBefore)
+	reg.Register(CertificateValidityState)
+	reg.Register(CertificateExpectationMismatch)
+	reg.Register(CertificatePinMismatch)
+	reg.Register(CertificatePolicyViolation)
+	reg.Register(CertificateStatus)
+	reg.Register(CertificateLifetimeRemainingRatio)
+	reg.Register(CertificateWarningThreshold)
+	reg.Register(CertificateCriticalThreshold)
+	reg.Register(ConfigLastReloadSuccessful)
+	reg.Register(ConfigLastReloadSuccessTimestamp)
+	reg.Register(ConfigLastReloadFailureTimestamp)
+	reg.Register(DeduplicatedCertificateEpoch)
+	reg.Register(DeduplicatedCertificateLocations)
+	reg.Register(DeduplicatedCertificateLocationInfo)
 
 	return &Metrics{
 		Registry: reg,
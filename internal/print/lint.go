@@ -0,0 +1,74 @@
+package print
+
+import (
+	"certalert/internal/certificates"
+	"fmt"
+)
+
+// PolicyViolation represents a violated policy rule together with the certificate violating it.
+type PolicyViolation struct {
+	Name     string `mapstructure:"name"`
+	Subject  string `mapstructure:"subject"`
+	Rule     string `mapstructure:"rule"`
+	Severity string `mapstructure:"severity"`
+	Message  string `mapstructure:"message"`
+}
+
+// LintCertificates processes the provided certificates and lints them with the policy.
+//
+// Parameters:
+//   - certs: []certificates.Certificate
+//     The list of certificates to lint.
+//   - policy: certificates.Policy
+//     The policy with the severities of the rules.
+//   - failOnError: bool
+//     A flag indicating whether to fail on errors during certificate processing.
+//
+// Returns:
+//   - []PolicyViolation
+//     A policy violation per finding, in the order of the certificates.
+//   - error
+//     An error if certificate processing fails.
+func LintCertificates(certs []certificates.Certificate, policy certificates.Policy, failOnError bool) ([]PolicyViolation, error) {
+	certificatesInfo, err := certificates.Process(certs, failOnError)
+	if err != nil {
+		return nil, err
+	}
+
+	certificates.Lint(certificatesInfo, policy)
+
+	var violations []PolicyViolation
+	for _, certInfo := range certificatesInfo {
+		for _, finding := range certInfo.Findings {
+			violations = append(violations, PolicyViolation{
+				Name:     certInfo.Name,
+				Subject:  certInfo.Subject,
+				Rule:     finding.Rule,
+				Severity: finding.Severity,
+				Message:  finding.Message,
+			})
+		}
+	}
+
+	return violations, nil
+}
+
+// ConvertPolicyViolationsToFormat converts the provided policy violations to the specified output format.
+//
+// Parameters:
+//   - outputFormat: string
+//     The desired output format ("yaml", "json", or "text").
+//   - violations: []PolicyViolation
+//     The policy violations to convert. Must not be empty for the "text" format.
+//
+// Returns:
+//   - string
+//     The formatted output as a string.
+//   - error
+//     An error if the conversion fails.
+func ConvertPolicyViolationsToFormat(outputFormat string, violations []PolicyViolation) (string, error) {
+	if handler, exists := FormatHandlers[outputFormat]; exists {
+		return handler(violations)
+	}
+	return "", fmt.Errorf("Unsupported output format: %s", outputFormat)
+}
@@ -3,6 +3,8 @@ package print
 import (
 	"certalert/internal/certificates"
 	"fmt"
+
+	"github.com/rs/zerolog/log"
 )
 
 // FormatHandlers maps each output format to its corresponding conversion function.
@@ -40,3 +42,38 @@ func ConvertCertificatesToFormat(outputFormat string, certs []certificates.Certi
 	}
 	return "", fmt.Errorf("Unsupported output format: %s", outputFormat)
 }
+
+// ConvertDeduplicatedCertificatesToFormat converts the provided certificates, grouped by their SHA-256
+// fingerprint, to the specified output format. Every distinct certificate is printed once together with
+// the locations containing it. Certificates which could not be extracted are logged, as they can't be grouped.
+//
+// Parameters:
+//   - outputFormat: string
+//     The desired output format ("yaml", "json", or "text").
+//   - certs: []certificates.Certificate
+//     The list of certificates to convert.
+//   - failOnError: bool
+//     A flag indicating whether to fail on errors during certificate processing.
+//
+// Returns:
+//   - string
+//     The formatted output as a string.
+//   - error
+//     An error if certificate processing or conversion fails.
+func ConvertDeduplicatedCertificatesToFormat(outputFormat string, certs []certificates.Certificate, failOnError bool) (string, error) {
+	handler, exists := FormatHandlers[outputFormat]
+	if !exists {
+		return "", fmt.Errorf("Unsupported output format: %s", outputFormat)
+	}
+
+	deduplicated, failed, err := certificates.ProcessDeduplicated(certs, failOnError)
+	if err != nil {
+		return "", err
+	}
+
+	for _, certInfo := range failed {
+		log.Warn().Msgf("Failed to extract certificate '%s'. %s", certInfo.Name, certInfo.Error)
+	}
+
+	return handler(deduplicated)
+}
@@ -40,3 +40,45 @@ func TestConvertCertificatesToFormat(t *testing.T) {
 	assert.NotNil(t, err)
 	assert.Equal(t, "Unknown certificate type 'invalid'", err.Error())
 }
+
+func TestConvertDeduplicatedCertificatesToFormat(t *testing.T) {
+	certs := []certificates.Certificate{
+		{Name: "chain", Path: "../../tests/certs/pem/chain.pem", Type: "pem"},
+		{Name: "root", Path: "../../tests/certs/pem/root.crt", Type: "pem"},
+	}
+
+	for _, format := range utils.ExtractMapKeys(FormatHandlers) {
+		_, err := ConvertDeduplicatedCertificatesToFormat(format, certs, true)
+		assert.Nil(t, err)
+	}
+
+	output, err := ConvertDeduplicatedCertificatesToFormat("yaml", certs, true)
+	assert.Nil(t, err)
+	assert.Contains(t, output, "fingerprint: 427bac57fe276aa65a220ec63df5a137dac5d3e100ca716951ce07fc73120634")
+	assert.Contains(t, output, "locations:\n    - name: chain\n      type: pem\n    - name: root\n      type: pem\n")
+
+	_, err = ConvertDeduplicatedCertificatesToFormat("unsupported", certs, true)
+	assert.EqualError(t, err, "Unsupported output format: unsupported")
+}
+
+func TestLintCertificates(t *testing.T) {
+	certs := []certificates.Certificate{
+		{Name: "chain", Path: "../../tests/certs/pem/chain.pem", Type: "pem"},
+	}
+
+	violations, err := LintCertificates(certs, certificates.Policy{}, true)
+	assert.Nil(t, err)
+	assert.Len(t, violations, 3)
+	assert.Equal(t, PolicyViolation{Name: "chain", Subject: "CN=final", Rule: "unencrypted-private-key", Severity: "warning", Message: "File contains an unencrypted private key."}, violations[0])
+
+	violations, err = LintCertificates(certs, certificates.Policy{Rules: map[string]string{"unencrypted-private-key": "off"}}, true)
+	assert.Nil(t, err)
+	assert.Empty(t, violations)
+
+	output, err := ConvertPolicyViolationsToFormat("json", []PolicyViolation{{Name: "chain", Rule: "dsa-key"}})
+	assert.Nil(t, err)
+	assert.Contains(t, output, `"Rule": "dsa-key"`)
+
+	_, err = ConvertPolicyViolationsToFormat("unsupported", nil)
+	assert.EqualError(t, err, "Unsupported output format: unsupported")
+}
@@ -2,9 +2,13 @@ package print
 
 import (
 	"bytes"
+	"certalert/internal/certificates"
 	"encoding/json"
 	"fmt"
 	"reflect"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/kataras/tablewriter"
 	"gopkg.in/yaml.v3"
@@ -53,6 +57,8 @@ func convertToJson(output interface{}) (string, error) {
 }
 
 // convertToTable converts the provided data to a table format.
+// Map fields tagged with 'print:"columns"' are expanded into a column per key of the maps of all items,
+// e.g. to print the custom labels of certificates.
 //
 // Parameters:
 //   - data: interface{}
@@ -77,21 +83,53 @@ func convertToTable(data interface{}) (string, error) {
 		return "", fmt.Errorf("Empty slice provided")
 	}
 
+	// column is a field of the items or a key of an expanded map field
+	type column struct {
+		field int
+		key   string
+	}
+
 	firstItem := s.Index(0)
 	numFields := firstItem.NumField()
 	var headers []string
+	var columns []column
 
 	for i := 0; i < numFields; i++ {
-		headers = append(headers, firstItem.Type().Field(i).Tag.Get("json"))
+		field := firstItem.Type().Field(i)
+		// Unexported fields can't be read and are internal details
+		if !field.IsExported() {
+			continue
+		}
+		if field.Tag.Get("print") == "columns" && field.Type.Kind() == reflect.Map {
+			for _, key := range mapKeys(s, i) {
+				headers = append(headers, key)
+				columns = append(columns, column{field: i, key: key})
+			}
+			continue
+		}
+		headers = append(headers, field.Tag.Get("json"))
+		columns = append(columns, column{field: i})
 	}
 	table.SetHeader(headers)
 
 	for i := 0; i < s.Len(); i++ {
 		item := s.Index(i)
 		var row []string
-		for j := 0; j < numFields; j++ {
-			field := item.Field(j)
-			row = append(row, fmt.Sprintf("%v", field.Interface()))
+		for _, c := range columns {
+			field := item.Field(c.field)
+			if c.key != "" {
+				value := field.MapIndex(reflect.ValueOf(c.key))
+				if !value.IsValid() {
+					row = append(row, "")
+					continue
+				}
+				field = value
+			}
+			if c.key == "" && item.Type().Field(c.field).Tag.Get("print") == "seconds" {
+				row = append(row, formatSeconds(field.Int()))
+				continue
+			}
+			row = append(row, formatCell(field))
 		}
 		table.Append(row)
 	}
@@ -108,3 +146,68 @@ func convertToTable(data interface{}) (string, error) {
 
 	return output.String(), nil
 }
+
+// formatCell formats the value of a field for a table cell. Maps of strings, like the labels of the source
+// of a certificate, are formatted as sorted comma separated 'key=value' pairs. Slices, like the mismatches
+// of a certificate, are formatted as comma separated values. Empty maps and slices are left blank.
+//
+// Parameters:
+//   - value: reflect.Value
+//     The value of the field.
+//
+// Returns:
+//   - string
+//     The formatted value.
+func formatCell(value reflect.Value) string {
+	if labels, ok := value.Interface().(map[string]string); ok {
+		return certificates.FormatLabels(labels)
+	}
+	if value.Kind() == reflect.Slice {
+		items := make([]string, value.Len())
+		for i := range items {
+			items[i] = formatCell(value.Index(i))
+		}
+		return strings.Join(items, ",")
+	}
+	return fmt.Sprintf("%v", value.Interface())
+}
+
+// formatSeconds formats a number of seconds, like the thresholds of a certificate, as a duration, e.g. '720h0m0s'.
+//
+// Parameters:
+//   - seconds: int64
+//     The number of seconds. Left blank if 0.
+//
+// Returns:
+//   - string
+//     The formatted duration.
+func formatSeconds(seconds int64) string {
+	if seconds == 0 {
+		return ""
+	}
+	return (time.Duration(seconds) * time.Second).String()
+}
+
+// mapKeys returns the sorted keys of a map field of all items of a slice.
+//
+// Parameters:
+//   - s: reflect.Value
+//     The slice of structs.
+//   - field: int
+//     The index of the map field.
+//
+// Returns:
+//   - []string
+//     The sorted keys of the maps.
+func mapKeys(s reflect.Value, field int) []string {
+	var keys []string
+	for i := 0; i < s.Len(); i++ {
+		for _, key := range s.Index(i).Field(field).MapKeys() {
+			if !slices.Contains(keys, key.String()) {
+				keys = append(keys, key.String())
+			}
+		}
+	}
+	slices.Sort(keys)
+	return keys
+}
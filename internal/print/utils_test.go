@@ -1,6 +1,7 @@
 package print
 
 import (
+	"certalert/internal/certificates"
 	"fmt"
 	"strings"
 	"testing"
@@ -109,3 +110,60 @@ func TestConvertToTableEmptySlice(t *testing.T) {
 	assert.NotNil(t, err)
 	assert.Equal(t, "Empty slice provided", err.Error())
 }
+
+func TestConvertToTableUnexportedFields(t *testing.T) {
+	type withUnexported struct {
+		ID     int    `json:"id"`
+		secret string // not printed
+		Name   string `json:"name"`
+	}
+
+	result, err := convertToTable([]withUnexported{{1, "hidden", "John"}})
+	assert.Nil(t, err)
+	assert.Equal(t, "  ID  NAME  \n  1   John  \n", result)
+}
+
+func TestConvertToTableColumns(t *testing.T) {
+	type withLabels struct {
+		ID     int               `json:"id"`
+		Labels map[string]string `print:"columns"`
+	}
+
+	result, err := convertToTable([]withLabels{
+		{1, map[string]string{"team": "web"}},
+		{2, map[string]string{"env": "prod", "team": "db"}},
+		{3, nil},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, "  ID  ENV   TEAM  \n  1         web   \n  2   prod  db    \n  3               \n", result)
+}
+
+func TestConvertToTableLabels(t *testing.T) {
+	type withLabels struct {
+		ID     int               `json:"id"`
+		Labels map[string]string `json:"labels"`
+	}
+
+	result, err := convertToTable([]withLabels{
+		{1, map[string]string{"secret": "web-tls", "namespace": "shop"}},
+		{2, nil},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, "  ID  LABELS                         \n  1   namespace=shop,secret=web-tls  \n  2                                  \n", result)
+}
+
+func TestConvertToTableSlicesAndSeconds(t *testing.T) {
+	type withSlices struct {
+		ID        int                    `json:"id"`
+		Names     []string               `json:"names"`
+		Findings  []certificates.Finding `json:"findings"`
+		Threshold int64                  `json:"threshold" print:"seconds"`
+	}
+
+	result, err := convertToTable([]withSlices{
+		{1, []string{"dns:example.com", "ip:10.0.0.1"}, []certificates.Finding{{Rule: "weak-key", Severity: "critical"}}, 2592000},
+		{2, nil, nil, 0},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, "  ID  NAMES                        FINDINGS           THRESHOLD  \n  1   dns:example.com,ip:10.0.0.1  weak-key:critical  720h0m0s   \n  2                                                              \n", result)
+}
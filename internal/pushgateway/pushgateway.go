@@ -3,8 +3,11 @@ package pushgateway
 import (
 	"certalert/internal/certificates"
 	"certalert/internal/config"
+	"certalert/internal/metrics"
 	"certalert/internal/utils"
 	"fmt"
+	"maps"
+	"slices"
 
 	"github.com/rs/zerolog/log"
 )
@@ -24,11 +27,13 @@ import (
 //     Whether to skip TLS certificate verification when communicating with the Pushgateway.
 //   - failOnError: bool
 //     Whether to fail on processing errors for individual certificates.
+//   - labels: map[string]string
+//     The global labels, used as grouping key. The other custom labels of the certificates are added to the metrics.
 //
 // Returns:
 //   - error
 //     An error if the push to the Pushgateway fails or if there are errors processing individual certificates.
-func Send(address string, jobName string, auth config.Auth, certs []certificates.Certificate, insecureSkipVerify bool, failOnError bool) error {
+func Send(address string, jobName string, auth config.Auth, certs []certificates.Certificate, insecureSkipVerify bool, failOnError bool, labels map[string]string) error {
 	if address == "" {
 		return fmt.Errorf("Pushgateway address is empty")
 	}
@@ -37,14 +42,24 @@ func Send(address string, jobName string, auth config.Auth, certs []certificates
 		return fmt.Errorf("Invalid pushgateway address '%s'", address)
 	}
 
-	pusher := createPusher(address, jobName, auth, insecureSkipVerify)
+	pusher := createPusher(address, jobName, auth, insecureSkipVerify, labels)
 
 	certificatesInfo, err := certificates.Process(certs, failOnError)
 	if err != nil {
 		return fmt.Errorf("Failed to process certificates: %w", err)
 	}
 
+	// Pushed metrics must not contain the labels of the grouping key
+	metrics.SetCustomLabels(certificates.CustomLabelsByName(certificatesInfo, slices.Collect(maps.Keys(labels))...))
+
 	for _, certificateInfo := range certificatesInfo {
+		// Neither must the labels of the source of the certificate
+		certificateInfo.Labels = maps.Clone(certificateInfo.Labels)
+		maps.DeleteFunc(certificateInfo.Labels, func(name, _ string) bool {
+			_, grouping := labels[name]
+			return grouping
+		})
+
 		if err := pushToGateway(pusher, certificateInfo); err != nil {
 			return fmt.Errorf("Failed to push certificate info to gateway: %w", err)
 		}
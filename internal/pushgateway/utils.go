@@ -7,8 +7,11 @@ import (
 	"certalert/internal/utils"
 	"crypto/tls"
 	"fmt"
+	"maps"
 	"net/http"
+	"slices"
 
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/push"
 )
 
@@ -23,11 +26,13 @@ import (
 //     The authentication configuration for the Pusher.
 //   - insecureSkipVerify: bool
 //     Whether to skip TLS certificate verification when communicating with the Pushgateway.
+//   - grouping: map[string]string
+//     The labels added to the grouping key of the pushed metrics.
 //
 // Returns:
 //   - *push.Pusher
 //     A configured Pusher for pushing metrics.
-func createPusher(address, job string, auth config.Auth, insecureSkipVerify bool) *push.Pusher {
+func createPusher(address, job string, auth config.Auth, insecureSkipVerify bool, grouping map[string]string) *push.Pusher {
 	var httpClient *http.Client
 	if insecureSkipVerify {
 		tr := &http.Transport{
@@ -42,6 +47,10 @@ func createPusher(address, job string, auth config.Auth, insecureSkipVerify bool
 		Collector(metrics.CertificateEpoch).
 		Client(httpClient)
 
+	for _, name := range slices.Sorted(maps.Keys(grouping)) {
+		pusher = pusher.Grouping(name, grouping[name])
+	}
+
 	if utils.HasStructField(auth, "Bearer.Token") && auth.Bearer.Token != "" {
 		pusher = pusher.BasicAuth("Bearer", auth.Bearer.Token)
 	} else if utils.HasStructField(auth, "Basic.Username") && auth.Basic.Username != "" {
@@ -63,7 +72,13 @@ func createPusher(address, job string, auth config.Auth, insecureSkipVerify bool
 //   - error
 //     An error if the push to the Pushgateway fails.
 func pushToGateway(pusher *push.Pusher, cert certificates.CertificateInfo) error {
-	gauge := metrics.CertificateEpoch.WithLabelValues(cert.Name, cert.Type, cert.Subject)
+	gauge := metrics.CertificateEpoch.WithSource(prometheus.Labels{
+		"instance": cert.Name,
+		"subject":  cert.Subject,
+		"type":     cert.Type,
+		"role":     cert.Role,
+		"reason":   "none",
+	}, cert.Labels)
 	gauge.Set(float64(cert.Epoch))
 
 	if err := pusher.Push(); err != nil {
@@ -0,0 +1,34 @@
+package resolve
+
+import (
+	"encoding/base64"
+	"fmt"
+)
+
+// base64Prefix is the prefix to identify base64 encoded values
+const base64Prefix = "base64:"
+
+func init() {
+	// The encoded value is the secret itself, so it is redacted like a plain value
+	Register(base64Prefix, resolveBase64Variable, true)
+}
+
+// resolveBase64Variable decodes a base64 encoded value, e.g. a password containing characters
+// which are hard to escape in the config file.
+//
+// Parameters:
+//   - encoded: string
+//     The base64 encoded value.
+//
+// Returns:
+//   - string
+//     The decoded value.
+//   - error
+//     An error if the value is not valid base64.
+func resolveBase64Variable(encoded string) (string, error) {
+	decoded, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", fmt.Errorf("Invalid base64 value. %v", err)
+	}
+	return string(decoded), nil
+}
@@ -0,0 +1,175 @@
+package resolve
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"filippo.io/age"
+	"filippo.io/age/armor"
+)
+
+// encPrefix is the prefix to identify values encrypted with age
+const encPrefix = "enc:"
+
+// AgeIdentityFile is the path of the age identity file used to decrypt 'enc:' and 'enc:sops:' values. It is set
+// by the --age-identity flag, the CERTALERT_AGE_IDENTITY or the SOPS_AGE_KEY_FILE environment variable.
+var AgeIdentityFile string
+
+func init() {
+	for _, env := range []string{"CERTALERT_AGE_IDENTITY", "SOPS_AGE_KEY_FILE"} {
+		if path, found := os.LookupEnv(env); found {
+			AgeIdentityFile = path
+			break
+		}
+	}
+	// The encrypted value doesn't reveal the secret, so it is shown on the /config endpoint
+	Register(encPrefix, resolveEncVariable, false)
+}
+
+// resolveEncVariable decrypts a value encrypted with age. The value is either the base64 encoded
+// ciphertext, as produced by 'certalert config encrypt', or the ASCII armored ciphertext produced by
+// 'age --armor', which can be written as YAML block scalar.
+//
+// Parameters:
+//   - ciphertext: string
+//     The encrypted value.
+//
+// Returns:
+//   - string
+//     The decrypted value.
+//   - error
+//     An error if the identity file can't be read or the value can't be decrypted.
+func resolveEncVariable(ciphertext string) (string, error) {
+	identities, err := readIdentities(AgeIdentityFile)
+	if err != nil {
+		return "", err
+	}
+
+	var src io.Reader
+	ciphertext = strings.TrimSpace(ciphertext)
+	if strings.HasPrefix(ciphertext, armor.Header) {
+		src = armor.NewReader(strings.NewReader(ciphertext))
+	} else {
+		data, err := base64.StdEncoding.DecodeString(ciphertext)
+		if err != nil {
+			return "", fmt.Errorf("Invalid encrypted value. %v", err)
+		}
+		src = bytes.NewReader(data)
+	}
+
+	r, err := age.Decrypt(src, identities...)
+	if err != nil {
+		return "", fmt.Errorf("Failed to decrypt value. %v", err)
+	}
+
+	plaintext, err := io.ReadAll(r)
+	if err != nil {
+		return "", fmt.Errorf("Failed to decrypt value. %v", err)
+	}
+
+	return string(plaintext), nil
+}
+
+// Encrypt encrypts a value with age for the given recipients and returns it as 'enc:' value.
+//
+// Parameters:
+//   - plaintext: string
+//     The value to encrypt.
+//   - recipients: []string
+//     The age public keys, like 'age1...', which can decrypt the value.
+//
+// Returns:
+//   - string
+//     The 'enc:' value with the base64 encoded ciphertext.
+//   - error
+//     An error if a recipient is invalid or the encryption fails.
+func Encrypt(plaintext string, recipients []string) (string, error) {
+	if len(recipients) == 0 {
+		return "", fmt.Errorf("No recipient defined.")
+	}
+
+	var parsed []age.Recipient
+	for _, recipient := range recipients {
+		r, err := age.ParseX25519Recipient(recipient)
+		if err != nil {
+			return "", fmt.Errorf("Invalid recipient '%s'. %v", recipient, err)
+		}
+		parsed = append(parsed, r)
+	}
+
+	var buf bytes.Buffer
+	w, err := age.Encrypt(&buf, parsed...)
+	if err != nil {
+		return "", fmt.Errorf("Failed to encrypt value. %v", err)
+	}
+	if _, err := io.WriteString(w, plaintext); err != nil {
+		return "", fmt.Errorf("Failed to encrypt value. %v", err)
+	}
+	if err := w.Close(); err != nil {
+		return "", fmt.Errorf("Failed to encrypt value. %v", err)
+	}
+
+	return encPrefix + base64.StdEncoding.EncodeToString(buf.Bytes()), nil
+}
+
+// IdentityRecipients returns the public keys of the identities in the age identity file, so values
+// can be encrypted for the identity CertAlert decrypts them with.
+//
+// Parameters:
+//   - path: string
+//     The path of the age identity file.
+//
+// Returns:
+//   - []string
+//     The public keys of the identities.
+//   - error
+//     An error if the identity file can't be read.
+func IdentityRecipients(path string) ([]string, error) {
+	identities, err := readIdentities(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var recipients []string
+	for _, identity := range identities {
+		if x25519, ok := identity.(*age.X25519Identity); ok {
+			recipients = append(recipients, x25519.Recipient().String())
+		}
+	}
+
+	return recipients, nil
+}
+
+// readIdentities reads the identities of an age identity file.
+//
+// Parameters:
+//   - path: string
+//     The path of the age identity file.
+//
+// Returns:
+//   - []age.Identity
+//     The identities of the file.
+//   - error
+//     An error if the path is not set or the file can't be read.
+func readIdentities(path string) ([]age.Identity, error) {
+	if path == "" {
+		return nil, fmt.Errorf("No age identity file defined. Use --age-identity or CERTALERT_AGE_IDENTITY.")
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to open age identity file '%s'. %v", path, err)
+	}
+	defer file.Close()
+
+	identities, err := age.ParseIdentities(file)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read age identity file '%s'. %v", path, err)
+	}
+
+	return identities, nil
+}
@@ -0,0 +1,117 @@
+package resolve
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"filippo.io/age"
+	"filippo.io/age/armor"
+)
+
+// writeIdentityFile generates an age identity, writes it to a temporary identity file and sets AgeIdentityFile.
+func writeIdentityFile(t *testing.T) *age.X25519Identity {
+	identity, err := age.GenerateX25519Identity()
+	if err != nil {
+		t.Fatalf("Failed to generate identity: %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "key.txt")
+	if err := os.WriteFile(path, []byte("# created for tests\n"+identity.String()+"\n"), 0o600); err != nil {
+		t.Fatalf("Failed to write identity file: %v", err)
+	}
+
+	oldPath := AgeIdentityFile
+	AgeIdentityFile = path
+	t.Cleanup(func() { AgeIdentityFile = oldPath })
+
+	return identity
+}
+
+func TestResolveEncVariable(t *testing.T) {
+	identity := writeIdentityFile(t)
+
+	t.Run("encrypt and decrypt", func(t *testing.T) {
+		recipients, err := IdentityRecipients(AgeIdentityFile)
+		if err != nil {
+			t.Fatalf("Failed to read recipients: %v", err)
+		}
+		if len(recipients) != 1 || recipients[0] != identity.Recipient().String() {
+			t.Fatalf("Expected recipient '%s', got %v", identity.Recipient(), recipients)
+		}
+
+		encrypted, err := Encrypt("changeit", recipients)
+		if err != nil {
+			t.Fatalf("Failed to encrypt value: %v", err)
+		}
+		if !strings.HasPrefix(encrypted, "enc:") || strings.Contains(encrypted, "changeit") {
+			t.Fatalf("Unexpected encrypted value '%s'", encrypted)
+		}
+
+		result, err := ResolveVariable(encrypted)
+		if err != nil {
+			t.Fatalf("Failed to resolve variable: %v", err)
+		}
+		if result != "changeit" {
+			t.Fatalf("Expected 'changeit', got '%s'", result)
+		}
+	})
+
+	t.Run("armored value", func(t *testing.T) {
+		var buf bytes.Buffer
+		armored := armor.NewWriter(&buf)
+		w, err := age.Encrypt(armored, identity.Recipient())
+		if err != nil {
+			t.Fatalf("Failed to encrypt value: %v", err)
+		}
+		io.WriteString(w, "changeit")
+		w.Close()
+		armored.Close()
+
+		result, err := ResolveVariable("enc:" + buf.String())
+		if err != nil {
+			t.Fatalf("Failed to resolve variable: %v", err)
+		}
+		if result != "changeit" {
+			t.Fatalf("Expected 'changeit', got '%s'", result)
+		}
+	})
+
+	t.Run("value for another identity", func(t *testing.T) {
+		other, _ := age.GenerateX25519Identity()
+		encrypted, err := Encrypt("changeit", []string{other.Recipient().String()})
+		if err != nil {
+			t.Fatalf("Failed to encrypt value: %v", err)
+		}
+
+		_, err = ResolveVariable(encrypted)
+		if err == nil || !strings.HasPrefix(err.Error(), "Failed to decrypt value. ") {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	})
+
+	t.Run("invalid value", func(t *testing.T) {
+		_, err := ResolveVariable("enc:not base64")
+		if err == nil || !strings.HasPrefix(err.Error(), "Invalid encrypted value. ") {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	})
+
+	t.Run("invalid recipient", func(t *testing.T) {
+		_, err := Encrypt("changeit", []string{"age1invalid"})
+		if err == nil || !strings.HasPrefix(err.Error(), "Invalid recipient 'age1invalid'. ") {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	})
+
+	t.Run("no identity file", func(t *testing.T) {
+		AgeIdentityFile = ""
+		_, err := ResolveVariable("enc:YWJj")
+		if err == nil || err.Error() != "No age identity file defined. Use --age-identity or CERTALERT_AGE_IDENTITY." {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	})
+}
@@ -0,0 +1,93 @@
+package resolve
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// execPrefix is the prefix to identify command references
+const execPrefix = "exec:"
+
+// ExecTimeout is the time a command of an 'exec:' reference may run. It is set by the --exec-timeout flag.
+var ExecTimeout = 10 * time.Second
+
+func init() {
+	Register(execPrefix, resolveExecVariable, false)
+}
+
+// IsCommand reports if the value is an 'exec:' reference.
+//
+// Parameters:
+//   - value: string
+//     The value to check.
+//
+// Returns:
+//   - bool
+//     True if the value runs a command when it is resolved.
+func IsCommand(value string) bool {
+	return strings.HasPrefix(value, execPrefix)
+}
+
+// CheckCommand checks an 'exec:' reference without running its command. The command must not be empty
+// and its executable must be found, either as path or in the directories of the PATH environment variable.
+//
+// Parameters:
+//   - value: string
+//     The 'exec:' reference.
+//
+// Returns:
+//   - error
+//     An error if the command is empty or its executable can't be found.
+func CheckCommand(value string) error {
+	args := strings.Fields(strings.TrimPrefix(value, execPrefix))
+	if len(args) == 0 {
+		return fmt.Errorf("Command is empty.")
+	}
+
+	if _, err := exec.LookPath(args[0]); err != nil {
+		return fmt.Errorf("Command '%s' not found. %v", args[0], err)
+	}
+	return nil
+}
+
+// resolveExecVariable runs a command and returns its output, e.g. to read a password from a password manager.
+// The command is split at whitespace and run without a shell. Leading and trailing whitespace of the
+// output is removed.
+//
+// Parameters:
+//   - command: string
+//     The command with its arguments.
+//
+// Returns:
+//   - string
+//     The standard output of the command.
+//   - error
+//     An error if the command fails, exits with a non-zero status or runs longer than ExecTimeout.
+func resolveExecVariable(command string) (string, error) {
+	args := strings.Fields(command)
+	if len(args) == 0 {
+		return "", fmt.Errorf("Command is empty.")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), ExecTimeout)
+	defer cancel()
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return "", fmt.Errorf("Command '%s' timed out after %s.", args[0], ExecTimeout)
+		}
+		return "", fmt.Errorf("Command '%s' failed. %v: %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+
+	return strings.TrimSpace(stdout.String()), nil
+}
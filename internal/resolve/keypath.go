@@ -0,0 +1,144 @@
+package resolve
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// keyPathRoot is the root of a key path into a JSON or YAML file, e.g. '$.jks.password'.
+const keyPathRoot = "$"
+
+// searchKeyPathInFile parses a JSON or YAML file and returns the value at the specified key path.
+// The key path starts with '$' followed by the keys of maps, e.g. '$.jks.password', and the indexes
+// of lists, e.g. '$.keystores[0].password'.
+//
+// Parameters:
+//   - file: *os.File
+//     The opened JSON or YAML file.
+//   - keyPath: string
+//     The key path of the value.
+//
+// Returns:
+//   - string
+//     The value at the key path.
+//   - error
+//     An error if the file can't be parsed, the key path is invalid or doesn't point to a scalar value.
+func searchKeyPathInFile(file *os.File, keyPath string) (string, error) {
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return "", fmt.Errorf("Failed to read file '%s'. %v", file.Name(), err)
+	}
+
+	// JSON is a subset of YAML, so both formats are parsed as YAML
+	var content any
+	if err := yaml.Unmarshal(data, &content); err != nil {
+		return "", fmt.Errorf("Failed to parse file '%s' as JSON or YAML. %v", file.Name(), err)
+	}
+
+	content, _, err = valueAtKeyPath(content, keyPath, file.Name())
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprint(content), nil
+}
+
+// valueAtKeyPath returns the value at the specified key path of the parsed content of a JSON or YAML file.
+//
+// Parameters:
+//   - content: any
+//     The parsed content of the file.
+//   - keyPath: string
+//     The key path of the value.
+//   - fileName: string
+//     The name of the file, used in errors.
+//
+// Returns:
+//   - any
+//     The scalar value at the key path.
+//   - []string
+//     The keys of the maps on the key path, without the indexes of lists.
+//   - error
+//     An error if the key path is invalid or doesn't point to a scalar value.
+func valueAtKeyPath(content any, keyPath, fileName string) (any, []string, error) {
+	segments, err := splitKeyPath(keyPath)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var keys []string
+	for _, segment := range segments {
+		switch node := content.(type) {
+		case map[string]any:
+			value, found := node[segment]
+			if !found {
+				return nil, nil, fmt.Errorf("Key path '%s' not found in file '%s'.", keyPath, fileName)
+			}
+			content = value
+			keys = append(keys, segment)
+		case []any:
+			index, err := strconv.Atoi(segment)
+			if err != nil || index < 0 || index >= len(node) {
+				return nil, nil, fmt.Errorf("Key path '%s' not found in file '%s'.", keyPath, fileName)
+			}
+			content = node[index]
+		default:
+			return nil, nil, fmt.Errorf("Key path '%s' not found in file '%s'.", keyPath, fileName)
+		}
+	}
+
+	switch content.(type) {
+	case map[string]any, []any, nil:
+		return nil, nil, fmt.Errorf("Key path '%s' in file '%s' is not a value.", keyPath, fileName)
+	}
+
+	return content, keys, nil
+}
+
+// splitKeyPath splits a key path like '$.keystores[0].password' into its segments 'keystores', '0' and 'password'.
+//
+// Parameters:
+//   - keyPath: string
+//     The key path starting with '$'.
+//
+// Returns:
+//   - []string
+//     The keys and indexes of the key path.
+//   - error
+//     An error if the key path is invalid.
+func splitKeyPath(keyPath string) ([]string, error) {
+	rest := strings.TrimPrefix(keyPath, keyPathRoot)
+
+	var segments []string
+	for rest != "" {
+		switch rest[0] {
+		case '.':
+			end := strings.IndexAny(rest[1:], ".[")
+			if end == -1 {
+				end = len(rest) - 1
+			}
+			segments = append(segments, rest[1:end+1])
+			rest = rest[end+1:]
+		case '[':
+			end := strings.Index(rest, "]")
+			if end == -1 {
+				return nil, fmt.Errorf("Invalid key path '%s'. Missing ']'.", keyPath)
+			}
+			segments = append(segments, rest[1:end])
+			rest = rest[end+1:]
+		default:
+			return nil, fmt.Errorf("Invalid key path '%s'. Must look like '$.key.list[0]'.", keyPath)
+		}
+
+		if segments[len(segments)-1] == "" {
+			return nil, fmt.Errorf("Invalid key path '%s'. Must look like '$.key.list[0]'.", keyPath)
+		}
+	}
+
+	return segments, nil
+}
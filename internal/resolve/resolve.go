@@ -4,7 +4,9 @@ import (
 	"bufio"
 	"fmt"
 	"io"
+	"maps"
 	"os"
+	"slices"
 	"strings"
 )
 
@@ -15,15 +17,103 @@ const (
 	keyDelim   = "//"    // Delimiter to identify a key in a file
 )
 
+// resolver represents a registered reference scheme.
+type resolver struct {
+	resolve   func(reference string) (string, error) // resolve resolves the reference following the prefix
+	sensitive bool                                   // sensitive is set if the reference contains the secret itself
+}
+
+// resolvers maps the prefix of each reference scheme to its resolver.
+var resolvers = map[string]resolver{}
+
+func init() {
+	Register(envPrefix, resolveEnvVariable, false)
+	Register(filePrefix, resolveFileVariable, false)
+}
+
+// Register registers a reference scheme with its prefix, e.g. 'env:'.
+//
+// Parameters:
+//   - prefix: string
+//     The prefix identifying the references of the scheme, including the colon.
+//   - resolve: func(reference string) (string, error)
+//     The function resolving the reference following the prefix.
+//   - sensitive: bool
+//     Whether the reference contains the secret itself, e.g. an encoded value, and must be redacted.
+//
+// Panics:
+//   - If the prefix is already registered.
+func Register(prefix string, resolve func(reference string) (string, error), sensitive bool) {
+	if _, exists := resolvers[prefix]; exists {
+		panic(fmt.Sprintf("Resolver for prefix '%s' is already registered", prefix))
+	}
+
+	resolvers[prefix] = resolver{resolve: resolve, sensitive: sensitive}
+}
+
+// Prefixes returns the prefixes of all registered reference schemes.
+//
+// Returns:
+//   - []string
+//     The sorted prefixes.
+func Prefixes() []string {
+	return slices.Sorted(maps.Keys(resolvers))
+}
+
+// IsReference reports if the value starts with the prefix of a registered reference scheme.
+//
+// Parameters:
+//   - value: string
+//     The value to check.
+//
+// Returns:
+//   - bool
+//     True if the value is a reference.
+func IsReference(value string) bool {
+	_, _, found := lookup(value)
+	return found
+}
+
+// IsSensitive reports if the value is a reference which contains the secret itself, like 'base64:',
+// and must be redacted like a plain value.
+//
+// Parameters:
+//   - value: string
+//     The value to check.
+//
+// Returns:
+//   - bool
+//     True if the value is a sensitive reference.
+func IsSensitive(value string) bool {
+	r, _, found := lookup(value)
+	return found && r.sensitive
+}
+
+// lookup returns the resolver of the value and the reference following its prefix. The longest matching prefix wins.
+func lookup(value string) (resolver, string, bool) {
+	matched := ""
+	for prefix := range resolvers {
+		if strings.HasPrefix(value, prefix) && len(prefix) > len(matched) {
+			matched = prefix
+		}
+	}
+	if matched == "" {
+		return resolver{}, "", false
+	}
+	return resolvers[matched], value[len(matched):], true
+}
+
 // ResolveVariable takes a string and resolves its value based on its prefix.
 //
 // If the string is prefixed with "env:", it's treated as an environment variable and resolved accordingly.
 //
 // If the string is prefixed with "file:", it's treated as a path to a file, optionally followed by a key
 // (e.g., "file:/path/to/file//key") which specifies which line to retrieve from the file. The key is expected
-// to be in the format "key = value".
+// to be in the format "key = value". A key starting with "$" is a path into a JSON or YAML file
+// (e.g., "file:/secrets/creds.json//$.jks.password").
 //
-// If no prefix is present, the string is returned as is.
+// All other registered prefixes, like "exec:", "base64:" and "secretKeyRef:", are resolved by their
+// registered resolver. If no prefix is present, the string is returned as is.
 //
 // Parameters:
 //   - value: string
@@ -35,14 +125,9 @@ const (
 //   - error
 //     An error if the resolution fails.
 func ResolveVariable(value string) (string, error) {
-	if strings.HasPrefix(value, envPrefix) {
-		return resolveEnvVariable(value[len(envPrefix):])
+	if r, reference, found := lookup(value); found {
+		return r.resolve(reference)
 	}
-
-	if strings.HasPrefix(value, filePrefix) {
-		return resolveFileVariable(value[len(filePrefix):])
-	}
-
 	return value, nil
 }
 
@@ -67,7 +152,7 @@ func resolveEnvVariable(envVar string) (string, error) {
 }
 
 // resolveFileVariable resolves a string as a path to a file with an optional key.
-// The key is expected to be in the format "key = value".
+// The key is expected to be in the format "key = value" or to be a key path into a JSON or YAML file.
 //
 // Parameters:
 //   - filePathWithKey: string
@@ -96,6 +181,9 @@ func resolveFileVariable(filePathWithKey string) (string, error) {
 	}
 	defer file.Close()
 
+	if strings.HasPrefix(key, keyPathRoot) {
+		return searchKeyPathInFile(file, key)
+	}
 	if key != "" {
 		return searchKeyInFile(file, key)
 	}
@@ -0,0 +1,176 @@
+package resolve
+
+import (
+	"certalert/internal/test_helpers"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRegister(t *testing.T) {
+	t.Run("registered prefixes", func(t *testing.T) {
+		expected := []string{"base64:", "enc:", "enc:sops:", "env:", "exec:", "file:", "secretKeyRef:"}
+		prefixes := Prefixes()
+		if len(prefixes) != len(expected) {
+			t.Fatalf("Expected prefixes %v, got %v", expected, prefixes)
+		}
+		for i := range expected {
+			if prefixes[i] != expected[i] {
+				t.Fatalf("Expected prefixes %v, got %v", expected, prefixes)
+			}
+		}
+	})
+
+	t.Run("duplicate prefix panics", func(t *testing.T) {
+		defer func() {
+			if recover() == nil {
+				t.Fatalf("Expected a panic, got none")
+			}
+		}()
+		Register(envPrefix, resolveEnvVariable, false)
+	})
+
+	t.Run("references", func(t *testing.T) {
+		if !IsReference("exec:true") || IsReference("execute") || IsReference("") {
+			t.Fatalf("Unexpected result of IsReference")
+		}
+		if !IsSensitive("base64:c2VjcmV0") || IsSensitive("env:SECRET") {
+			t.Fatalf("Unexpected result of IsSensitive")
+		}
+	})
+}
+
+func TestResolveBase64Variable(t *testing.T) {
+	result, err := ResolveVariable("base64:cGFzcz13b3JkIQ==")
+	if err != nil {
+		t.Fatalf("Failed to resolve variable: %v", err)
+	}
+	if result != "pass=word!" {
+		t.Fatalf("Expected 'pass=word!', got '%s'", result)
+	}
+
+	_, err = ResolveVariable("base64:not base64")
+	if err == nil || err.Error() != "Invalid base64 value. illegal base64 data at input byte 3" {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+}
+
+func TestResolveExecVariable(t *testing.T) {
+	t.Run("output of the command", func(t *testing.T) {
+		result, err := ResolveVariable("exec:echo  secret ")
+		if err != nil {
+			t.Fatalf("Failed to resolve variable: %v", err)
+		}
+		if result != "secret" {
+			t.Fatalf("Expected 'secret', got '%s'", result)
+		}
+	})
+
+	t.Run("failing command", func(t *testing.T) {
+		_, err := ResolveVariable("exec:false")
+		if err == nil || err.Error() != "Command 'false' failed. exit status 1: " {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	})
+
+	t.Run("empty command", func(t *testing.T) {
+		_, err := ResolveVariable("exec: ")
+		if err == nil || err.Error() != "Command is empty." {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		oldTimeout := ExecTimeout
+		ExecTimeout = 10 * time.Millisecond
+		defer func() { ExecTimeout = oldTimeout }()
+
+		_, err := ResolveVariable("exec:sleep 1")
+		if err == nil || err.Error() != "Command 'sleep' timed out after 10ms." {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	})
+}
+
+func TestResolveSecretKeyRef(t *testing.T) {
+	oldDir := SecretsDir
+	SecretsDir = t.TempDir()
+	defer func() { SecretsDir = oldDir }()
+
+	if err := os.MkdirAll(filepath.Join(SecretsDir, "keystore"), 0o755); err != nil {
+		t.Fatalf("Failed to create secret dir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(SecretsDir, "keystore", "password"), []byte("changeit\n"), 0o600); err != nil {
+		t.Fatalf("Failed to write secret: %v", err)
+	}
+
+	result, err := ResolveVariable("secretKeyRef:keystore/password")
+	if err != nil {
+		t.Fatalf("Failed to resolve variable: %v", err)
+	}
+	if result != "changeit" {
+		t.Fatalf("Expected 'changeit', got '%s'", result)
+	}
+
+	for _, ref := range []string{"keystore", "keystore/", "../keystore/password", "keystore/nested/password"} {
+		_, err := ResolveVariable("secretKeyRef:" + ref)
+		if err == nil || err.Error() != "Invalid secret reference '"+ref+"'. Must be 'secret/key'." {
+			t.Errorf("Unexpected error for '%s': %v", ref, err)
+		}
+	}
+
+	if _, err := ResolveVariable("secretKeyRef:keystore/missing"); err == nil {
+		t.Errorf("Expected an error, got nil")
+	}
+}
+
+func TestSearchKeyPathInFile(t *testing.T) {
+	testCases := []struct {
+		Name          string
+		Content       string
+		KeyPath       string
+		Expected      string
+		ExpectedError string
+	}{
+		{Name: "json", Content: `{"jks": {"password": "changeit"}}`, KeyPath: "$.jks.password", Expected: "changeit"},
+		{Name: "yaml", Content: "jks:\n  password: changeit\n", KeyPath: "$.jks.password", Expected: "changeit"},
+		{Name: "list index", Content: `{"keystores": [{"password": "a"}, {"password": "b"}]}`, KeyPath: "$.keystores[1].password", Expected: "b"},
+		{Name: "number", Content: `{"pin": 1234}`, KeyPath: "$.pin", Expected: "1234"},
+		{Name: "missing key", Content: `{"jks": {}}`, KeyPath: "$.jks.password", ExpectedError: "Key path '$.jks.password' not found in file '%s'."},
+		{Name: "index out of range", Content: `{"keystores": []}`, KeyPath: "$.keystores[0]", ExpectedError: "Key path '$.keystores[0]' not found in file '%s'."},
+		{Name: "not a value", Content: `{"jks": {"password": "changeit"}}`, KeyPath: "$.jks", ExpectedError: "Key path '$.jks' in file '%s' is not a value."},
+		{Name: "invalid key path", Content: `{}`, KeyPath: "$jks", ExpectedError: "Invalid key path '$jks'. Must look like '$.key.list[0]'."},
+		{Name: "missing bracket", Content: `{}`, KeyPath: "$.keystores[0", ExpectedError: "Invalid key path '$.keystores[0'. Missing ']'."},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			tmpfile, err := test_helpers.CreateTempFile(tc.Content)
+			if err != nil {
+				t.Fatalf("Failed to create temp file: %v", err)
+			}
+			defer os.Remove(tmpfile.Name())
+
+			result, err := ResolveVariable("file:" + tmpfile.Name() + "//" + tc.KeyPath)
+			if tc.ExpectedError != "" {
+				expected := tc.ExpectedError
+				if strings.Contains(expected, "%s") {
+					expected = fmt.Sprintf(expected, tmpfile.Name())
+				}
+				if err == nil || err.Error() != expected {
+					t.Fatalf("Expected error '%s', got '%v'", expected, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Failed to resolve variable: %v", err)
+			}
+			if result != tc.Expected {
+				t.Fatalf("Expected '%s', got '%s'", tc.Expected, result)
+			}
+		})
+	}
+}
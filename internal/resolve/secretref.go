@@ -0,0 +1,53 @@
+package resolve
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// secretKeyRefPrefix is the prefix to identify references to a key of a mounted secret
+const secretKeyRefPrefix = "secretKeyRef:"
+
+// DefaultSecretsDir is the directory the secrets are mounted to if neither the --secrets-dir flag
+// nor the CERTALERT_SECRETS_DIR environment variable is set.
+const DefaultSecretsDir = "/var/run/secrets/certalert"
+
+// SecretsDir is the directory the secrets of 'secretKeyRef:' references are mounted to. Every secret
+// is a directory containing a file per key, like a Kubernetes secret mounted as volume.
+var SecretsDir = DefaultSecretsDir
+
+func init() {
+	if dir, found := os.LookupEnv("CERTALERT_SECRETS_DIR"); found {
+		SecretsDir = dir
+	}
+	Register(secretKeyRefPrefix, resolveSecretKeyRef, false)
+}
+
+// resolveSecretKeyRef resolves a reference like 'keystore/password' to the key 'password' of the
+// secret 'keystore' mounted to SecretsDir, i.e. the content of the file '<SecretsDir>/keystore/password'.
+//
+// Parameters:
+//   - ref: string
+//     The name of the secret and the key, separated by a slash.
+//
+// Returns:
+//   - string
+//     The value of the key.
+//   - error
+//     An error if the reference is invalid or the key can't be read.
+func resolveSecretKeyRef(ref string) (string, error) {
+	name, key, found := strings.Cut(ref, "/")
+	if !found || name == "" || key == "" || strings.Contains(key, "/") || name == ".." || key == ".." {
+		return "", fmt.Errorf("Invalid secret reference '%s'. Must be 'secret/key'.", ref)
+	}
+
+	path := filepath.Join(SecretsDir, name, key)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("Failed to read key '%s' of secret '%s'. %v", key, name, err)
+	}
+
+	return strings.TrimSpace(string(data)), nil
+}
@@ -0,0 +1,185 @@
+package resolve
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+
+	"filippo.io/age"
+	"filippo.io/age/armor"
+	"gopkg.in/yaml.v3"
+)
+
+// sopsPrefix is the prefix to identify values of files encrypted with sops
+const sopsPrefix = encPrefix + "sops:"
+
+// sopsValue matches a value encrypted by sops, e.g. 'ENC[AES256_GCM,data:...,iv:...,tag:...,type:str]'.
+var sopsValue = regexp.MustCompile(`^ENC\[AES256_GCM,data:(.+),iv:(.+),tag:(.+),type:(.+)\]$`)
+
+// sopsMetadata represents the metadata sops adds to an encrypted file. Only the data key encrypted
+// with age is used.
+type sopsMetadata struct {
+	Age []struct {
+		Recipient string `yaml:"recipient"`
+		Enc       string `yaml:"enc"`
+	} `yaml:"age"`
+}
+
+func init() {
+	// The reference only names the encrypted file, so it is shown on the /config endpoint
+	Register(sopsPrefix, resolveSopsVariable, false)
+}
+
+// resolveSopsVariable decrypts a value of a JSON or YAML file encrypted with sops, e.g.
+// 'enc:sops:/secrets/keystores.enc.yaml//$.jks.password'. The data key of the file is decrypted with
+// the age identity file, so the file must be encrypted for one of its identities. Values sops left
+// unencrypted, e.g. because of 'unencrypted_suffix', are returned as is.
+//
+// Parameters:
+//   - filePathWithKeyPath: string
+//     The path of the encrypted file and the key path of the value, separated by '//'.
+//
+// Returns:
+//   - string
+//     The decrypted value.
+//   - error
+//     An error if the file can't be read, has no data key for age or the value can't be decrypted.
+func resolveSopsVariable(filePathWithKeyPath string) (string, error) {
+	separatorIndex := strings.LastIndex(filePathWithKeyPath, keyDelim)
+	if separatorIndex == -1 || !strings.HasPrefix(filePathWithKeyPath[separatorIndex+len(keyDelim):], keyPathRoot) {
+		return "", fmt.Errorf("Invalid sops reference '%s'. Must look like '/path/to/file.enc.yaml//$.key'.", filePathWithKeyPath)
+	}
+	filePath := os.ExpandEnv(filePathWithKeyPath[:separatorIndex])
+	keyPath := filePathWithKeyPath[separatorIndex+len(keyDelim):]
+
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return "", fmt.Errorf("Failed to open file '%s'. %v", filePath, err)
+	}
+
+	// JSON is a subset of YAML, so both formats are parsed as YAML
+	var content map[string]any
+	if err := yaml.Unmarshal(data, &content); err != nil {
+		return "", fmt.Errorf("Failed to parse file '%s' as JSON or YAML. %v", filePath, err)
+	}
+	var file struct {
+		Sops *sopsMetadata `yaml:"sops"`
+	}
+	if err := yaml.Unmarshal(data, &file); err != nil || file.Sops == nil {
+		return "", fmt.Errorf("File '%s' is not encrypted with sops.", filePath)
+	}
+
+	value, keys, err := valueAtKeyPath(content, keyPath, filePath)
+	if err != nil {
+		return "", err
+	}
+	ciphertext, ok := value.(string)
+	if !ok || !sopsValue.MatchString(ciphertext) {
+		return fmt.Sprint(value), nil
+	}
+
+	dataKey, err := sopsDataKey(*file.Sops, filePath)
+	if err != nil {
+		return "", err
+	}
+
+	// sops authenticates every value with the keys of its path, e.g. 'jks:password:'
+	plaintext, err := decryptSopsValue(ciphertext, dataKey, strings.Join(keys, ":")+":")
+	if err != nil {
+		return "", fmt.Errorf("Failed to decrypt key path '%s' of file '%s'. %v", keyPath, filePath, err)
+	}
+	return plaintext, nil
+}
+
+// sopsDataKey decrypts the data key of a file encrypted with sops with the age identity file.
+//
+// Parameters:
+//   - metadata: sopsMetadata
+//     The sops metadata of the file.
+//   - filePath: string
+//     The path of the file, used in errors.
+//
+// Returns:
+//   - []byte
+//     The data key the values of the file are encrypted with.
+//   - error
+//     An error if the file has no data key for age or none of the identities can decrypt it.
+func sopsDataKey(metadata sopsMetadata, filePath string) ([]byte, error) {
+	if len(metadata.Age) == 0 {
+		return nil, fmt.Errorf("File '%s' is not encrypted for age.", filePath)
+	}
+
+	identities, err := readIdentities(AgeIdentityFile)
+	if err != nil {
+		return nil, err
+	}
+
+	var errs []string
+	for _, recipient := range metadata.Age {
+		r, err := age.Decrypt(armor.NewReader(strings.NewReader(recipient.Enc)), identities...)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", recipient.Recipient, err))
+			continue
+		}
+		dataKey, err := io.ReadAll(r)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", recipient.Recipient, err))
+			continue
+		}
+		return dataKey, nil
+	}
+
+	return nil, fmt.Errorf("Failed to decrypt the data key of file '%s'. %s", filePath, strings.Join(errs, ", "))
+}
+
+// decryptSopsValue decrypts a value encrypted by sops with AES-GCM.
+//
+// Parameters:
+//   - ciphertext: string
+//     The encrypted value, e.g. 'ENC[AES256_GCM,data:...,iv:...,tag:...,type:str]'.
+//   - dataKey: []byte
+//     The data key of the file.
+//   - additionalData: string
+//     The keys of the path of the value joined with ':', followed by ':'.
+//
+// Returns:
+//   - string
+//     The decrypted value.
+//   - error
+//     An error if the value is malformed or can't be authenticated.
+func decryptSopsValue(ciphertext string, dataKey []byte, additionalData string) (string, error) {
+	match := sopsValue.FindStringSubmatch(ciphertext)
+	if match == nil {
+		return "", fmt.Errorf("Invalid sops value.")
+	}
+
+	var parts [3][]byte
+	for i, encoded := range match[1:4] {
+		decoded, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return "", fmt.Errorf("Invalid sops value. %v", err)
+		}
+		parts[i] = decoded
+	}
+	data, iv, tag := parts[0], parts[1], parts[2]
+
+	block, err := aes.NewCipher(dataKey)
+	if err != nil {
+		return "", err
+	}
+	gcm, err := cipher.NewGCMWithNonceSize(block, len(iv))
+	if err != nil {
+		return "", err
+	}
+
+	plaintext, err := gcm.Open(nil, iv, append(data, tag...), []byte(additionalData))
+	if err != nil {
+		return "", err
+	}
+	return string(plaintext), nil
+}
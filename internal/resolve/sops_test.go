@@ -0,0 +1,139 @@
+package resolve
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"filippo.io/age"
+	"filippo.io/age/armor"
+	"github.com/stretchr/testify/assert"
+)
+
+// encryptSopsValue encrypts a value like sops, with a 32 byte IV and the path of the value as additional data.
+func encryptSopsValue(t *testing.T, plaintext string, dataKey []byte, additionalData string) string {
+	iv := make([]byte, 32)
+	if _, err := rand.Read(iv); err != nil {
+		t.Fatalf("Failed to generate IV: %v", err)
+	}
+	block, _ := aes.NewCipher(dataKey)
+	gcm, _ := cipher.NewGCMWithNonceSize(block, len(iv))
+	out := gcm.Seal(nil, iv, []byte(plaintext), []byte(additionalData))
+
+	return fmt.Sprintf("ENC[AES256_GCM,data:%s,iv:%s,tag:%s,type:str]",
+		base64.StdEncoding.EncodeToString(out[:len(out)-aes.BlockSize]),
+		base64.StdEncoding.EncodeToString(iv),
+		base64.StdEncoding.EncodeToString(out[len(out)-aes.BlockSize:]))
+}
+
+// encryptSopsDataKey encrypts the data key for the recipient like sops, as ASCII armored age ciphertext.
+func encryptSopsDataKey(t *testing.T, dataKey []byte, recipient *age.X25519Recipient) string {
+	var buf bytes.Buffer
+	aw := armor.NewWriter(&buf)
+	w, err := age.Encrypt(aw, recipient)
+	if err != nil {
+		t.Fatalf("Failed to encrypt data key: %v", err)
+	}
+	w.Write(dataKey)
+	w.Close()
+	aw.Close()
+	return buf.String()
+}
+
+// indent indents every line of the text, so it can be written as YAML block scalar.
+func indent(text, prefix string) string {
+	return prefix + strings.ReplaceAll(strings.TrimSpace(text), "\n", "\n"+prefix)
+}
+
+func TestResolveSopsVariable(t *testing.T) {
+	identity := writeIdentityFile(t)
+
+	dataKey := make([]byte, 32)
+	if _, err := rand.Read(dataKey); err != nil {
+		t.Fatalf("Failed to generate data key: %v", err)
+	}
+	other, _ := age.GenerateX25519Identity()
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "secrets.enc.yaml")
+	content := fmt.Sprintf(`jks:
+    password: %s
+keystores:
+    - name: regular
+      password: %s
+    - name: moved
+      password: %s
+comment_unencrypted: plain
+sops:
+    age:
+        - recipient: %s
+          enc: |
+%s
+        - recipient: %s
+          enc: |
+%s
+    version: 3.9.0
+`,
+		encryptSopsValue(t, "changeit", dataKey, "jks:password:"),
+		encryptSopsValue(t, "password", dataKey, "keystores:password:"),
+		encryptSopsValue(t, "password", dataKey, "jks:password:"),
+		other.Recipient(), indent(encryptSopsDataKey(t, dataKey, other.Recipient()), "            "),
+		identity.Recipient(), indent(encryptSopsDataKey(t, dataKey, identity.Recipient()), "            "),
+	)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+
+	plain := filepath.Join(dir, "plain.yaml")
+	if err := os.WriteFile(plain, []byte("jks:\n  password: changeit\n"), 0o600); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+
+	t.Run("decrypts values", func(t *testing.T) {
+		value, err := ResolveVariable("enc:sops:" + path + "//$.jks.password")
+		assert.NoError(t, err)
+		assert.Equal(t, "changeit", value)
+
+		value, err = ResolveVariable("enc:sops:" + path + "//$.keystores[0].password")
+		assert.NoError(t, err)
+		assert.Equal(t, "password", value)
+	})
+
+	t.Run("returns unencrypted values", func(t *testing.T) {
+		value, err := ResolveVariable("enc:sops:" + path + "//$.comment_unencrypted")
+		assert.NoError(t, err)
+		assert.Equal(t, "plain", value)
+	})
+
+	t.Run("value moved to another key", func(t *testing.T) {
+		_, err := ResolveVariable("enc:sops:" + path + "//$.keystores[1].password")
+		assert.EqualError(t, err, "Failed to decrypt key path '$.keystores[1].password' of file '"+path+"'. cipher: message authentication failed")
+	})
+
+	t.Run("file for another identity", func(t *testing.T) {
+		writeIdentityFile(t)
+		_, err := ResolveVariable("enc:sops:" + path + "//$.jks.password")
+		assert.ErrorContains(t, err, "Failed to decrypt the data key of file '"+path+"'.")
+	})
+
+	t.Run("file not encrypted", func(t *testing.T) {
+		_, err := ResolveVariable("enc:sops:" + plain + "//$.jks.password")
+		assert.EqualError(t, err, "File '"+plain+"' is not encrypted with sops.")
+	})
+
+	t.Run("missing key path", func(t *testing.T) {
+		_, err := ResolveVariable("enc:sops:" + path)
+		assert.EqualError(t, err, "Invalid sops reference '"+path+"'. Must look like '/path/to/file.enc.yaml//$.key'.")
+	})
+
+	t.Run("is not sensitive", func(t *testing.T) {
+		assert.False(t, IsSensitive("enc:sops:"+path+"//$.jks.password"))
+	})
+}
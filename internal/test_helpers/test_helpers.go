@@ -3,6 +3,9 @@ package test_helpers
 import (
 	"fmt"
 	"os"
+	"reflect"
+	"strings"
+	"testing"
 )
 
 // CreateTempFile creates a temporary file with the given content and returns a pointer to it.
@@ -31,3 +34,60 @@ func ReadFile(filePath string) (*os.File, error) {
 	}
 	return file, nil
 }
+
+// SecretFieldNames are the substrings of a field name which mark it as possibly sensitive.
+var SecretFieldNames = []string{"password", "token", "secret"}
+
+// AssertSecretTags fails the test for every field below the given value whose name contains one of
+// SecretFieldNames but which has no `secret` struct tag. Fields holding a secret are tagged with
+// `secret:"true"`, fields like 'passwordKey' which only name a secret are tagged with `secret:"false"`.
+func AssertSecretTags(t testing.TB, v any) {
+	t.Helper()
+	for _, path := range UntaggedSecretFields(reflect.TypeOf(v)) {
+		t.Errorf("Field '%s' looks sensitive but has no 'secret' struct tag.", path)
+	}
+}
+
+// UntaggedSecretFields returns the paths of the fields below the given type whose name contains one of
+// SecretFieldNames but which have no `secret` struct tag. Structs, pointers, slices, arrays and maps are
+// walked recursively, every struct type is visited once.
+func UntaggedSecretFields(typ reflect.Type) []string {
+	var paths []string
+	walkSecretFields(typ, typ.String(), map[reflect.Type]bool{}, &paths)
+	return paths
+}
+
+// walkSecretFields collects the untagged secret fields of a type into paths.
+func walkSecretFields(typ reflect.Type, path string, visited map[reflect.Type]bool, paths *[]string) {
+	switch typ.Kind() {
+	case reflect.Pointer, reflect.Slice, reflect.Array, reflect.Map:
+		walkSecretFields(typ.Elem(), path, visited, paths)
+	case reflect.Struct:
+		if visited[typ] {
+			return
+		}
+		visited[typ] = true
+		for i := 0; i < typ.NumField(); i++ {
+			field := typ.Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			fieldPath := path + "." + field.Name
+			if _, tagged := field.Tag.Lookup("secret"); !tagged && looksSecret(field.Name) {
+				*paths = append(*paths, fieldPath)
+			}
+			walkSecretFields(field.Type, fieldPath, visited, paths)
+		}
+	}
+}
+
+// looksSecret reports whether a field name contains one of SecretFieldNames.
+func looksSecret(name string) bool {
+	name = strings.ToLower(name)
+	for _, s := range SecretFieldNames {
+		if strings.Contains(name, s) {
+			return true
+		}
+	}
+	return false
+}
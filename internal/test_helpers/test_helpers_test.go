@@ -2,6 +2,7 @@ package test_helpers
 
 import (
 	"os"
+	"reflect"
 	"testing"
 )
 
@@ -53,3 +54,24 @@ func TestReadFile(t *testing.T) {
 		}
 	})
 }
+
+func TestUntaggedSecretFields(t *testing.T) {
+	type auth struct {
+		Username string
+		Password string `secret:"true"`
+		TokenKey string `secret:"false"`
+		APIToken string
+	}
+	type config struct {
+		Name    string
+		Auth    *auth
+		Sources []auth
+		Secrets map[string]string
+	}
+
+	paths := UntaggedSecretFields(reflect.TypeOf(config{}))
+	expected := []string{"test_helpers.config.Auth.APIToken", "test_helpers.config.Secrets"}
+	if !reflect.DeepEqual(paths, expected) {
+		t.Errorf("Expected %v, got %v", expected, paths)
+	}
+}